@@ -4,9 +4,11 @@
 package browser
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/teocci/go-chrome-cookies/core/data"
@@ -18,9 +20,15 @@ type Browser interface {
 	// InitSecretKey is init chrome secret key, firefox's key always empty
 	InitSecretKey() error
 
+	// InitSecretKeyContext is InitSecretKey bounded by ctx (D-Bus calls, keychain lookup)
+	InitSecretKeyContext(ctx context.Context) error
+
 	// GetName return browser name
 	GetName() string
 
+	// GetProfilePath return the profile directory of the browser
+	GetProfilePath() string
+
 	// GetSecretKey return browser secret key
 	GetSecretKey() []byte
 
@@ -34,6 +42,24 @@ type Browser interface {
 	ListItems() []string
 }
 
+// optInItems are left out of "all" because they copy a lot of data, they are extracted
+// when asked for by name
+var optInItems = map[string]bool{
+	data.ItemNameCache: true,
+}
+
+// DefaultItems return the items of the browser that "all" extracts, sorted
+func DefaultItems(b Browser) []string {
+	var items []string
+	for _, v := range b.ListItems() {
+		if !optInItems[v] {
+			items = append(items, v)
+		}
+	}
+	sort.Strings(items)
+	return items
+}
+
 // PickBrowser return a list of browser interface
 func PickBrowser(name string) ([]Browser, error) {
 	var browsers []Browser
@@ -55,6 +81,101 @@ func PickBrowser(name string) ([]Browser, error) {
 	return nil, throw.ErrorBrowserNotSupported()
 }
 
+func ListBrowser() []string {
+	var l []string
+	for k := range browserList {
+		l = append(l, k)
+	}
+	return l
+}
+
+// PickProfiles return one browser interface per profile directory found for the browser,
+// name can be "all". Profiles are told apart by the directory name appended to the browser name,
+// and by an index when several users have a profile with the same directory name.
+func PickProfiles(name string) ([]Browser, error) {
+	name = strings.ToLower(name)
+	var names []string
+	if name == "all" {
+		names = ListBrowser()
+	} else if _, ok := browserList[name]; ok {
+		names = []string{name}
+	} else {
+		return nil, throw.ErrorBrowserNotSupported()
+	}
+	sort.Strings(names)
+	var browsers []Browser
+	seen := make(map[string]int)
+	for _, n := range names {
+		choice := browserList[n]
+		browsers = append(browsers, globProfiles(seen, choice.ProfilePath, choice.KeyPath, choice.Name, choice.Storage, choice.New)...)
+	}
+	return browsers, nil
+}
+
+// ExpandProfiles replace the browsers with a glob profile path, like the ones of PickBrowser,
+// by one browser per profile directory the glob matches. The other browsers are kept.
+func ExpandProfiles(browsers []Browser) []Browser {
+	var expanded []Browser
+	seen := make(map[string]int)
+	for _, b := range browsers {
+		if !strings.ContainsAny(b.GetProfilePath(), "*?[") {
+			expanded = append(expanded, b)
+			continue
+		}
+		switch v := b.(type) {
+		case *Chromium:
+			expanded = append(expanded, globProfiles(seen, v.profilePath, v.keyPath, v.name, v.storage, NewChromium)...)
+		case *Firefox:
+			expanded = append(expanded, globProfiles(seen, v.profilePath, v.keyPath, v.name, "", NewFirefox)...)
+		default:
+			expanded = append(expanded, b)
+		}
+	}
+	return expanded
+}
+
+func globProfiles(seen map[string]int, pattern, key, name, storage string, newBrowser func(profile, key, name, storage string) (Browser, error)) []Browser {
+	profiles, err := filepath.Glob(filepath.Clean(pattern))
+	if err != nil {
+		logger.Error(err)
+		return nil
+	}
+	var browsers []Browser
+	for _, profile := range profiles {
+		if !isProfileDir(profile) {
+			continue
+		}
+		b, err := newBrowser(profile, key, profileName(seen, name, profile), storage)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		browsers = append(browsers, b)
+	}
+	return browsers
+}
+
+// profileName return "<browser> <profile dir>", the same name seen again gets its count appended
+// so every profile is written to its own output file
+func profileName(seen map[string]int, browserName, profile string) string {
+	name := fmt.Sprintf("%s %s", browserName, filepath.Base(profile))
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		return fmt.Sprintf("%s %d", name, n)
+	}
+	return name
+}
+
+// isProfileDir check for the preference file every chromium and firefox profile has
+func isProfileDir(dir string) bool {
+	for _, file := range []string{"Preferences", "prefs.js"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // PickCustomBrowser pick single browser with custom browser profile path and key file path (Windows only).
 // If custom key file path is empty, but the current browser requires key file (chromium for Windows version > 80)
 // key file path will be automatically found in the profile path's parent directory.
@@ -90,6 +211,29 @@ func PickCustomBrowser(browserName, cusProfile, cusKey string) ([]Browser, error
 	}
 }
 
+// NewCookieImporter return a cookie importer bound to the cookie database of the target browser.
+// Chromium browsers must call InitSecretKey first, the key is used to encrypt the imported values.
+func NewCookieImporter(target Browser, dryRun bool) (*data.CookieImporter, error) {
+	switch b := target.(type) {
+	case *Chromium:
+		path, err := GetItemPath(fmt.Sprintf("%s/Network", b.profilePath), data.ChromeCookieFile)
+		if err != nil {
+			path, err = GetItemPath(b.profilePath, data.ChromeCookieFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return data.NewCookieImporter(path, b.GetSecretKey(), dryRun), nil
+	case *Firefox:
+		path, err := GetItemPath(b.profilePath, data.FirefoxCookieFile)
+		if err != nil {
+			return nil, err
+		}
+		return data.NewCookieImporter(path, nil, dryRun), nil
+	}
+	return nil, throw.ErrorBrowserNotSupported()
+}
+
 // GetItemPath try to get item file path with the browser's profile path
 // default key file path is in the parent directory of the profile dir, and name is [Local State]
 func GetItemPath(profilePath, file string) (string, error) {
@@ -103,6 +247,27 @@ func GetItemPath(profilePath, file string) (string, error) {
 	return "", fmt.Errorf("find %s failed", file)
 }
 
+// cacheDirReplacer turn a profile path into the path the browser keeps its cache in,
+// linux uses ~/.cache, Windows the local app data and macOS ~/Library/Caches
+var cacheDirReplacer = strings.NewReplacer(
+	"/.config/", "/.cache/",
+	"/.mozilla/", "/.cache/mozilla/",
+	"/AppData/Roaming/", "/AppData/Local/",
+	"/Library/Application Support/", "/Library/Caches/",
+)
+
+// itemPath is GetItemPath, the cache is also looked for in the cache directory of the
+// profile when it is not in the profile
+func itemPath(profilePath, itemName, file string) (string, error) {
+	p, err := GetItemPath(profilePath, file)
+	if err != nil && itemName == data.ItemNameCache {
+		if cacheDir := cacheDirReplacer.Replace(filepath.ToSlash(profilePath)); cacheDir != filepath.ToSlash(profilePath) {
+			return GetItemPath(filepath.FromSlash(cacheDir), file)
+		}
+	}
+	return p, err
+}
+
 // getKeyPath try to get key file path with the browser's profile path
 // default key file path is in the parent directory of the profile dir, and name is [Local State]
 func getKeyPath(profilePath string) (string, error) {
@@ -136,4 +301,4 @@ func getParentDirectory(dir string) string {
 		return string([]rune(dir)[:length])
 	}
 	return ""
-}
\ No newline at end of file
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/core/data"
 	"github.com/teocci/go-chrome-cookies/logger"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -47,8 +49,13 @@ func TestPickBrowser(t *testing.T) {
 	fmt.Printf("key: %s\n", reflect.ValueOf(key).String())
 	err = item.CopyDB()
 	if err != nil {
-		fmt.Printf("err: %s\n", err)
+		t.Skipf("err: %s", err)
 	}
+	defer func() {
+		if err := item.Release(); err != nil {
+			fmt.Printf("err: %s\n", err)
+		}
+	}()
 
 	err = item.ChromeParse(key)
 	if err != nil {
@@ -83,7 +90,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestDB(t *testing.T) {
-	cookieDB, err := sql.Open("sqlite3", data.ChromeCookieFile)
+	cookieDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), data.ChromeCookieFile))
 	if err != nil {
 		fmt.Printf("err: %s\n", err)
 	}
@@ -113,3 +120,40 @@ func TestDB(t *testing.T) {
 		fmt.Println("name:", name )
 	}
 }
+
+func TestProfileName(t *testing.T) {
+	seen := make(map[string]int)
+	got := []string{
+		profileName(seen, chromeName, "/home/a/.config/google-chrome/Default"),
+		profileName(seen, chromeName, "/home/b/.config/google-chrome/Default"),
+		profileName(seen, chromeName, "/home/b/.config/google-chrome/Profile 1"),
+	}
+	want := []string{chromeName + " Default", chromeName + " Default 2", chromeName + " Profile 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpandProfiles(t *testing.T) {
+	home := t.TempDir()
+	for _, dir := range []string{"a/Default", "b/Default", "b/Crashpad"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if dir != "b/Crashpad" {
+			if err := os.WriteFile(filepath.Join(home, dir, "Preferences"), nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	glob, _ := NewChromium(filepath.Join(home, "*", "*"), "", chromeName, "storage")
+	single, _ := NewFirefox(filepath.Join(home, "a", "Default"), "", firefoxName, "")
+	got := ExpandProfiles([]Browser{glob, single})
+	if len(got) != 3 || got[2] != single {
+		t.Fatalf("got %d browsers, want the 2 chrome profiles and firefox", len(got))
+	}
+	if got[0].GetName() != chromeName+" Default" || got[1].GetName() != chromeName+" Default 2" ||
+		got[1].GetProfilePath() != filepath.Join(home, "b", "Default") || got[1].(*Chromium).GetStorage() != "storage" {
+		t.Errorf("unexpected profiles %s %s", got[0].GetName(), got[1].GetProfilePath())
+	}
+}
@@ -4,6 +4,7 @@
 package browser
 
 import (
+	"context"
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/core/data"
 	"github.com/teocci/go-chrome-cookies/core/throw"
@@ -27,6 +28,10 @@ var chromiumItems = map[string]struct {
 		mainFile: data.ChromeHistoryFile,
 		newItem:  data.NewHistoryData,
 	},
+	data.ItemNameVisit: {
+		mainFile: data.ChromeHistoryFile,
+		newItem:  data.NewVisits,
+	},
 	data.ItemNameDownload: {
 		mainFile: data.ChromeDownloadFile,
 		newItem:  data.NewDownloads,
@@ -39,6 +44,66 @@ var chromiumItems = map[string]struct {
 		mainFile: data.ChromeCreditFile,
 		newItem:  data.NewCCards,
 	},
+	data.ItemNameAutofill: {
+		mainFile: data.ChromeWebDataFile,
+		newItem:  data.NewAutofill,
+	},
+	data.ItemNameAddress: {
+		mainFile: data.ChromeWebDataFile,
+		newItem:  data.NewAddresses,
+	},
+	data.ItemNameSearch: {
+		mainFile: data.ChromeWebDataFile,
+		newItem:  data.NewSearchEngines,
+	},
+	data.ItemNameShortcut: {
+		mainFile: data.ChromeShortcutFile,
+		newItem:  data.NewShortcuts,
+	},
+	data.ItemNamePredictor: {
+		mainFile: data.ChromePredictFile,
+		newItem:  data.NewPredictor,
+	},
+	data.ItemNameExtension: {
+		mainFile: data.ChromePrefsFile,
+		newItem:  data.NewExtensions,
+	},
+	data.ItemNameStorage: {
+		mainFile: data.ChromeStorageDir,
+		newItem:  data.NewWebStorage,
+	},
+	data.ItemNameIndexedDB: {
+		mainFile: data.ChromeIndexedDBDir,
+		newItem:  data.NewIndexedDB,
+	},
+	data.ItemNameSession: {
+		mainFile: data.ChromeSessionsDir,
+		newItem:  data.NewSessions,
+	},
+	data.ItemNamePermission: {
+		mainFile: data.ChromePrefsFile,
+		newItem:  data.NewPermissions,
+	},
+	data.ItemNameTopSite: {
+		mainFile: data.ChromeTopSitesFile,
+		newItem:  data.NewTopSites,
+	},
+	data.ItemNameFavicon: {
+		mainFile: data.ChromeFaviconFile,
+		newItem:  data.NewFavicons,
+	},
+	data.ItemNameSetting: {
+		mainFile: data.ChromePrefsFile,
+		newItem:  data.NewSettings,
+	},
+	data.ItemNameNetwork: {
+		mainFile: data.ChromeTransportFile,
+		newItem:  data.NewNetworkState,
+	},
+	data.ItemNameCache: {
+		mainFile: data.ChromeCacheDir,
+		newItem:  data.NewCache,
+	},
 }
 
 type Chromium struct {
@@ -74,12 +139,20 @@ func (c *Chromium) SetSecretKey(secretKey []byte) {
 	c.secretKey = secretKey
 }
 
+// GetProfilePath return the profile directory, it can be a glob pattern
+func (c *Chromium) GetProfilePath() string {
+	return c.profilePath
+}
+
 // GetAllItems return all chromium items from browser
 // If it can't find the item path, log error then continue
 func (c *Chromium) GetAllItems() ([]data.Item, error) {
 	var items []data.Item
 	for item, choice := range chromiumItems {
-		m, err := GetItemPath(c.profilePath, choice.mainFile)
+		if optInItems[item] {
+			continue
+		}
+		m, err := itemPath(c.profilePath, item, choice.mainFile)
 		if err != nil {
 			logger.Debugf("%s find %s file failed, ERR:%s", c.name, item, err)
 			continue
@@ -100,7 +173,7 @@ func (c *Chromium) GetItem(itemName string) (data.Item, error) {
 			path = fmt.Sprintf("%s/Network", c.profilePath)
 		}
 
-		m, err := GetItemPath(path, item.mainFile)
+		m, err := itemPath(path, itemName, item.mainFile)
 		if err != nil {
 			logger.Debugf("%s find %s file failed, ERR:%s", c.name, item.mainFile, err)
 		}
@@ -112,13 +185,22 @@ func (c *Chromium) GetItem(itemName string) (data.Item, error) {
 }
 
 func (c *Chromium) InitSecretKey() error {
-	err := InitSecretKey(c)
+	return c.InitSecretKeyContext(context.Background())
+}
+
+func (c *Chromium) InitSecretKeyContext(ctx context.Context) error {
+	err := InitSecretKeyContext(ctx, c)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// InitSecretKey is InitSecretKeyContext without deadline
+func InitSecretKey(c *Chromium) error {
+	return InitSecretKeyContext(context.Background(), c)
+}
+
 func (c *Chromium) ListItems() []string {
 	var l []string
 	for k := range chromiumItems {
@@ -1,16 +1,20 @@
 // Package browser
 // Created by Teocci.
 // Author: teocci@yandex.com on 2021-Aug-12
+//go:build !windows && !plan9 && !nacl && !linux
 // +build !windows,!plan9,!nacl,!linux
 
 package browser
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"errors"
 	"os/exec"
 
+	"github.com/teocci/go-chrome-cookies/core/throw"
+
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -124,13 +128,15 @@ var (
 	}
 )
 
-func InitSecretKey(c *Chromium) error {
+// InitSecretKeyContext read the safe storage password from the keychain, the security
+// command is killed when ctx is done
+func InitSecretKeyContext(ctx context.Context, c *Chromium) error {
 	var (
 		cmd            *exec.Cmd
 		stdout, stderr bytes.Buffer
 	)
 	// ➜ security find-generic-password -wa 'Chrome'
-	cmd = exec.Command("security", "find-generic-password", "-wa", c.GetStorage())
+	cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-wa", c.GetStorage())
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	err := cmd.Run()
@@ -144,7 +150,7 @@ func InitSecretKey(c *Chromium) error {
 	temp := stdout.Bytes()
 	chromeSecret := temp[:len(temp)-1]
 	if chromeSecret == nil {
-		return ThrowErrorChromeSecretIsEmpty()
+		return throw.ErrorChromeSecretIsEmpty()
 	}
 	var chromeSalt = []byte("saltysalt")
 	// @https://source.chromium.org/chromium/chromium/src/+/master:components/os_crypt/os_crypt_mac.mm;l=157
@@ -4,6 +4,7 @@
 package browser
 
 import (
+	"context"
 	"github.com/teocci/go-chrome-cookies/core/data"
 	"github.com/teocci/go-chrome-cookies/core/throw"
 	"github.com/teocci/go-chrome-cookies/logger"
@@ -33,6 +34,10 @@ var firefoxItems = map[string]struct {
 		mainFile: data.FirefoxDataFile,
 		newItem:  data.NewHistoryData,
 	},
+	data.ItemNameVisit: {
+		mainFile: data.FirefoxDataFile,
+		newItem:  data.NewVisits,
+	},
 	data.ItemNameDownload: {
 		mainFile: data.FirefoxDataFile,
 		newItem:  data.NewDownloads,
@@ -42,6 +47,61 @@ var firefoxItems = map[string]struct {
 		subFile:  data.FirefoxLoginFile,
 		newItem:  data.NewFPasswords,
 	},
+	data.ItemNameAutofill: {
+		mainFile: data.FirefoxFormFile,
+		newItem:  data.NewAutofill,
+	},
+	data.ItemNameAddress: {
+		mainFile: data.FirefoxAddressFile,
+		newItem:  data.NewAddresses,
+	},
+	data.ItemNameSearch: {
+		mainFile: data.FirefoxSearchFile,
+		newItem:  data.NewSearchEngines,
+	},
+	data.ItemNameExtension: {
+		mainFile: data.FirefoxExtFile,
+		subFile:  data.FirefoxAddonsFile,
+		newItem:  data.NewExtensions,
+	},
+	data.ItemNameStorage: {
+		mainFile: data.FirefoxStorageDir,
+		newItem:  data.NewWebStorage,
+	},
+	data.ItemNameIndexedDB: {
+		mainFile: data.FirefoxStorageDir,
+		newItem:  data.NewIndexedDB,
+	},
+	data.ItemNameSession: {
+		mainFile: data.FirefoxSessionDir,
+		newItem:  data.NewSessions,
+	},
+	data.ItemNamePermission: {
+		mainFile: data.FirefoxPermFile,
+		newItem:  data.NewPermissions,
+	},
+	data.ItemNameTopSite: {
+		mainFile: data.FirefoxDataFile,
+		newItem:  data.NewTopSites,
+	},
+	data.ItemNameFavicon: {
+		mainFile: data.FirefoxFaviconFile,
+		newItem:  data.NewFavicons,
+	},
+	data.ItemNameSetting: {
+		mainFile: data.FirefoxPrefsFile,
+		newItem:  data.NewSettings,
+	},
+	// every network state file is optional, the item is looked up by prefs.js and
+	// the SiteSecurityServiceState.bin of recent versions is not supported
+	data.ItemNameNetwork: {
+		mainFile: data.FirefoxPrefsFile,
+		newItem:  data.NewNetworkState,
+	},
+	data.ItemNameCache: {
+		mainFile: data.FirefoxCacheDir,
+		newItem:  data.NewCache,
+	},
 }
 
 // NewFirefox return firefox browser interface
@@ -49,10 +109,18 @@ func NewFirefox(profile, key, name, storage string) (Browser, error) {
 	return &Firefox{profilePath: profile, keyPath: key, name: name}, nil
 }
 
+// GetProfilePath return the profile directory, it can be a glob pattern
+func (f *Firefox) GetProfilePath() string {
+	return f.profilePath
+}
+
 // GetAllItems return all item with firefox
 func (f *Firefox) GetAllItems() ([]data.Item, error) {
 	var items []data.Item
 	for item, choice := range firefoxItems {
+		if optInItems[item] {
+			continue
+		}
 		var (
 			sub, main string
 			err       error
@@ -64,7 +132,7 @@ func (f *Firefox) GetAllItems() ([]data.Item, error) {
 				continue
 			}
 		}
-		main, err = GetItemPath(f.profilePath, choice.mainFile)
+		main, err = itemPath(f.profilePath, item, choice.mainFile)
 		if err != nil {
 			logger.Debugf("%s find %s file failed, ERR:%s", f.name, item, err)
 			continue
@@ -89,7 +157,7 @@ func (f *Firefox) GetItem(itemName string) (data.Item, error) {
 				logger.Debugf("%s find %s file failed, ERR:%s", f.name, item.subFile, err)
 			}
 		}
-		main, err = GetItemPath(f.profilePath, item.mainFile)
+		main, err = itemPath(f.profilePath, itemName, item.mainFile)
 		if err != nil {
 			logger.Debugf("%s find %s file failed, ERR:%s", f.name, item.mainFile, err)
 		}
@@ -117,6 +185,11 @@ func (f *Firefox) InitSecretKey() error {
 	return nil
 }
 
+// InitSecretKeyContext for firefox is always nil
+func (f *Firefox) InitSecretKeyContext(ctx context.Context) error {
+	return nil
+}
+
 func (f *Firefox) ListItems() []string {
 	var l []string
 	for k := range firefoxItems {
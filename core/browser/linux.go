@@ -1,13 +1,19 @@
 // Package browser
 // Created by Teocci.
 // Author: teocci@yandex.com on 2021-Aug-12
+//go:build !windows && !plan9 && !nacl && !darwin
 // +build !windows,!plan9,!nacl,!darwin
 
 package browser
 
 import (
+	"context"
 	"crypto/sha1"
+	"strings"
+	"time"
+
 	"github.com/godbus/dbus/v5"
+	"github.com/teocci/go-chrome-cookies/core/throw"
 	"github.com/teocci/go-chrome-cookies/logger"
 
 	keyring "github.com/ppacher/go-dbus-keyring"
@@ -29,6 +35,9 @@ const (
 	vivaldiProfilePath        = "/home/*/.config/vivaldi/*/"
 )
 
+// dbusCloseTimeout bounds the Secret Service session close, it doesn't use the caller ctx
+const dbusCloseTimeout = 2 * time.Second
+
 const (
 	chromeStorageName     = "Chrome Safe Storage"
 	chromiumStorageName   = "chromium Safe Storage"
@@ -117,41 +126,56 @@ var (
 	}
 )
 
-func InitSecretKey(c *Chromium) error {
+// InitSecretKeyContext read the safe storage secret from the Secret Service, every D-Bus
+// call is bound to ctx so a hung keyring prompt can be cancelled or timed out
+func InitSecretKeyContext(ctx context.Context, c *Chromium) error {
 	// what is d-bus @https://dbus.freedesktop.org/
 	var chromeSecret []byte
 	conn, err := dbus.SessionBus()
 	if err != nil {
 		return err
 	}
-	svc, err := keyring.GetSecretService(conn)
-	if err != nil {
-		return err
-	}
-	session, err := svc.OpenSession()
+	svc := conn.Object(keyring.SecretServiceDest, keyring.SecretServicePath)
+	var (
+		output  dbus.Variant
+		session dbus.ObjectPath
+	)
+	err = svc.CallWithContext(ctx, keyring.ServiceInterface+".OpenSession", 0, keyring.AlgPlain, dbus.MakeVariant("")).Store(&output, &session)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		session.Close()
+		// the session is closed even when ctx is done, else it leaks in the service
+		closeCtx, cancel := context.WithTimeout(context.Background(), dbusCloseTimeout)
+		defer cancel()
+		call := conn.Object(keyring.SecretServiceDest, session).CallWithContext(closeCtx, keyring.SessionInterface+".Close", 0)
+		if call.Err != nil {
+			logger.Debug(call.Err)
+		}
 	}()
-	collections, err := svc.GetAllCollections()
+	collections, err := dbusObjectPaths(ctx, svc, keyring.ServiceInterface+".Collections")
 	if err != nil {
 		return err
 	}
 	for _, col := range collections {
-		items, err := col.GetAllItems()
+		items, err := dbusObjectPaths(ctx, conn.Object(keyring.SecretServiceDest, col), keyring.CollectionInterface+".Items")
 		if err != nil {
 			return err
 		}
-		for _, item := range items {
-			label, err := item.GetLabel()
+		for _, path := range items {
+			item := conn.Object(keyring.SecretServiceDest, path)
+			var label dbus.Variant
+			err = item.CallWithContext(ctx, dbusPropertiesGet, 0, keyring.ItemInterface, "Label").Store(&label)
 			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 				logger.Error(err)
 				continue
 			}
-			if label == c.GetStorage() {
-				se, err := item.GetSecret(session.Path())
+			if l, ok := label.Value().(string); ok && l == c.GetStorage() {
+				var se keyring.Secret
+				err = item.CallWithContext(ctx, keyring.ItemInterface+".GetSecret", 0, session).Store(&se)
 				if err != nil {
 					logger.Error(err)
 					return err
@@ -161,7 +185,7 @@ func InitSecretKey(c *Chromium) error {
 		}
 	}
 	if chromeSecret == nil {
-		return ThrowErrorDbusSecretIsEmpty()
+		return throw.ErrorDbusSecretIsEmpty()
 	}
 	var chromeSalt = []byte("saltysalt")
 	// @https://source.chromium.org/chromium/chromium/src/+/master:components/os_crypt/os_crypt_linux.cc
@@ -169,3 +193,20 @@ func InitSecretKey(c *Chromium) error {
 	c.SetSecretKey(key)
 	return nil
 }
+
+const dbusPropertiesGet = "org.freedesktop.DBus.Properties.Get"
+
+// dbusObjectPaths read an object path array property such as Collections or Items
+func dbusObjectPaths(ctx context.Context, obj dbus.BusObject, property string) ([]dbus.ObjectPath, error) {
+	i := strings.LastIndex(property, ".")
+	var v dbus.Variant
+	err := obj.CallWithContext(ctx, dbusPropertiesGet, 0, property[:i], property[i+1:]).Store(&v)
+	if err != nil {
+		return nil, err
+	}
+	paths, ok := v.Value().([]dbus.ObjectPath)
+	if !ok {
+		return nil, keyring.ErrInvalidType("[]ObjectPath", v.Value())
+	}
+	return paths, nil
+}
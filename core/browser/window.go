@@ -8,6 +8,7 @@
 package browser
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -142,17 +143,12 @@ var (
 	errBase64DecodeFailed = errors.New("decode base64 failed")
 )
 
-func ListBrowser() []string {
-	var l []string
-	for k := range browserList {
-		l = append(l, k)
-	}
-	return l
-}
-
-// InitSecretKey with win32 DPAPI
+// InitSecretKeyContext with win32 DPAPI
 // conference from @https://gist.github.com/akamajoris/ed2f14d817d5514e7548
-func InitSecretKey(c *Chromium) error {
+func InitSecretKeyContext(ctx context.Context, c *Chromium) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	keyPath := c.GetKeyPath()
 	if keyPath == "" {
 		return nil
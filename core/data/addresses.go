@@ -0,0 +1,381 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+// chromium autofill::FieldType values stored in local_addresses_type_tokens
+const (
+	fieldNameFirst     = 3
+	fieldNameMiddle    = 4
+	fieldNameLast      = 5
+	fieldNameFull      = 7
+	fieldEmail         = 9
+	fieldPhone         = 14
+	fieldCity          = 33
+	fieldState         = 34
+	fieldZipcode       = 35
+	fieldCountry       = 36
+	fieldCompany       = 60
+	fieldStreetAddress = 77
+
+	// addressListSep joins the emails and phones of the legacy profiles
+	addressListSep = ", "
+)
+
+// chromiumLegacyAddressColumns are the autofill_profiles columns before local_addresses
+var chromiumLegacyAddressColumns = []columnDefault{
+	{"guid", "''"},
+	{"company_name", "''"},
+	{"street_address", "''"},
+	{"city", "''"},
+	{"state", "''"},
+	{"zipcode", "''"},
+	{"country_code", "''"},
+	{"use_count", "0"},
+	{"use_date", "0"},
+	{"date_modified", "0"},
+}
+
+var chromiumAddressColumns = []columnDefault{
+	{"guid", "''"},
+	{"use_count", "0"},
+	{"use_date", "0"},
+	{"date_modified", "0"},
+}
+
+// Address is an address profile the browser fills forms with
+type Address struct {
+	GUID          string
+	FullName      string
+	FirstName     string
+	MiddleName    string
+	LastName      string
+	Company       string
+	StreetAddress string
+	City          string
+	State         string
+	Zipcode       string
+	Country       string
+	Emails        string
+	Phones        string
+	UseCount      int
+	DateLastUsed  time.Time
+	DateModified  time.Time
+}
+
+type addresses struct {
+	mainPath  string
+	tempDir   string
+	addresses []Address
+}
+
+func NewAddresses(main, sub string) Item {
+	return &addresses{mainPath: main}
+}
+
+func (a *addresses) ChromeParse(key []byte) error {
+	return a.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the local_addresses tables of recent chromium and fall back
+// to the autofill_profiles tables they replaced
+func (a *addresses) ChromeParseContext(ctx context.Context, key []byte) error {
+	webDB, err := sql.Open("sqlite3", filepath.Join(a.tempDir, ChromeWebDataFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := webDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	a.addresses = nil
+	if columns, err := tableColumns(ctx, webDB, "local_addresses"); err == nil {
+		return a.chromeAddresses(ctx, webDB, columns)
+	}
+	columns, err := tableColumns(ctx, webDB, "autofill_profiles")
+	if err != nil {
+		return err
+	}
+	return a.chromeLegacyAddresses(ctx, webDB, columns)
+}
+
+func (a *addresses) chromeAddresses(ctx context.Context, db *sql.DB, columns []tableColumn) error {
+	query := fmt.Sprintf(QueryChromiumAddresses, selectColumns(columns, chromiumAddressColumns), "local_addresses")
+	index, err := a.scanAddresses(ctx, db, query, func(rows *sql.Rows, address *Address) error {
+		var useDate, modified int64
+		err := rows.Scan(&address.GUID, &address.UseCount, &useDate, &modified)
+		address.DateLastUsed = filemgmt.UnixTime(useDate)
+		address.DateModified = filemgmt.UnixTime(modified)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return scanAddressRows(ctx, db, QueryChromiumAddrTokens, func(rows *sql.Rows) error {
+		var (
+			guid, value string
+			fieldType   int
+		)
+		if err := rows.Scan(&guid, &fieldType, &value); err != nil {
+			return err
+		}
+		if address, ok := index[guid]; ok {
+			setAddressField(address, fieldType, value)
+		}
+		return nil
+	})
+}
+
+func (a *addresses) chromeLegacyAddresses(ctx context.Context, db *sql.DB, columns []tableColumn) error {
+	query := fmt.Sprintf(QueryChromiumAddresses, selectColumns(columns, chromiumLegacyAddressColumns), "autofill_profiles")
+	index, err := a.scanAddresses(ctx, db, query, func(rows *sql.Rows, address *Address) error {
+		var useDate, modified int64
+		err := rows.Scan(&address.GUID, &address.Company, &address.StreetAddress, &address.City, &address.State,
+			&address.Zipcode, &address.Country, &address.UseCount, &useDate, &modified)
+		address.DateLastUsed = filemgmt.UnixTime(useDate)
+		address.DateModified = filemgmt.UnixTime(modified)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// the names, emails and phones tables are optional, a profile can live without them
+	err = scanAddressRows(ctx, db, QueryChromiumAddrNames, func(rows *sql.Rows) error {
+		var guid, first, middle, last, full sql.NullString
+		if err := rows.Scan(&guid, &first, &middle, &last, &full); err != nil {
+			return err
+		}
+		if address, ok := index[guid.String]; ok && address.FullName == "" {
+			address.FirstName, address.MiddleName = first.String, middle.String
+			address.LastName, address.FullName = last.String, full.String
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Debug(err)
+	}
+	for query, field := range map[string]int{QueryChromiumAddrEmails: fieldEmail, QueryChromiumAddrPhones: fieldPhone} {
+		err = scanAddressRows(ctx, db, query, func(rows *sql.Rows) error {
+			var guid, value sql.NullString
+			if err := rows.Scan(&guid, &value); err != nil {
+				return err
+			}
+			if address, ok := index[guid.String]; ok && value.String != "" {
+				setAddressField(address, field, value.String)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Debug(err)
+		}
+	}
+	return nil
+}
+
+// scanAddresses append an address for every row of query and return them by guid
+func (a *addresses) scanAddresses(ctx context.Context, db *sql.DB, query string, scan func(*sql.Rows, *Address) error) (map[string]*Address, error) {
+	var list []Address
+	err := scanAddressRows(ctx, db, query, func(rows *sql.Rows) error {
+		var address Address
+		if err := scan(rows, &address); err != nil {
+			logger.Warn(err)
+			return nil
+		}
+		list = append(list, address)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.addresses = list
+	index := make(map[string]*Address, len(list))
+	for i := range a.addresses {
+		index[a.addresses[i].GUID] = &a.addresses[i]
+	}
+	return index, nil
+}
+
+func scanAddressRows(ctx context.Context, db *sql.DB, query string, fn func(*sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	for rows.Next() {
+		if err = fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// setAddressField store value in the address field of the chromium field type, emails and
+// phones are appended since legacy profiles can have several
+func setAddressField(address *Address, fieldType int, value string) {
+	switch fieldType {
+	case fieldNameFirst:
+		address.FirstName = value
+	case fieldNameMiddle:
+		address.MiddleName = value
+	case fieldNameLast:
+		address.LastName = value
+	case fieldNameFull:
+		address.FullName = value
+	case fieldEmail:
+		address.Emails = joinAddressList(address.Emails, value)
+	case fieldPhone:
+		address.Phones = joinAddressList(address.Phones, value)
+	case fieldCity:
+		address.City = value
+	case fieldState:
+		address.State = value
+	case fieldZipcode:
+		address.Zipcode = value
+	case fieldCountry:
+		address.Country = value
+	case fieldCompany:
+		address.Company = value
+	case fieldStreetAddress:
+		address.StreetAddress = value
+	}
+}
+
+func joinAddressList(list, value string) string {
+	if value == "" {
+		return list
+	}
+	if list == "" {
+		return value
+	}
+	return list + addressListSep + value
+}
+
+func (a *addresses) FirefoxParse() error {
+	return a.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the address profiles of the firefox form autofill, they are
+// kept unencrypted in autofill-profiles.json
+func (a *addresses) FirefoxParseContext(ctx context.Context) error {
+	s, err := os.ReadFile(filepath.Join(a.tempDir, FirefoxAddressFile))
+	if err != nil {
+		return err
+	}
+	a.addresses = nil
+	for _, v := range gjson.GetBytes(s, "addresses").Array() {
+		address := Address{
+			GUID:          v.Get("guid").String(),
+			FullName:      v.Get("name").String(),
+			FirstName:     v.Get("given-name").String(),
+			MiddleName:    v.Get("additional-name").String(),
+			LastName:      v.Get("family-name").String(),
+			Company:       v.Get("organization").String(),
+			StreetAddress: v.Get("street-address").String(),
+			City:          v.Get("address-level2").String(),
+			State:         v.Get("address-level1").String(),
+			Zipcode:       v.Get("postal-code").String(),
+			Country:       v.Get("country").String(),
+			Emails:        v.Get("email").String(),
+			Phones:        v.Get("tel").String(),
+			UseCount:      int(v.Get("timesUsed").Int()),
+			DateLastUsed:  filemgmt.UnixMilliTime(v.Get("timeLastUsed").Int()),
+			DateModified:  filemgmt.UnixMilliTime(v.Get("timeLastModified").Int()),
+		}
+		if address.FullName == "" {
+			address.FullName = strings.Join(strings.Fields(address.FirstName+" "+address.MiddleName+" "+address.LastName), " ")
+		}
+		a.addresses = append(a.addresses, address)
+	}
+	return ctx.Err()
+}
+
+// Records return all the parsed addresses
+func (a *addresses) Records() []Address {
+	return a.addresses
+}
+
+func (a *addresses) CopyDB() error {
+	dir, err := copyToTempDir(a.mainPath)
+	if err != nil {
+		return err
+	}
+	a.tempDir = dir
+	return nil
+}
+
+func (a *addresses) Release() error {
+	return releaseTempDir(a.tempDir)
+}
+
+func (a *addresses) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(a.addresses, func(i, j int) bool {
+		return a.addresses[i].UseCount > a.addresses[j].UseCount
+	})
+	switch format {
+	case formatCSV:
+		err := a.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		a.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return a.outPutJsonLines(browser, dir)
+	default:
+		err := a.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (a *addresses) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAddress, GetFormatName(formatJson))
+	err := WriteToJson(filename, a.addresses)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d addresses, filename is %s \n", filemgmt.Prefix, len(a.addresses), filename)
+	return nil
+}
+
+func (a *addresses) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAddress, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, a.addresses); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d addresses, filename is %s \n", filemgmt.Prefix, len(a.addresses), filename)
+	return nil
+}
+
+func (a *addresses) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAddress, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, a.addresses); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d addresses, filename is %s \n", filemgmt.Prefix, len(a.addresses), filename)
+	return nil
+}
+
+func (a *addresses) outPutConsole() {
+	for _, v := range a.addresses {
+		fmt.Printf("%+v\n", v)
+	}
+}
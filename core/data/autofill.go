@@ -0,0 +1,168 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// FormEntry is a value the browser remembers for a form field name
+type FormEntry struct {
+	Name         string
+	Value        string
+	Count        int
+	DateCreated  time.Time
+	DateLastUsed time.Time
+}
+
+type autofill struct {
+	mainPath string
+	tempDir  string
+	entries  []FormEntry
+}
+
+func NewAutofill(main, sub string) Item {
+	return &autofill{mainPath: main}
+}
+
+func (a *autofill) ChromeParse(key []byte) error {
+	return a.ChromeParseContext(context.Background(), key)
+}
+
+func (a *autofill) ChromeParseContext(ctx context.Context, key []byte) error {
+	return a.parse(ctx, filepath.Join(a.tempDir, ChromeWebDataFile), QueryChromiumAutofill, filemgmt.UnixTime)
+}
+
+func (a *autofill) FirefoxParse() error {
+	return a.FirefoxParseContext(context.Background())
+}
+
+func (a *autofill) FirefoxParseContext(ctx context.Context) error {
+	return a.parse(ctx, filepath.Join(a.tempDir, FirefoxFormFile), QueryFirefoxFormHistory, filemgmt.PRTime)
+}
+
+// parse read the form entries, both browsers keep them in a single table with the
+// same columns, only the time unit differs
+func (a *autofill) parse(ctx context.Context, path, query string, toTime func(int64) time.Time) error {
+	formDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := formDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := formDB.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	a.entries = nil
+	for rows.Next() {
+		var (
+			name, value       string
+			count             int
+			created, lastUsed int64
+		)
+		if err = rows.Scan(&name, &value, &count, &created, &lastUsed); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		a.entries = append(a.entries, FormEntry{
+			Name:         name,
+			Value:        value,
+			Count:        count,
+			DateCreated:  toTime(created),
+			DateLastUsed: toTime(lastUsed),
+		})
+	}
+	return rows.Err()
+}
+
+// Records return all the parsed form entries
+func (a *autofill) Records() []FormEntry {
+	return a.entries
+}
+
+func (a *autofill) CopyDB() error {
+	dir, err := copyToTempDir(a.mainPath)
+	if err != nil {
+		return err
+	}
+	a.tempDir = dir
+	return nil
+}
+
+func (a *autofill) Release() error {
+	return releaseTempDir(a.tempDir)
+}
+
+func (a *autofill) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(a.entries, func(i, j int) bool {
+		if a.entries[i].Name != a.entries[j].Name {
+			return a.entries[i].Name < a.entries[j].Name
+		}
+		return a.entries[i].Count > a.entries[j].Count
+	})
+	switch format {
+	case formatCSV:
+		err := a.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		a.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return a.outPutJsonLines(browser, dir)
+	default:
+		err := a.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (a *autofill) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAutofill, GetFormatName(formatJson))
+	err := WriteToJson(filename, a.entries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d autofill entries, filename is %s \n", filemgmt.Prefix, len(a.entries), filename)
+	return nil
+}
+
+func (a *autofill) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAutofill, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, a.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d autofill entries, filename is %s \n", filemgmt.Prefix, len(a.entries), filename)
+	return nil
+}
+
+func (a *autofill) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameAutofill, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, a.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d autofill entries, filename is %s \n", filemgmt.Prefix, len(a.entries), filename)
+	return nil
+}
+
+func (a *autofill) outPutConsole() {
+	for _, v := range a.entries {
+		fmt.Printf("%+v\n", v)
+	}
+}
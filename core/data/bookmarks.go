@@ -4,11 +4,12 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"time"
 
 	"github.com/teocci/go-chrome-cookies/filemgmt"
@@ -19,23 +20,57 @@ import (
 const (
 	bookmarkID       = "id"
 	bookmarkAdded    = "date_added"
+	bookmarkModified = "date_modified"
+	bookmarkLastUsed = "date_last_used"
 	bookmarkUrl      = "url"
 	bookmarkName     = "name"
 	bookmarkType     = "type"
 	bookmarkChildren = "children"
+
+	bookmarkTypeURL       = "url"
+	bookmarkTypeFolder    = "folder"
+	bookmarkTypeSeparator = "separator"
+
+	// bookmarkFolderSep joins the folder names of the Folder path
+	bookmarkFolderSep = "/"
 )
 
-type bookmark struct {
-	ID        int64
-	Name      string
-	Type      string
-	URL       string
-	DateAdded time.Time
+// firefoxBookmarkRoots maps the guid of the firefox root folders to their root name
+var firefoxBookmarkRoots = map[string]string{
+	"menu________": "menu",
+	"toolbar_____": "toolbar",
+	"unfiled_____": "unfiled",
+	"mobile______": "mobile",
+	"tags________": "tags",
+}
+
+// Bookmark is a single bookmark or folder node, Folder is the path of the parent
+// folders and Position the index of the node inside its parent
+type Bookmark struct {
+	ID           int64
+	ParentID     int64
+	Name         string
+	Type         string
+	URL          string
+	Root         string
+	Folder       string
+	Position     int
+	DateAdded    time.Time
+	DateModified time.Time
+	LastUsed     time.Time
+}
+
+// BookmarkNode is a bookmark with its children, folders keep the browser order
+type BookmarkNode struct {
+	Bookmark
+	Children []*BookmarkNode `json:",omitempty"`
 }
 
 type bookmarks struct {
 	mainPath  string
-	bookmarks []bookmark
+	tempDir   string
+	roots     []*BookmarkNode
+	bookmarks []Bookmark
 }
 
 func NewBookmarks(main, sub string) Item {
@@ -43,30 +78,44 @@ func NewBookmarks(main, sub string) Item {
 }
 
 func (b *bookmarks) ChromeParse(key []byte) error {
-	bookmarks, err := filemgmt.ReadFile(ChromeBookmarkFile)
+	return b.ChromeParseContext(context.Background(), key)
+}
+
+func (b *bookmarks) ChromeParseContext(ctx context.Context, key []byte) error {
+	bookmarks, err := filemgmt.ReadFile(filepath.Join(b.tempDir, ChromeBookmarkFile))
 	if err != nil {
 		return err
 	}
+	b.roots = nil
 	r := gjson.Parse(bookmarks)
 	if r.Exists() {
 		roots := r.Get("roots")
+		var position int
 		roots.ForEach(func(key, value gjson.Result) bool {
-			getBookmarkChildren(value, b)
-			return true
+			// roots also holds sync metadata, only the folders are bookmark roots
+			if !value.Get(bookmarkType).Exists() {
+				return true
+			}
+			b.roots = append(b.roots, chromeBookmarkNode(value, key.String(), 0, position, ""))
+			position++
+			return ctx.Err() == nil
 		})
 	}
-	return nil
+	b.bookmarks = flattenBookmarks(b.roots)
+	return ctx.Err()
 }
 
 func (b *bookmarks) FirefoxParse() error {
+	return b.FirefoxParseContext(context.Background())
+}
+
+func (b *bookmarks) FirefoxParseContext(ctx context.Context) error {
 	var (
 		err          error
 		keyDB        *sql.DB
 		bookmarkRows *sql.Rows
-		tempMap      map[int64]string
-		bookmarkUrl  string
 	)
-	keyDB, err = sql.Open("sqlite3", FirefoxDataFile)
+	keyDB, err = sql.Open("sqlite3", filepath.Join(b.tempDir, FirefoxDataFile))
 	if err != nil {
 		return err
 	}
@@ -75,49 +124,100 @@ func (b *bookmarks) FirefoxParse() error {
 			logger.Error(err)
 		}
 	}()
-	_, err = keyDB.Exec(CloseJournalMode)
+	_, err = keyDB.ExecContext(ctx, CloseJournalMode)
 	if err != nil {
 		logger.Error(err)
 	}
-	bookmarkRows, err = keyDB.Query(QueryFirefoxBookMarks)
+	bookmarkRows, err = keyDB.QueryContext(ctx, QueryFirefoxBookMarks)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err := bookmarkRows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var (
+		nodes = make(map[int64]*BookmarkNode)
+		order []*BookmarkNode
+		guids = make(map[int64]string)
+	)
 	for bookmarkRows.Next() {
 		var (
-			id, bType, dateAdded int64
-			title, url           string
+			id, parent, bType, position int64
+			dateAdded, lastModified     int64
+			title, url, guid            sql.NullString
+			lastVisit                   sql.NullInt64
 		)
-		err = bookmarkRows.Scan(&id, &url, &bType, &dateAdded, &title)
+		err = bookmarkRows.Scan(&id, &parent, &bType, &position, &title, &dateAdded, &lastModified, &guid, &url, &lastVisit)
 		if err != nil {
 			logger.Warn(err)
+			continue
 		}
-		if url, ok := tempMap[id]; ok {
-			bookmarkUrl = url
+		node := &BookmarkNode{Bookmark: Bookmark{
+			ID:           id,
+			ParentID:     parent,
+			Name:         title.String,
+			Type:         BookMarkType(bType),
+			URL:          url.String,
+			Position:     int(position),
+			DateAdded:    filemgmt.PRTime(dateAdded),
+			DateModified: filemgmt.PRTime(lastModified),
+			LastUsed:     filemgmt.PRTime(lastVisit.Int64),
+		}}
+		nodes[id] = node
+		guids[id] = guid.String
+		order = append(order, node)
+	}
+	if err = bookmarkRows.Err(); err != nil {
+		return err
+	}
+	// rows are ordered by parent and position, so children keep their position
+	b.roots = nil
+	for _, node := range order {
+		if root, ok := firefoxBookmarkRoots[guids[node.ID]]; ok {
+			node.Root = root
+			if node.Name == "" {
+				node.Name = root
+			}
+			b.roots = append(b.roots, node)
+			continue
 		}
-		b.bookmarks = append(b.bookmarks, bookmark{
-			ID:        id,
-			Name:      title,
-			Type:      BookMarkType(bType),
-			URL:       bookmarkUrl,
-			DateAdded: filemgmt.TimeStampFormat(dateAdded / 1000000),
-		})
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	for _, root := range b.roots {
+		setBookmarkPath(root, root.Root, "")
 	}
+	b.bookmarks = flattenBookmarks(b.roots)
 	return nil
 }
 
+// Records return all the parsed bookmarks, depth first in the browser order
+func (b *bookmarks) Records() []Bookmark {
+	return b.bookmarks
+}
+
+// Tree return the root folders of the parsed bookmarks
+func (b *bookmarks) Tree() []*BookmarkNode {
+	return b.roots
+}
+
 func (b *bookmarks) CopyDB() error {
-	return CopyToLocalPath(b.mainPath, filepath.Base(b.mainPath))
+	dir, err := copyToTempDir(b.mainPath)
+	if err != nil {
+		return err
+	}
+	b.tempDir = dir
+	return nil
 }
 
 func (b *bookmarks) Release() error {
-	return os.Remove(filepath.Base(b.mainPath))
+	return releaseTempDir(b.tempDir)
 }
 
 func (b *bookmarks) OutPut(format OutputFormat, browser, dir string) error {
-	sort.Slice(b.bookmarks, func(i, j int) bool {
-		return b.bookmarks[i].ID < b.bookmarks[j].ID
-	})
 	switch format {
 	case formatCSV:
 		err := b.outPutCsv(browser, dir)
@@ -125,6 +225,10 @@ func (b *bookmarks) OutPut(format OutputFormat, browser, dir string) error {
 	case formatConsole:
 		b.outPutConsole()
 		return nil
+	case formatHTML:
+		return b.outPutHtml(browser, dir)
+	case formatJsonLines:
+		return b.outPutJsonLines(browser, dir)
 	default:
 		err := b.outPutJson(browser, dir)
 		return err
@@ -133,10 +237,7 @@ func (b *bookmarks) OutPut(format OutputFormat, browser, dir string) error {
 
 func (b *bookmarks) outPutJson(browser, dir string) error {
 	filename := filemgmt.FormatFileName(dir, browser, ItemNameBookmark, GetFormatName(formatJson))
-	sort.Slice(b.bookmarks, func(i, j int) bool {
-		return b.bookmarks[i].ID < b.bookmarks[j].ID
-	})
-	err := WriteToJson(filename, b.bookmarks)
+	err := WriteToJson(filename, b.roots)
 	if err != nil {
 		return err
 	}
@@ -153,38 +254,96 @@ func (b *bookmarks) outPutCsv(browser, dir string) error {
 	return nil
 }
 
+func (b *bookmarks) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameBookmark, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, b.bookmarks); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d bookmarks, filename is %s \n", filemgmt.Prefix, len(b.bookmarks), filename)
+	return nil
+}
+
+func (b *bookmarks) outPutHtml(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameBookmark, GetFormatName(formatHTML))
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	fnc := filemgmt.CloseFile()
+	defer fnc(f)
+	if err = WriteNetscapeBookmarks(f, b.roots); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d bookmarks, filename is %s \n", filemgmt.Prefix, len(b.bookmarks), filename)
+	return nil
+}
+
 func (b *bookmarks) outPutConsole() {
 	for _, v := range b.bookmarks {
 		fmt.Printf("%+v\n", v)
 	}
 }
 
-func getBookmarkChildren(value gjson.Result, b *bookmarks) (children gjson.Result) {
-	nodeType := value.Get(bookmarkType)
-	bm := bookmark{
-		ID:        value.Get(bookmarkID).Int(),
-		Name:      value.Get(bookmarkName).String(),
-		URL:       value.Get(bookmarkUrl).String(),
-		DateAdded: filemgmt.TimeEpochFormat(value.Get(bookmarkAdded).Int()),
-	}
-	children = value.Get(bookmarkChildren)
-	if nodeType.Exists() {
-		bm.Type = nodeType.String()
-		b.bookmarks = append(b.bookmarks, bm)
-		if children.Exists() && children.IsArray() {
-			for _, v := range children.Array() {
-				children = getBookmarkChildren(v, b)
-			}
+// chromeBookmarkNode build the node and its children from the chromium Bookmarks json
+func chromeBookmarkNode(value gjson.Result, root string, parentID int64, position int, folder string) *BookmarkNode {
+	node := &BookmarkNode{Bookmark: Bookmark{
+		ID:           value.Get(bookmarkID).Int(),
+		ParentID:     parentID,
+		Name:         value.Get(bookmarkName).String(),
+		Type:         value.Get(bookmarkType).String(),
+		URL:          value.Get(bookmarkUrl).String(),
+		Root:         root,
+		Folder:       folder,
+		Position:     position,
+		DateAdded:    filemgmt.WebKitTime(value.Get(bookmarkAdded).Int()),
+		DateModified: filemgmt.WebKitTime(value.Get(bookmarkModified).Int()),
+		LastUsed:     filemgmt.WebKitTime(value.Get(bookmarkLastUsed).Int()),
+	}}
+	children := value.Get(bookmarkChildren)
+	if children.IsArray() {
+		path := joinBookmarkFolder(folder, node.Name)
+		for i, v := range children.Array() {
+			node.Children = append(node.Children, chromeBookmarkNode(v, root, node.ID, i, path))
 		}
 	}
-	return children
+	return node
+}
+
+// setBookmarkPath fill the root and folder path of the children of node
+func setBookmarkPath(node *BookmarkNode, root, folder string) {
+	node.Root = root
+	node.Folder = folder
+	path := joinBookmarkFolder(folder, node.Name)
+	for _, child := range node.Children {
+		setBookmarkPath(child, root, path)
+	}
+}
+
+func joinBookmarkFolder(folder, name string) string {
+	name = strings.ReplaceAll(name, bookmarkFolderSep, "\\"+bookmarkFolderSep)
+	if folder == "" {
+		return name
+	}
+	return folder + bookmarkFolderSep + name
+}
+
+// flattenBookmarks return the nodes depth first without their children
+func flattenBookmarks(nodes []*BookmarkNode) []Bookmark {
+	var flat []Bookmark
+	for _, node := range nodes {
+		flat = append(flat, node.Bookmark)
+		flat = append(flat, flattenBookmarks(node.Children)...)
+	}
+	return flat
 }
 
 func BookMarkType(a int64) string {
 	switch a {
 	case 1:
-		return "url"
+		return bookmarkTypeURL
+	case 3:
+		return bookmarkTypeSeparator
 	default:
-		return "folder"
+		return bookmarkTypeFolder
 	}
 }
@@ -0,0 +1,349 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+)
+
+const netscapeBookmarkHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+`
+
+const (
+	netscapeToolbarAttr = "PERSONAL_TOOLBAR_FOLDER"
+	netscapeRootToolbar = "toolbar"
+)
+
+// toolbarRoots are the root names of the bookmarks bar for chromium and firefox
+var toolbarRoots = map[string]bool{
+	"bookmark_bar":      true,
+	netscapeRootToolbar: true,
+}
+
+// WriteNetscapeBookmarks render the bookmark tree as a NETSCAPE-Bookmark-file-1 document,
+// the format every browser can import. Firefox tags are not bookmarks and are skipped.
+func WriteNetscapeBookmarks(w io.Writer, roots []*BookmarkNode) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(netscapeBookmarkHeader); err != nil {
+		return err
+	}
+	var visible []*BookmarkNode
+	for _, root := range roots {
+		if root.Root == "tags" {
+			continue
+		}
+		visible = append(visible, root)
+	}
+	writeNetscapeList(bw, visible, 0)
+	return bw.Flush()
+}
+
+func writeNetscapeList(w *bufio.Writer, nodes []*BookmarkNode, depth int) {
+	indent := strings.Repeat("    ", depth)
+	fmt.Fprintf(w, "%s<DL><p>\n", indent)
+	for _, node := range nodes {
+		switch node.Type {
+		case bookmarkTypeFolder:
+			fmt.Fprintf(w, "%s    <DT><H3%s", indent, netscapeDates(node.Bookmark))
+			if depth == 0 && toolbarRoots[node.Root] {
+				fmt.Fprintf(w, ` %s="true"`, netscapeToolbarAttr)
+			}
+			fmt.Fprintf(w, ">%s</H3>\n", html.EscapeString(node.Name))
+			writeNetscapeList(w, node.Children, depth+1)
+		case bookmarkTypeSeparator:
+			fmt.Fprintf(w, "%s    <HR>\n", indent)
+		default:
+			fmt.Fprintf(w, "%s    <DT><A HREF=\"%s\"%s>%s</A>\n", indent, html.EscapeString(node.URL), netscapeDates(node.Bookmark), html.EscapeString(node.Name))
+		}
+	}
+	fmt.Fprintf(w, "%s</DL><p>\n", indent)
+}
+
+func netscapeDates(b Bookmark) string {
+	var attrs string
+	if !netscapeZeroTime(b.DateAdded) {
+		attrs += fmt.Sprintf(` ADD_DATE="%d"`, b.DateAdded.Unix())
+	}
+	if !netscapeZeroTime(b.DateModified) {
+		attrs += fmt.Sprintf(` LAST_MODIFIED="%d"`, b.DateModified.Unix())
+	}
+	return attrs
+}
+
+// netscapeZeroTime reports times that are unset in the source, the format only has
+// unix seconds so anything before 1970 is dropped
+func netscapeZeroTime(t time.Time) bool {
+	return t.IsZero() || t.Unix() <= 0
+}
+
+// ParseNetscapeBookmarks read a NETSCAPE-Bookmark-file-1 document back into the bookmark
+// tree, ids are given in document order and the toolbar folder gets the toolbar root
+func ParseNetscapeBookmarks(r io.Reader) ([]*BookmarkNode, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		roots      []*BookmarkNode
+		stack      []*BookmarkNode // open folders, nil for the document list
+		lastFolder *BookmarkNode
+		depth      int
+	)
+	appendNode := func(node *BookmarkNode) {
+		if len(stack) == 0 || stack[len(stack)-1] == nil {
+			roots = append(roots, node)
+			return
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, node)
+	}
+	doc := string(src)
+	for pos := 0; pos < len(doc); {
+		start := strings.IndexByte(doc[pos:], '<')
+		if start < 0 {
+			break
+		}
+		start += pos
+		end := strings.IndexByte(doc[start:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated tag at offset %d", start)
+		}
+		end += start
+		name, attrs := parseNetscapeTag(doc[start+1 : end])
+		pos = end + 1
+		switch name {
+		case "DL":
+			stack = append(stack, lastFolder)
+			lastFolder = nil
+			depth++
+		case "/DL":
+			if depth == 0 {
+				return nil, fmt.Errorf("unbalanced </DL> at offset %d", start)
+			}
+			stack = stack[:len(stack)-1]
+			depth--
+		case "H3", "A":
+			closing := "</" + name + ">"
+			textEnd := indexFold(doc[pos:], closing)
+			if textEnd < 0 {
+				return nil, fmt.Errorf("missing %s at offset %d", closing, pos)
+			}
+			text := html.UnescapeString(strings.TrimSpace(doc[pos : pos+textEnd]))
+			pos += textEnd + len(closing)
+			node := &BookmarkNode{Bookmark: Bookmark{
+				Name:         text,
+				DateAdded:    netscapeTime(attrs["ADD_DATE"]),
+				DateModified: netscapeTime(attrs["LAST_MODIFIED"]),
+			}}
+			if name == "H3" {
+				node.Type = bookmarkTypeFolder
+				if strings.EqualFold(attrs[netscapeToolbarAttr], "true") {
+					node.Root = netscapeRootToolbar
+				}
+				lastFolder = node
+			} else {
+				node.Type = bookmarkTypeURL
+				node.URL = attrs["HREF"]
+			}
+			appendNode(node)
+		case "HR":
+			appendNode(&BookmarkNode{Bookmark: Bookmark{Type: bookmarkTypeSeparator}})
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%d <DL> not closed", depth)
+	}
+	var id int64
+	numberBookmarks(roots, 0, &id)
+	for _, root := range roots {
+		if root.Root == "" && root.Type == bookmarkTypeFolder {
+			root.Root = root.Name
+		}
+		setBookmarkPath(root, root.Root, "")
+	}
+	return roots, nil
+}
+
+// indexFold is a case insensitive strings.Index for the ascii closing tags
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		j := strings.IndexByte(s[i:], '<')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if i+len(substr) <= len(s) && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// numberBookmarks give ids, parent ids and positions in depth first order
+func numberBookmarks(nodes []*BookmarkNode, parentID int64, id *int64) {
+	for i, node := range nodes {
+		*id++
+		node.ID = *id
+		node.ParentID = parentID
+		node.Position = i
+		numberBookmarks(node.Children, node.ID, id)
+	}
+}
+
+// parseNetscapeTag split a tag into its upper case name and attributes
+func parseNetscapeTag(tag string) (string, map[string]string) {
+	tag = strings.TrimSpace(tag)
+	i := strings.IndexAny(tag, " \t\r\n")
+	if i < 0 {
+		return strings.ToUpper(tag), nil
+	}
+	name := strings.ToUpper(tag[:i])
+	attrs := make(map[string]string)
+	rest := tag[i:]
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexAny(rest, "= \t\r\n")
+		if end < 0 {
+			end = len(rest)
+		}
+		key := strings.ToUpper(rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t\r\n")
+		// a bare attribute like <A FOO HREF=...> has no value
+		if rest == "" || rest[0] != '=' {
+			attrs[key] = ""
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		var value string
+		if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+			q := rest[0]
+			end := strings.IndexByte(rest[1:], q)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\r\n")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+		attrs[key] = html.UnescapeString(value)
+	}
+	return name, attrs
+}
+
+func netscapeTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return filemgmt.UnixTime(sec)
+}
+
+// DiffBookmarks compare two bookmark sets by folder path and url, it returns the urls
+// only present in b and the urls only present in a
+func DiffBookmarks(a, b []Bookmark) (added, removed []Bookmark) {
+	key := func(v Bookmark) string {
+		return v.Folder + "\x00" + v.URL
+	}
+	inA := make(map[string]bool)
+	for _, v := range a {
+		if v.Type == bookmarkTypeURL {
+			inA[key(v)] = true
+		}
+	}
+	inB := make(map[string]bool)
+	for _, v := range b {
+		if v.Type != bookmarkTypeURL {
+			continue
+		}
+		inB[key(v)] = true
+		if !inA[key(v)] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range a {
+		if v.Type == bookmarkTypeURL && !inB[key(v)] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+// MergeBookmarks add the folders and urls of src missing in dst, folders are matched by
+// name and urls by address inside the same folder. The merged tree is a renumbered copy,
+// dst and src are left as they are.
+func MergeBookmarks(dst, src []*BookmarkNode) []*BookmarkNode {
+	merged := mergeBookmarkNodes(copyBookmarkNodes(dst), src)
+	var id int64
+	numberBookmarks(merged, 0, &id)
+	for _, root := range merged {
+		setBookmarkPath(root, root.Root, "")
+	}
+	return merged
+}
+
+func mergeBookmarkNodes(dst, src []*BookmarkNode) []*BookmarkNode {
+	for _, s := range src {
+		var match *BookmarkNode
+		for _, d := range dst {
+			if d.Type == s.Type && d.Type == bookmarkTypeFolder && d.Name == s.Name {
+				match = d
+				break
+			}
+			if d.Type == s.Type && d.Type == bookmarkTypeURL && d.URL == s.URL {
+				match = d
+				break
+			}
+		}
+		switch {
+		case match == nil:
+			dst = append(dst, copyBookmarkNode(s))
+		case match.Type == bookmarkTypeFolder:
+			match.Children = mergeBookmarkNodes(match.Children, s.Children)
+		}
+	}
+	return dst
+}
+
+func copyBookmarkNodes(nodes []*BookmarkNode) []*BookmarkNode {
+	if nodes == nil {
+		return nil
+	}
+	c := make([]*BookmarkNode, len(nodes))
+	for i, node := range nodes {
+		c[i] = copyBookmarkNode(node)
+	}
+	return c
+}
+
+func copyBookmarkNode(node *BookmarkNode) *BookmarkNode {
+	c := *node
+	c.Children = copyBookmarkNodes(node.Children)
+	return &c
+}
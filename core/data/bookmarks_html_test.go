@@ -0,0 +1,93 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNetscapeBookmarksRoundTrip(t *testing.T) {
+	added := time.Unix(1600000000, 0)
+	roots := []*BookmarkNode{
+		{Bookmark: Bookmark{Name: "Bookmarks bar", Type: bookmarkTypeFolder, Root: "bookmark_bar", DateAdded: added}, Children: []*BookmarkNode{
+			{Bookmark: Bookmark{Name: "Go & <Tools>", Type: bookmarkTypeURL, URL: "https://go.dev/?a=1&b=2", DateAdded: added}},
+			{Bookmark: Bookmark{Type: bookmarkTypeSeparator}},
+			{Bookmark: Bookmark{Name: "a/b", Type: bookmarkTypeFolder}, Children: []*BookmarkNode{
+				{Bookmark: Bookmark{Name: "Example", Type: bookmarkTypeURL, URL: "https://example.com/"}},
+			}},
+		}},
+		{Bookmark: Bookmark{Name: "tags", Type: bookmarkTypeFolder, Root: "tags"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteNetscapeBookmarks(&buf, roots); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseNetscapeBookmarks(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 {
+		t.Fatalf("got %d roots, want 1", len(parsed))
+	}
+	bar := parsed[0]
+	if bar.Root != netscapeRootToolbar || !bar.DateAdded.Equal(added) || len(bar.Children) != 3 {
+		t.Fatalf("unexpected toolbar folder %+v", bar)
+	}
+	link := bar.Children[0]
+	if link.Name != "Go & <Tools>" || link.URL != "https://go.dev/?a=1&b=2" || !link.DateAdded.Equal(added) {
+		t.Errorf("unexpected link %+v", link.Bookmark)
+	}
+	if bar.Children[1].Type != bookmarkTypeSeparator {
+		t.Errorf("expected a separator, got %+v", bar.Children[1].Bookmark)
+	}
+	nested := bar.Children[2].Children[0]
+	if nested.Folder != `Bookmarks bar/a\/b` || nested.ParentID != bar.Children[2].ID {
+		t.Errorf("unexpected nested link %+v", nested.Bookmark)
+	}
+
+	added2, removed := DiffBookmarks(flattenBookmarks(parsed), flattenBookmarks(MergeBookmarks(parsed, []*BookmarkNode{
+		{Bookmark: Bookmark{Name: "Bookmarks bar", Type: bookmarkTypeFolder}, Children: []*BookmarkNode{
+			{Bookmark: Bookmark{Name: "New", Type: bookmarkTypeURL, URL: "https://new.example/"}},
+		}},
+	})))
+	if len(added2) != 1 || added2[0].URL != "https://new.example/" || len(removed) != 0 {
+		t.Errorf("unexpected diff added %+v removed %+v", added2, removed)
+	}
+}
+
+func TestNetscapeBookmarksBareAttribute(t *testing.T) {
+	doc := `<DL><p><DT><H3 FOLDED ADD_DATE="1600000000">Folder</H3><DL><p>
+		<DT><A FOO HREF="https://example.com/" ICON_URI=x>Example</A>
+	</DL><p></DL><p>`
+	roots, err := ParseNetscapeBookmarks(bytes.NewBufferString(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].DateAdded.Unix() != 1600000000 || len(roots[0].Children) != 1 {
+		t.Fatalf("unexpected roots %+v", roots)
+	}
+	if link := roots[0].Children[0]; link.URL != "https://example.com/" || link.Name != "Example" {
+		t.Errorf("unexpected link %+v", link.Bookmark)
+	}
+}
+
+func TestMergeBookmarksCopies(t *testing.T) {
+	dst := []*BookmarkNode{{Bookmark: Bookmark{ID: 7, Name: "bar", Type: bookmarkTypeFolder}}}
+	src := []*BookmarkNode{{Bookmark: Bookmark{ID: 9, Name: "bar", Type: bookmarkTypeFolder}, Children: []*BookmarkNode{
+		{Bookmark: Bookmark{ID: 10, Name: "New", Type: bookmarkTypeURL, URL: "https://new.example/"}},
+	}}}
+	merged := MergeBookmarks(dst, src)
+	if len(merged[0].Children) != 1 || merged[0].ID != 1 {
+		t.Fatalf("unexpected merge %+v", merged[0])
+	}
+	if len(dst[0].Children) != 0 || dst[0].ID != 7 || src[0].Children[0].ID != 10 || src[0].Children[0].Folder != "" {
+		t.Errorf("inputs changed, dst %+v src %+v", dst[0], src[0].Children[0])
+	}
+	merged[0].Children[0].Name = "changed"
+	if src[0].Children[0].Name != "New" {
+		t.Error("merged tree shares nodes with src")
+	}
+}
@@ -0,0 +1,443 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// BodyWriter is implemented by the items that hold response bodies, WriteBodies save
+// them in a <browser>_<item> directory of dir and records the file name of every body
+type BodyWriter interface {
+	WriteBodies(browser, dir string) error
+}
+
+const (
+	simpleInitialMagic = 0xfcfb6d1ba7725c30
+	simpleFinalMagic   = 0xf4fa6f45970d41d8
+	simpleIndexMagic   = 0x656e74657220796f
+	// SimpleFileHeader and SimpleFileEOF are both padded to 24 bytes
+	simpleHeaderSize  = 24
+	simpleEOFSize     = 24
+	simpleHasKeySha   = 2
+	simpleKeySha256   = 32
+	simpleEntrySuffix = "_0"
+	simpleIndexFile   = "the-real-index"
+
+	// HttpResponseInfo flags
+	responseInfoHasExtraFlags = 1 << 31
+	responseExtraHasOrigTime  = 1 << 2
+
+	firefoxCacheChunkSize = 256 * 1024
+	firefoxCacheNoExpiry  = 0xFFFFFFFF
+)
+
+var errCacheCorrupt = errors.New("cache: corrupt entry")
+
+// CacheEntry is a cached response, Key is the cache key the browser built for Url, it
+// holds the network isolation key of the request. Headers are the response headers one
+// per line, the body is saved as received, Content-Encoding tells if it is compressed.
+type CacheEntry struct {
+	Url          string
+	Key          string
+	Status       string
+	ContentType  string
+	Headers      string
+	Size         int64
+	FetchCount   int
+	RequestTime  time.Time
+	ResponseTime time.Time
+	LastUsed     time.Time
+	Expires      time.Time
+	File         string
+	entryFile    string
+	bodyOffset   int64
+}
+
+type cache struct {
+	mainPath string
+	tempDir  string
+	entries  []CacheEntry
+}
+
+func NewCache(main, sub string) Item {
+	return &cache{mainPath: main}
+}
+
+func (c *cache) ChromeParse(key []byte) error {
+	return c.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the _0 files of the simple cache, they hold the key, the body
+// and the response info, the last used times come from the index
+func (c *cache) ChromeParseContext(ctx context.Context, key []byte) error {
+	dataDir := filepath.Join(c.tempDir, filepath.Base(c.mainPath))
+	files, err := os.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+	index, err := readSimpleIndex(filepath.Join(c.tempDir, filepath.Base(ChromeCacheIndexDir), simpleIndexFile))
+	if err != nil {
+		logger.Debug(err)
+	}
+	c.entries = nil
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := f.Name()
+		if !strings.HasSuffix(name, simpleEntrySuffix) {
+			continue
+		}
+		entryFile := filepath.Join(dataDir, name)
+		content, err := os.ReadFile(entryFile)
+		if err != nil {
+			// the browser may evict an entry while it is read
+			logger.Debugf("%s: %v", name, err)
+			continue
+		}
+		entry, err := chromiumCacheEntry(content)
+		if err != nil {
+			logger.Debugf("%s: %v", name, err)
+			continue
+		}
+		if hash, err := strconv.ParseUint(strings.TrimSuffix(name, simpleEntrySuffix), 16, 64); err == nil {
+			entry.LastUsed = index[hash]
+		}
+		entry.entryFile = entryFile
+		c.entries = append(c.entries, entry)
+	}
+	return nil
+}
+
+// chromiumCacheEntry read a simple cache entry file, it is the header, the key, the body
+// (stream 1) and its EOF record, then the response info (stream 0), an optional sha256
+// of the key and the EOF record of stream 0 that gives the size of the stream
+func chromiumCacheEntry(b []byte) (CacheEntry, error) {
+	var entry CacheEntry
+	if len(b) < simpleHeaderSize+2*simpleEOFSize || binary.LittleEndian.Uint64(b) != simpleInitialMagic {
+		return entry, errCacheCorrupt
+	}
+	keyEnd := simpleHeaderSize + int(binary.LittleEndian.Uint32(b[12:]))
+	eof0 := b[len(b)-simpleEOFSize:]
+	if keyEnd > len(b) || binary.LittleEndian.Uint64(eof0) != simpleFinalMagic {
+		return entry, errCacheCorrupt
+	}
+	end0 := len(b) - simpleEOFSize
+	if binary.LittleEndian.Uint32(eof0[8:])&simpleHasKeySha != 0 {
+		end0 -= simpleKeySha256
+	}
+	start0 := end0 - int(binary.LittleEndian.Uint32(eof0[16:]))
+	if start0-simpleEOFSize < keyEnd || binary.LittleEndian.Uint64(b[start0-simpleEOFSize:]) != simpleFinalMagic {
+		return entry, errCacheCorrupt
+	}
+	entry.Key = string(b[simpleHeaderSize:keyEnd])
+	entry.Url = entry.Key[strings.LastIndexByte(entry.Key, ' ')+1:]
+	entry.Size = int64(start0 - simpleEOFSize - keyEnd)
+	entry.bodyOffset = int64(keyEnd)
+
+	// HttpResponseInfo pickle
+	p := newPickle(b[start0:end0])
+	flags, ok := p.readInt()
+	var extra int32
+	if ok && uint32(flags)&responseInfoHasExtraFlags != 0 {
+		extra, ok = p.readInt()
+	}
+	request, ok1 := p.readInt64()
+	response, ok2 := p.readInt64()
+	if extra&responseExtraHasOrigTime != 0 {
+		_, ok2 = p.readInt64()
+	}
+	headers, ok3 := p.readString()
+	if !ok || !ok1 || !ok2 || !ok3 {
+		return entry, errCacheCorrupt
+	}
+	entry.RequestTime = filemgmt.WebKitTime(request)
+	entry.ResponseTime = filemgmt.WebKitTime(response)
+	setCacheHeaders(&entry, strings.Split(headers, "\x00"))
+	return entry, nil
+}
+
+// readSimpleIndex read the last used time of the entries by their hash, the index is a
+// pickle with a crc, the header and the hash, time and packed size of every entry
+func readSimpleIndex(path string) (map[uint64]time.Time, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &pickle{b: b, pos: 8}
+	magic, ok := p.readInt64()
+	if !ok || uint64(magic) != simpleIndexMagic {
+		return nil, errCacheCorrupt
+	}
+	version, _ := p.readInt()
+	count, _ := p.readInt64()
+	_, _ = p.readInt64() // cache size
+	if version >= 7 {
+		_, _ = p.readInt() // write reason
+	}
+	index := make(map[uint64]time.Time)
+	for i := int64(0); i < count; i++ {
+		hash, ok1 := p.readInt64()
+		used, ok2 := p.readInt64()
+		_, ok3 := p.readInt64()
+		if !ok1 || !ok2 || !ok3 {
+			return index, errCacheCorrupt
+		}
+		index[uint64(hash)] = filemgmt.WebKitTime(used)
+	}
+	return index, nil
+}
+
+// setCacheHeaders set the status, the headers and the content type from the header
+// lines, the first one is the status line
+func setCacheHeaders(entry *CacheEntry, lines []string) {
+	var headers []string
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if entry.Status == "" {
+			entry.Status = line
+			continue
+		}
+		headers = append(headers, line)
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "content-type") {
+			entry.ContentType = strings.TrimSpace(value)
+		}
+	}
+	entry.Headers = strings.Join(headers, "\n")
+}
+
+func (c *cache) FirefoxParse() error {
+	return c.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the cache2 entries, their file is named after the sha1 of
+// the key
+func (c *cache) FirefoxParseContext(ctx context.Context) error {
+	dataDir := filepath.Join(c.tempDir, filepath.Base(c.mainPath))
+	files, err := os.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+	c.entries = nil
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		entryFile := filepath.Join(dataDir, f.Name())
+		content, err := os.ReadFile(entryFile)
+		if err != nil {
+			// the browser may evict an entry while it is read
+			logger.Debugf("%s: %v", f.Name(), err)
+			continue
+		}
+		entry, err := firefoxCacheEntry(content)
+		if err != nil {
+			logger.Debugf("%s: %v", f.Name(), err)
+			continue
+		}
+		entry.entryFile = entryFile
+		c.entries = append(c.entries, entry)
+	}
+	return nil
+}
+
+// firefoxCacheEntry read a cache2 entry, it is the body then the metadata, the big endian
+// offset of the metadata ends the file. The metadata is a hash of itself, a hash per
+// 256 KiB chunk of the body, the header, the key and the elements as pairs of strings.
+func firefoxCacheEntry(b []byte) (CacheEntry, error) {
+	var entry CacheEntry
+	if len(b) < 4 {
+		return entry, errCacheCorrupt
+	}
+	end := len(b) - 4
+	metaOffset := int(binary.BigEndian.Uint32(b[end:]))
+	chunks := (metaOffset + firefoxCacheChunkSize - 1) / firefoxCacheChunkSize
+	header := metaOffset + 4 + 2*chunks
+	if metaOffset > end || header+28 > end {
+		return entry, errCacheCorrupt
+	}
+	field := func(i int) uint32 {
+		return binary.BigEndian.Uint32(b[header+4*i:])
+	}
+	version := field(0)
+	keyStart := header + 28
+	if version >= 2 {
+		keyStart += 4 // flags
+	}
+	keyEnd := keyStart + int(field(6))
+	if keyEnd+1 > end {
+		return entry, errCacheCorrupt
+	}
+	entry.Key = string(b[keyStart:keyEnd])
+	entry.Url = firefoxCacheUrl(entry.Key)
+	entry.FetchCount = int(field(1))
+	entry.LastUsed = filemgmt.UnixTime(int64(field(2)))
+	entry.ResponseTime = filemgmt.UnixTime(int64(field(3)))
+	if expiry := field(5); expiry != firefoxCacheNoExpiry {
+		entry.Expires = filemgmt.UnixTime(int64(expiry))
+	}
+	entry.Size = int64(metaOffset)
+
+	elements := bytes.Split(b[keyEnd+1:end], []byte{0})
+	for i := 0; i+1 < len(elements); i += 2 {
+		switch string(elements[i]) {
+		case "response-head":
+			setCacheHeaders(&entry, strings.Split(string(elements[i+1]), "\r\n"))
+		case "alt-data":
+			// the alternative data, like compiled scripts, follows the body
+			if _, offset, ok := strings.Cut(string(elements[i+1]), ";"); ok {
+				offset, _, _ = strings.Cut(offset, ",")
+				if size, err := strconv.ParseInt(offset, 10, 64); err == nil && size <= entry.Size {
+					entry.Size = size
+				}
+			}
+		}
+	}
+	return entry, nil
+}
+
+// firefoxCacheUrl drop the tags before the url, like a, for anonymous loads or the
+// O^ origin attributes, each one ends with a comma and the url starts with a colon
+func firefoxCacheUrl(key string) string {
+	for key != "" && key[0] != ':' {
+		i := strings.IndexByte(key, ',')
+		if i < 0 {
+			return key
+		}
+		key = key[i+1:]
+	}
+	return strings.TrimPrefix(key, ":")
+}
+
+// WriteBodies save the bodies of the entries, named after their entry file
+func (c *cache) WriteBodies(browser, dir string) error {
+	bodyDir, err := makeItemDir(browser, ItemNameCache, dir)
+	if err != nil {
+		return err
+	}
+	var count int
+	for i, v := range c.entries {
+		if v.Size == 0 {
+			continue
+		}
+		name := filepath.Base(v.entryFile)
+		if err = copyCacheBody(v.entryFile, v.bodyOffset, v.Size, filepath.Join(bodyDir, name)); err != nil {
+			return err
+		}
+		c.entries[i].File = name
+		count++
+	}
+	fmt.Printf("%s Get %d cache bodies, directory is %s \n", filemgmt.Prefix, count, bodyDir)
+	return nil
+}
+
+func copyCacheBody(src string, offset, size int64, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := in.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, io.NewSectionReader(in, offset, size)); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Records return all the parsed cache entries
+func (c *cache) Records() []CacheEntry {
+	return c.entries
+}
+
+// CopyDB copy the entry files, chromium also gets its index-dir
+func (c *cache) CopyDB() error {
+	dir, err := copyDirsToTempDir(c.mainPath, filepath.Join(c.mainPath, filepath.Base(ChromeCacheIndexDir)))
+	if err != nil {
+		return err
+	}
+	c.tempDir = dir
+	return nil
+}
+
+func (c *cache) Release() error {
+	return releaseTempDir(c.tempDir)
+}
+
+func (c *cache) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(c.entries, func(i, j int) bool {
+		return c.entries[i].Url < c.entries[j].Url
+	})
+	switch format {
+	case formatCSV:
+		err := c.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		c.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return c.outPutJsonLines(browser, dir)
+	default:
+		err := c.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (c *cache) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameCache, GetFormatName(formatJson))
+	err := WriteToJson(filename, c.entries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d cache entries, filename is %s \n", filemgmt.Prefix, len(c.entries), filename)
+	return nil
+}
+
+func (c *cache) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameCache, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, c.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d cache entries, filename is %s \n", filemgmt.Prefix, len(c.entries), filename)
+	return nil
+}
+
+func (c *cache) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameCache, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, c.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d cache entries, filename is %s \n", filemgmt.Prefix, len(c.entries), filename)
+	return nil
+}
+
+func (c *cache) outPutConsole() {
+	for _, v := range c.entries {
+		fmt.Printf("%+v\n", v)
+	}
+}
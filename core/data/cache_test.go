@@ -0,0 +1,114 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func simpleEOF(flags, size uint32) []byte {
+	eof := make([]byte, simpleEOFSize)
+	binary.LittleEndian.PutUint64(eof, simpleFinalMagic)
+	binary.LittleEndian.PutUint32(eof[8:], flags)
+	binary.LittleEndian.PutUint32(eof[16:], size)
+	return eof
+}
+
+func TestChromiumCacheEntry(t *testing.T) {
+	key := "1/0/_dk_https://a.test https://a.test https://a.test/app.js"
+	body := "console.log(1)"
+	headers := "HTTP/1.1 200\x00content-type: text/javascript\x00content-length: 14\x00\x00"
+
+	info := make([]byte, 4, 64)
+	put32 := func(v uint32) { info = binary.LittleEndian.AppendUint32(info, v) }
+	put32(responseInfoHasExtraFlags | 3)
+	put32(responseExtraHasOrigTime)
+	for _, v := range []uint64{13300000000000000, 13300000001000000, 13300000000500000} {
+		info = binary.LittleEndian.AppendUint64(info, v)
+	}
+	put32(uint32(len(headers)))
+	info = append(info, headers...)
+	for len(info)%4 != 0 {
+		info = append(info, 0)
+	}
+	binary.LittleEndian.PutUint32(info, uint32(len(info)-4))
+
+	b := make([]byte, simpleHeaderSize)
+	binary.LittleEndian.PutUint64(b, simpleInitialMagic)
+	binary.LittleEndian.PutUint32(b[8:], 5)
+	binary.LittleEndian.PutUint32(b[12:], uint32(len(key)))
+	b = append(b, key...)
+	b = append(b, body...)
+	b = append(b, simpleEOF(0, uint32(len(body)))...)
+	b = append(b, info...)
+	b = append(b, make([]byte, simpleKeySha256)...)
+	b = append(b, simpleEOF(simpleHasKeySha, uint32(len(info)))...)
+
+	entry, err := chromiumCacheEntry(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Url != "https://a.test/app.js" || entry.Status != "HTTP/1.1 200" || entry.ContentType != "text/javascript" {
+		t.Errorf("got %+v", entry)
+	}
+	if entry.Size != int64(len(body)) || string(b[entry.bodyOffset:entry.bodyOffset+entry.Size]) != body {
+		t.Errorf("got body at %d size %d", entry.bodyOffset, entry.Size)
+	}
+	if entry.ResponseTime.Sub(entry.RequestTime).Seconds() != 1 || entry.Headers != "content-type: text/javascript\ncontent-length: 14" {
+		t.Errorf("got %+v", entry)
+	}
+	if _, err = chromiumCacheEntry(b[:len(b)-1]); err == nil {
+		t.Error("expected an error for a truncated entry")
+	}
+}
+
+func firefoxCacheFile(key, body string) []byte {
+	b := []byte(body)
+	b = append(b, 0, 0, 0, 0, 0, 0) // metadata hash and one chunk hash
+	for _, v := range []uint32{3, 2, 1700000100, 1700000000, 0, firefoxCacheNoExpiry, uint32(len(key)), 0} {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	b = append(b, key...)
+	b = append(b, 0)
+	b = append(b, "request-method\x00GET\x00response-head\x00HTTP/1.1 200 OK\r\nContent-Type: image/png\r\n\x00alt-data\x001;4,data\x00"...)
+	return binary.BigEndian.AppendUint32(b, uint32(len(body)))
+}
+
+func TestFirefoxCacheEntry(t *testing.T) {
+	key := "O^partitionKey=%28https%2Ca.test%29,a,:https://a.test/logo.png"
+	entry, err := firefoxCacheEntry(firefoxCacheFile(key, "\x89PNG...."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Url != "https://a.test/logo.png" || entry.Status != "HTTP/1.1 200 OK" || entry.ContentType != "image/png" {
+		t.Errorf("got %+v", entry)
+	}
+	if entry.Size != 4 || entry.FetchCount != 2 || !entry.Expires.IsZero() || entry.LastUsed.Unix() != 1700000100 {
+		t.Errorf("got %+v", entry)
+	}
+	if !strings.HasPrefix(entry.Key, "O^partitionKey") {
+		t.Errorf("got key %s", entry.Key)
+	}
+}
+
+func TestFirefoxCacheUnreadableEntry(t *testing.T) {
+	dir := t.TempDir()
+	entries := filepath.Join(dir, filepath.Base(FirefoxCacheDir))
+	writeTestFile(t, filepath.Join(entries, "ABCD"), string(firefoxCacheFile(":https://a.test/", "body")))
+	// an entry that can't be read is skipped, here a directory
+	if err := os.Mkdir(filepath.Join(entries, "EF01"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	c := &cache{mainPath: FirefoxCacheDir, tempDir: dir}
+	if err := c.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	if r := c.Records(); len(r) != 1 || r[0].Url != "https://a.test/" {
+		t.Errorf("got %+v", r)
+	}
+}
@@ -4,17 +4,20 @@
 package data
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/core/decrypt"
 	"github.com/teocci/go-chrome-cookies/filemgmt"
 	"github.com/teocci/go-chrome-cookies/logger"
-	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
-type cookie struct {
+// Cookie is a single cookie record from the cookie database
+type Cookie struct {
 	Host         string
 	Path         string
 	KeyName      string
@@ -30,7 +33,8 @@ type cookie struct {
 
 type cookies struct {
 	mainPath string
-	cookies  map[string][]cookie
+	tempDir  string
+	cookies  map[string][]Cookie
 }
 
 func NewCookies(main, sub string) Item {
@@ -38,8 +42,21 @@ func NewCookies(main, sub string) Item {
 }
 
 func (c *cookies) ChromeParse(secretKey []byte) error {
-	c.cookies = make(map[string][]cookie)
-	cookieDB, err := sql.Open("sqlite3", ChromeCookieFile)
+	return c.ChromeParseContext(context.Background(), secretKey)
+}
+
+func (c *cookies) ChromeParseContext(ctx context.Context, secretKey []byte) error {
+	c.cookies = make(map[string][]Cookie)
+	return c.ChromeStream(ctx, secretKey, func(record Cookie) error {
+		c.cookies[record.Host] = append(c.cookies[record.Host], record)
+		return nil
+	})
+}
+
+// ChromeStream call fn for every decrypted cookie while the chromium cookies table is scanned,
+// it stops at the first error returned by fn or when ctx is done
+func (c *cookies) ChromeStream(ctx context.Context, secretKey []byte, fn func(Cookie) error) error {
+	cookieDB, err := sql.Open("sqlite3", filepath.Join(c.tempDir, ChromeCookieFile))
 	if err != nil {
 		return err
 	}
@@ -48,7 +65,8 @@ func (c *cookies) ChromeParse(secretKey []byte) error {
 			logger.Debug(err)
 		}
 	}()
-	rows, err := cookieDB.Query(QueryChromiumCookie)
+	version := chromiumCookieVersion(ctx, cookieDB)
+	rows, err := cookieDB.QueryContext(ctx, QueryChromiumCookie)
 	if err != nil {
 		return err
 	}
@@ -68,7 +86,7 @@ func (c *cookies) ChromeParse(secretKey []byte) error {
 		if err != nil {
 			logger.Error(err)
 		}
-		cookie := cookie{
+		record := Cookie{
 			KeyName:      key,
 			Host:         host,
 			Path:         path,
@@ -77,8 +95,8 @@ func (c *cookies) ChromeParse(secretKey []byte) error {
 			IsHTTPOnly:   filemgmt.IntToBool(isHTTPOnly),
 			HasExpire:    filemgmt.IntToBool(hasExpire),
 			IsPersistent: filemgmt.IntToBool(isPersistent),
-			CreateDate:   filemgmt.TimeEpochFormat(createDate),
-			ExpireDate:   filemgmt.TimeEpochFormat(expireDate),
+			CreateDate:   filemgmt.WebKitTime(createDate),
+			ExpireDate:   filemgmt.WebKitTime(expireDate),
 		}
 		// remove 'v10'
 		if secretKey == nil {
@@ -89,15 +107,33 @@ func (c *cookies) ChromeParse(secretKey []byte) error {
 		if err != nil {
 			logger.Debug(err)
 		}
-		cookie.Value = string(value)
-		c.cookies[host] = append(c.cookies[host], cookie)
+		if version >= chromiumCookieHashVersion && len(value) >= sha256.Size {
+			value = value[sha256.Size:]
+		}
+		record.Value = string(value)
+		if err = fn(record); err != nil {
+			return err
+		}
 	}
-	return nil
+	return rows.Err()
 }
 
 func (c *cookies) FirefoxParse() error {
-	c.cookies = make(map[string][]cookie)
-	cookieDB, err := sql.Open("sqlite3", FirefoxCookieFile)
+	return c.FirefoxParseContext(context.Background())
+}
+
+func (c *cookies) FirefoxParseContext(ctx context.Context) error {
+	c.cookies = make(map[string][]Cookie)
+	return c.FirefoxStream(ctx, func(record Cookie) error {
+		c.cookies[record.Host] = append(c.cookies[record.Host], record)
+		return nil
+	})
+}
+
+// FirefoxStream call fn for every cookie while the firefox moz_cookies table is scanned,
+// it stops at the first error returned by fn or when ctx is done
+func (c *cookies) FirefoxStream(ctx context.Context, fn func(Cookie) error) error {
+	cookieDB, err := sql.Open("sqlite3", filepath.Join(c.tempDir, FirefoxCookieFile))
 	if err != nil {
 		return err
 	}
@@ -106,7 +142,7 @@ func (c *cookies) FirefoxParse() error {
 			logger.Debug(err)
 		}
 	}()
-	rows, err := cookieDB.Query(QueryFirefoxCookie)
+	rows, err := cookieDB.QueryContext(ctx, QueryFirefoxCookie)
 	if err != nil {
 		return err
 	}
@@ -125,26 +161,48 @@ func (c *cookies) FirefoxParse() error {
 		if err != nil {
 			logger.Error(err)
 		}
-		c.cookies[host] = append(c.cookies[host], cookie{
+		err = fn(Cookie{
 			KeyName:    name,
 			Host:       host,
 			Path:       path,
 			IsSecure:   filemgmt.IntToBool(isSecure),
 			IsHTTPOnly: filemgmt.IntToBool(isHttpOnly),
-			CreateDate: filemgmt.TimeStampFormat(creationTime / 1000000),
-			ExpireDate: filemgmt.TimeStampFormat(expiry),
+			CreateDate: filemgmt.PRTime(creationTime),
+			ExpireDate: filemgmt.UnixTime(expiry),
 			Value:      value,
 		})
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return rows.Err()
+}
+
+// Records return all the parsed cookies ordered by host
+func (c *cookies) Records() []Cookie {
+	hosts := make([]string, 0, len(c.cookies))
+	for host := range c.cookies {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	var records []Cookie
+	for _, host := range hosts {
+		records = append(records, c.cookies[host]...)
+	}
+	return records
 }
 
 func (c *cookies) CopyDB() error {
-	return CopyToLocalPath(c.mainPath, filepath.Base(c.mainPath))
+	dir, err := copyToTempDir(c.mainPath)
+	if err != nil {
+		return err
+	}
+	c.tempDir = dir
+	return nil
 }
 
 func (c *cookies) Release() error {
-	return os.Remove(filepath.Base(c.mainPath))
+	return releaseTempDir(c.tempDir)
 }
 
 func (c *cookies) OutPut(format OutputFormat, browser, dir string) error {
@@ -155,6 +213,8 @@ func (c *cookies) OutPut(format OutputFormat, browser, dir string) error {
 	case formatConsole:
 		c.outPutConsole()
 		return nil
+	case formatJsonLines:
+		return c.outPutJsonLines(browser, dir)
 	default:
 		err := c.outPutJson(browser, dir)
 		return err
@@ -173,19 +233,43 @@ func (c *cookies) outPutJson(browser, dir string) error {
 
 func (c *cookies) outPutCsv(browser, dir string) error {
 	filename := filemgmt.FormatFileName(dir, browser, ItemNameCookie, GetFormatName(formatCSV))
-	var tempSlice []cookie
-	for _, v := range c.cookies {
-		tempSlice = append(tempSlice, v...)
-	}
-	if err := WriteToCsv(filename, tempSlice); err != nil {
+	if err := WriteToCsv(filename, c.Records()); err != nil {
 		return err
 	}
 	fmt.Printf("%s Get %d cookies, filename is %s \n", filemgmt.Prefix, len(c.cookies), filename)
 	return nil
 }
 
+func (c *cookies) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameCookie, GetFormatName(formatJsonLines))
+	records := c.Records()
+	if err := WriteToJsonLines(filename, records); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d cookies, filename is %s \n", filemgmt.Prefix, len(records), filename)
+	return nil
+}
+
 func (c *cookies) outPutConsole() {
 	for host, value := range c.cookies {
 		fmt.Printf("%s\n%+v\n", host, value)
 	}
 }
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// chromiumCookieVersion return the schema version from the meta table, 0 if it can't be read
+func chromiumCookieVersion(ctx context.Context, db queryRower) int {
+	var version int
+	if err := db.QueryRowContext(ctx, QueryChromiumMetaVersion).Scan(&version); err != nil {
+		logger.Debug(err)
+	}
+	return version
+}
+
+func hostHash(host string) []byte {
+	h := sha256.Sum256([]byte(host))
+	return h[:]
+}
@@ -0,0 +1,283 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/decrypt"
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+const (
+	chromiumCookieTable = "cookies"
+	firefoxCookieTable  = "moz_cookies"
+
+	// chromium prepends sha256(host_key) to the cookie value since this version
+	chromiumCookieHashVersion = 24
+
+	// firefox does not keep session cookies in moz_cookies, they get this lifetime instead
+	sessionCookieLifetime = 24 * time.Hour
+
+	// chromiumNetworkDir holds the Cookies database of the newer chromium profiles
+	chromiumNetworkDir = "Network"
+)
+
+// profileLock is a file the browser holds while it runs, the advisory ones stay on disk
+// when the browser is closed and are only held with a lock on the file
+type profileLock struct {
+	name     string
+	advisory bool
+}
+
+var (
+	// chromiumLockFiles are in the user data directory, SingletonLock is a symlink on
+	// linux and macOS, lockfile is deleted on close on Windows
+	chromiumLockFiles = []profileLock{{"SingletonLock", false}, {"lockfile", false}}
+
+	// firefoxLockFiles are in the profile directory, lock is a symlink on linux,
+	// parent.lock is deleted on close on Windows and .parentlock is locked with fcntl
+	firefoxLockFiles = []profileLock{{"lock", false}, {"parent.lock", false}, {".parentlock", true}}
+
+	errProfileInUse = errors.New("target profile is in use, close the browser first")
+)
+
+// CookieImporter writes cookie records into a closed chromium Cookies or firefox cookies.sqlite database
+type CookieImporter struct {
+	targetPath string
+	secretKey  []byte
+	dryRun     bool
+}
+
+// NewCookieImporter return a cookie importer for the target database, secretKey is the
+// target chromium profile key and is ignored for firefox. In dry-run mode every row is
+// inserted inside a transaction that is rolled back.
+func NewCookieImporter(targetPath string, secretKey []byte, dryRun bool) *CookieImporter {
+	return &CookieImporter{targetPath: targetPath, secretKey: secretKey, dryRun: dryRun}
+}
+
+// Import writes the cookie records and returns how many rows were written
+func (ci *CookieImporter) Import(records []Cookie) (int, error) {
+	return ci.ImportContext(context.Background(), records)
+}
+
+// ImportContext is Import bounded by ctx, the transaction is rolled back when ctx is done
+func (ci *CookieImporter) ImportContext(ctx context.Context, records []Cookie) (int, error) {
+	if err := checkProfileClosed(ci.targetPath); err != nil {
+		return 0, err
+	}
+
+	var table string
+	switch filepath.Base(ci.targetPath) {
+	case ChromeCookieFile:
+		table = chromiumCookieTable
+	case FirefoxCookieFile:
+		table = firefoxCookieTable
+	default:
+		return 0, fmt.Errorf("%s is not a cookie database", ci.targetPath)
+	}
+
+	// exclusive transactions fail right away if the browser still holds the database
+	cookieDB, err := sql.Open("sqlite3", ci.targetPath+"?_txlock=exclusive&_busy_timeout=0")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := cookieDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	tx, err := cookieDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errProfileInUse, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Debug(err)
+		}
+	}()
+
+	columns, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	if table == chromiumCookieTable {
+		version = chromiumCookieVersion(ctx, tx)
+	}
+
+	var count int
+	for _, v := range records {
+		var values map[string]interface{}
+		if table == chromiumCookieTable {
+			values, err = ci.chromiumValues(v, version)
+		} else {
+			values = firefoxCookieValues(v)
+		}
+		if err != nil {
+			return count, err
+		}
+		if err = insertRow(ctx, tx, table, columns, values); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if ci.dryRun {
+		fmt.Printf("%s Dry run, %d cookies would be written to %s \n", filemgmt.Prefix, count, ci.targetPath)
+		return count, nil
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	fmt.Printf("%s Import %d cookies, filename is %s \n", filemgmt.Prefix, count, ci.targetPath)
+	return count, nil
+}
+
+func (ci *CookieImporter) chromiumValues(c Cookie, version int) (map[string]interface{}, error) {
+	var (
+		value []byte
+		err   error
+	)
+	plain := []byte(c.Value)
+	if version >= chromiumCookieHashVersion {
+		plain = append(hostHash(c.Host), plain...)
+	}
+	if ci.secretKey == nil {
+		value, err = decrypt.DPApiEncrypt(plain)
+	} else {
+		value, err = decrypt.EncryptChromePass(ci.secretKey, plain)
+	}
+	if err != nil {
+		return nil, err
+	}
+	created := c.CreateDate
+	if created.IsZero() {
+		created = time.Now()
+	}
+	var expires int64
+	hasExpire := cookieExpires(c)
+	if hasExpire {
+		expires = filemgmt.ToWebKitTime(c.ExpireDate)
+	}
+	sourceScheme := 1
+	if c.IsSecure {
+		sourceScheme = 2
+	}
+	return map[string]interface{}{
+		"creation_utc":    filemgmt.ToWebKitTime(created),
+		"host_key":        c.Host,
+		"name":            c.KeyName,
+		"value":           "",
+		"encrypted_value": value,
+		"path":            c.Path,
+		"expires_utc":     expires,
+		"is_secure":       c.IsSecure,
+		"is_httponly":     c.IsHTTPOnly,
+		"last_access_utc": filemgmt.ToWebKitTime(created),
+		"last_update_utc": filemgmt.ToWebKitTime(created),
+		"has_expires":     hasExpire,
+		"is_persistent":   hasExpire,
+		"priority":        1,
+		"samesite":        -1,
+		"source_scheme":   sourceScheme,
+		"source_port":     -1,
+	}, nil
+}
+
+func firefoxCookieValues(c Cookie) map[string]interface{} {
+	created := c.CreateDate
+	if created.IsZero() {
+		created = time.Now()
+	}
+	expires := c.ExpireDate
+	if !cookieExpires(c) {
+		expires = time.Now().Add(sessionCookieLifetime)
+	}
+	schemeMap := 1
+	if c.IsSecure {
+		schemeMap = 2
+	}
+	return map[string]interface{}{
+		"originAttributes": "",
+		"name":             c.KeyName,
+		"value":            c.Value,
+		"host":             c.Host,
+		"path":             c.Path,
+		"expiry":           filemgmt.ToUnixTime(expires),
+		"lastAccessed":     filemgmt.ToPRTime(created),
+		"creationTime":     filemgmt.ToPRTime(created),
+		"isSecure":         c.IsSecure,
+		"isHttpOnly":       c.IsHTTPOnly,
+		"schemeMap":        schemeMap,
+	}
+}
+
+// cookieExpires reports if the cookie has a real expiry date, chromium session cookies
+// are read as the zero time and firefox never sets HasExpire
+func cookieExpires(c Cookie) bool {
+	return c.ExpireDate.After(time.Unix(0, 0))
+}
+
+// insertRow fill the known columns from values and give every other NOT NULL
+// column without default a zero value of its type
+func insertRow(ctx context.Context, tx *sql.Tx, table string, columns []tableColumn, values map[string]interface{}) error {
+	var (
+		names []string
+		args  []interface{}
+	)
+	for _, col := range columns {
+		if v, ok := values[col.name]; ok {
+			names = append(names, col.name)
+			args = append(args, v)
+			continue
+		}
+		if !col.notNull || col.hasDflt || col.isPrimary {
+			continue
+		}
+		names = append(names, col.name)
+		switch {
+		case strings.Contains(col.colType, "INT"):
+			args = append(args, 0)
+		case strings.Contains(col.colType, "BLOB"):
+			args = append(args, []byte{})
+		default:
+			args = append(args, "")
+		}
+	}
+	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES (%s)",
+		table, strings.Join(names, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// checkProfileClosed look for the lock files of the browser that owns the database,
+// chromium keeps them in the user data directory above the profile
+func checkProfileClosed(dbPath string) error {
+	dir, locks := filepath.Dir(dbPath), firefoxLockFiles
+	if filepath.Base(dbPath) == ChromeCookieFile {
+		if filepath.Base(dir) == chromiumNetworkDir {
+			dir = filepath.Dir(dir)
+		}
+		dir, locks = filepath.Dir(dir), chromiumLockFiles
+	}
+	for _, l := range locks {
+		path := filepath.Join(dir, l.name)
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if !l.advisory || fileLocked(path) {
+			return errProfileInUse
+		}
+	}
+	return nil
+}
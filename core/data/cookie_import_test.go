@@ -0,0 +1,144 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/decrypt"
+)
+
+func createDB(t *testing.T, path, schema string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err = db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const firefoxCookieSchema = `CREATE TABLE moz_cookies (id INTEGER PRIMARY KEY, originAttributes TEXT NOT NULL DEFAULT '',
+	name TEXT, value TEXT, host TEXT, path TEXT, expiry INTEGER, lastAccessed INTEGER, creationTime INTEGER,
+	isSecure INTEGER, isHttpOnly INTEGER, CONSTRAINT moz_uniqueid UNIQUE (name, host, path, originAttributes))`
+
+func firefoxCookieValue(t *testing.T, path string) (int, string) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var (
+		count int
+		value sql.NullString
+	)
+	if err = db.QueryRow(`SELECT COUNT(*), MAX(value) FROM moz_cookies`).Scan(&count, &value); err != nil {
+		t.Fatal(err)
+	}
+	return count, value.String
+}
+
+func TestCookieImporterFirefox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FirefoxCookieFile)
+	createDB(t, path, firefoxCookieSchema)
+	cookie := Cookie{Host: ".a.test", Path: "/", KeyName: "sid", Value: "one", ExpireDate: time.Now().Add(time.Hour)}
+
+	n, err := NewCookieImporter(path, nil, true).Import([]Cookie{cookie})
+	if err != nil || n != 1 {
+		t.Fatalf("dry run got %d, %v", n, err)
+	}
+	if count, _ := firefoxCookieValue(t, path); count != 0 {
+		t.Fatalf("dry run wrote %d rows", count)
+	}
+
+	if _, err = NewCookieImporter(path, nil, false).Import([]Cookie{cookie}); err != nil {
+		t.Fatal(err)
+	}
+	cookie.Value = "two"
+	if _, err = NewCookieImporter(path, nil, false).Import([]Cookie{cookie}); err != nil {
+		t.Fatal(err)
+	}
+	if count, value := firefoxCookieValue(t, path); count != 1 || value != "two" {
+		t.Errorf("got %d rows with value %q, want the row replaced", count, value)
+	}
+}
+
+func TestCookieImporterChromiumSchema(t *testing.T) {
+	userData := t.TempDir()
+	path := filepath.Join(userData, "Default", chromiumNetworkDir, ChromeCookieFile)
+	// an old reduced table and a NOT NULL column the importer doesn't know about
+	createDB(t, path, `CREATE TABLE meta (key TEXT, value TEXT);
+		INSERT INTO meta VALUES ('version', '24');
+		CREATE TABLE cookies (creation_utc INTEGER NOT NULL, host_key TEXT NOT NULL, name TEXT NOT NULL,
+		value TEXT NOT NULL, path TEXT NOT NULL, expires_utc INTEGER NOT NULL, is_secure INTEGER NOT NULL,
+		encrypted_value BLOB NOT NULL, top_frame_site_key TEXT NOT NULL, UNIQUE (host_key, name, path))`)
+	key := []byte("0123456789abcdef")
+	cookie := Cookie{Host: ".a.test", Path: "/", KeyName: "sid", Value: "secret", IsSecure: true}
+	if _, err := NewCookieImporter(path, key, false).Import([]Cookie{cookie}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var (
+		expires       int64
+		topFrame      string
+		encryptedPass []byte
+	)
+	err = db.QueryRow(`SELECT expires_utc, top_frame_site_key, encrypted_value FROM cookies`).Scan(&expires, &topFrame, &encryptedPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := decrypt.ChromePass(key, encryptedPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expires != 0 || topFrame != "" || !bytes.Equal(value, append(hostHash(".a.test"), "secret"...)) {
+		t.Errorf("got expires %d, top frame %q, value %q", expires, topFrame, value)
+	}
+}
+
+func TestCookieImporterProfileInUse(t *testing.T) {
+	userData := t.TempDir()
+	chromePath := filepath.Join(userData, "Default", chromiumNetworkDir, ChromeCookieFile)
+	createDB(t, chromePath, `CREATE TABLE cookies (name TEXT)`)
+	if err := os.WriteFile(filepath.Join(userData, "lockfile"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCookieImporter(chromePath, nil, true).Import(nil); !errors.Is(err, errProfileInUse) {
+		t.Errorf("chromium got %v, want %v", err, errProfileInUse)
+	}
+
+	profile := t.TempDir()
+	firefoxPath := filepath.Join(profile, FirefoxCookieFile)
+	createDB(t, firefoxPath, firefoxCookieSchema)
+	// a .parentlock nobody holds is left behind by every closed firefox
+	if err := os.WriteFile(filepath.Join(profile, ".parentlock"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCookieImporter(firefoxPath, nil, true).Import(nil); err != nil {
+		t.Errorf("closed firefox got %v", err)
+	}
+	if err := os.Symlink("127.0.0.1:+1234", filepath.Join(profile, "lock")); err != nil {
+		t.Skip(err)
+	}
+	if _, err := NewCookieImporter(firefoxPath, nil, true).Import(nil); !errors.Is(err, errProfileInUse) {
+		t.Errorf("firefox got %v, want %v", err, errProfileInUse)
+	}
+}
@@ -4,16 +4,18 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/core/decrypt"
 	"github.com/teocci/go-chrome-cookies/filemgmt"
 	"github.com/teocci/go-chrome-cookies/logger"
-	"os"
 	"path/filepath"
+	"sort"
 )
 
-type card struct {
+// CreditCard is a single card saved in the chromium Web Data database
+type CreditCard struct {
 	GUID            string
 	Name            string
 	ExpirationYear  string
@@ -23,7 +25,8 @@ type card struct {
 
 type creditCards struct {
 	mainPath string
-	cards    map[string][]card
+	tempDir  string
+	cards    map[string][]CreditCard
 }
 
 func NewCCards(main string, sub string) Item {
@@ -31,12 +34,20 @@ func NewCCards(main string, sub string) Item {
 }
 
 func (c *creditCards) FirefoxParse() error {
+	return c.FirefoxParseContext(context.Background())
+}
+
+func (c *creditCards) FirefoxParseContext(ctx context.Context) error {
 	return nil // FireFox does not have a credit card saving feature
 }
 
 func (c *creditCards) ChromeParse(secretKey []byte) error {
-	c.cards = make(map[string][]card)
-	creditDB, err := sql.Open("sqlite3", ChromeCreditFile)
+	return c.ChromeParseContext(context.Background(), secretKey)
+}
+
+func (c *creditCards) ChromeParseContext(ctx context.Context, secretKey []byte) error {
+	c.cards = make(map[string][]CreditCard)
+	creditDB, err := sql.Open("sqlite3", filepath.Join(c.tempDir, ChromeCreditFile))
 	if err != nil {
 		return err
 	}
@@ -45,7 +56,7 @@ func (c *creditCards) ChromeParse(secretKey []byte) error {
 			logger.Debug(err)
 		}
 	}()
-	rows, err := creditDB.Query(QueryChromiumCredit)
+	rows, err := creditDB.QueryContext(ctx, QueryChromiumCredit)
 	if err != nil {
 		return err
 	}
@@ -63,7 +74,7 @@ func (c *creditCards) ChromeParse(secretKey []byte) error {
 		if err != nil {
 			logger.Error(err)
 		}
-		creditCardInfo := card{
+		creditCardInfo := CreditCard{
 			GUID:            guid,
 			Name:            name,
 			ExpirationMonth: month,
@@ -83,12 +94,31 @@ func (c *creditCards) ChromeParse(secretKey []byte) error {
 	return nil
 }
 
+// Records return all the parsed credit cards ordered by guid
+func (c *creditCards) Records() []CreditCard {
+	guids := make([]string, 0, len(c.cards))
+	for guid := range c.cards {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	var records []CreditCard
+	for _, guid := range guids {
+		records = append(records, c.cards[guid]...)
+	}
+	return records
+}
+
 func (c *creditCards) CopyDB() error {
-	return CopyToLocalPath(c.mainPath, filepath.Base(c.mainPath))
+	dir, err := copyToTempDir(c.mainPath)
+	if err != nil {
+		return err
+	}
+	c.tempDir = dir
+	return nil
 }
 
 func (c *creditCards) Release() error {
-	return os.Remove(filepath.Base(c.mainPath))
+	return releaseTempDir(c.tempDir)
 }
 
 func (c *creditCards) OutPut(format OutputFormat, browser, dir string) error {
@@ -99,6 +129,8 @@ func (c *creditCards) OutPut(format OutputFormat, browser, dir string) error {
 	case formatConsole:
 		c.outPutConsole()
 		return nil
+	case formatJsonLines:
+		return c.outPutJsonLines(browser, dir)
 	default:
 		err := c.outPutJson(browser, dir)
 		return err
@@ -117,11 +149,16 @@ func (c *creditCards) outPutJson(browser, dir string) error {
 
 func (c *creditCards) outPutCsv(browser, dir string) error {
 	filename := filemgmt.FormatFileName(dir, browser, ItemNameCreditCard, GetFormatName(formatCSV))
-	var tempSlice []card
-	for _, v := range c.cards {
-		tempSlice = append(tempSlice, v...)
+	if err := WriteToCsv(filename, c.Records()); err != nil {
+		return err
 	}
-	if err := WriteToCsv(filename, tempSlice); err != nil {
+	fmt.Printf("%s Get %d credit cards, filename is %s \n", filemgmt.Prefix, len(c.cards), filename)
+	return nil
+}
+
+func (c *creditCards) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameCreditCard, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, c.Records()); err != nil {
 		return err
 	}
 	fmt.Printf("%s Get %d credit cards, filename is %s \n", filemgmt.Prefix, len(c.cards), filename)
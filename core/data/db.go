@@ -0,0 +1,91 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+type tableColumn struct {
+	name      string
+	colType   string
+	notNull   bool
+	hasDflt   bool
+	isPrimary bool
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// tableColumns read the table schema, browser tables change between browser versions
+func tableColumns(ctx context.Context, db queryer, table string) ([]tableColumn, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var columns []tableColumn
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             sql.NullString
+		)
+		if err = rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, tableColumn{
+			name:      name,
+			colType:   strings.ToUpper(colType),
+			notNull:   filemgmt.IntToBool(notNull),
+			hasDflt:   dflt.Valid,
+			isPrimary: filemgmt.IntToBool(pk),
+		})
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return columns, rows.Err()
+}
+
+// hasColumn report if the schema read by tableColumns has the named column
+func hasColumn(columns []tableColumn, name string) bool {
+	for _, c := range columns {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnDefault is a column to select and the value used when the table doesn't have it
+type columnDefault struct {
+	name string
+	dflt string
+}
+
+// selectColumns build the select list of wanted, columns missing in the schema or NULL
+// are replaced by their default so the scan targets never change
+func selectColumns(columns []tableColumn, wanted []columnDefault) string {
+	list := make([]string, len(wanted))
+	for i, w := range wanted {
+		if hasColumn(columns, w.name) {
+			list[i] = fmt.Sprintf("IFNULL(%s, %s)", w.name, w.dflt)
+		} else {
+			list[i] = w.dflt
+		}
+	}
+	return strings.Join(list, ", ")
+}
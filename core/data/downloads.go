@@ -4,29 +4,155 @@
 package data
 
 import (
+	"context"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/filemgmt"
 	"github.com/teocci/go-chrome-cookies/logger"
 	"github.com/tidwall/gjson"
-	"os"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-type download struct {
-	TargetPath string
-	Url        string
-	TotalBytes int64
-	StartTime  time.Time
-	EndTime    time.Time
-	MimeType   string
+const (
+	firefoxAnnoDestination = "downloads/destinationFileURI"
+	firefoxAnnoFileName    = "downloads/destinationFileName"
+	firefoxAnnoMetaData    = "downloads/metaData"
+
+	// downloadChainSep joins the redirect chain, spaces are always escaped in urls
+	downloadChainSep = " -> "
+)
+
+// chromiumDownloadColumns are read when present, the downloads table grew over the years
+var chromiumDownloadColumns = []columnDefault{
+	{"id", "0"},
+	{"target_path", "''"},
+	{"tab_url", "''"},
+	{"tab_referrer_url", "''"},
+	{"referrer", "''"},
+	{"site_url", "''"},
+	{"total_bytes", "0"},
+	{"received_bytes", "0"},
+	{"start_time", "0"},
+	{"end_time", "0"},
+	{"state", "0"},
+	{"danger_type", "0"},
+	{"interrupt_reason", "0"},
+	{"opened", "0"},
+	{"hash", "x''"},
+	{"mime_type", "''"},
+}
+
+// chromiumDownloadStates is download::DownloadState as stored in the history db
+var chromiumDownloadStates = map[int64]string{
+	0: "in_progress",
+	1: "complete",
+	2: "cancelled",
+	3: "interrupted",
+	4: "interrupted",
+}
+
+// chromiumDangerTypes is download::DownloadDangerType as stored in the history db
+var chromiumDangerTypes = []string{
+	"not_dangerous",
+	"dangerous_file",
+	"dangerous_url",
+	"dangerous_content",
+	"maybe_dangerous_content",
+	"uncommon_content",
+	"user_validated",
+	"dangerous_host",
+	"potentially_unwanted",
+	"allowlisted_by_policy",
+	"async_scanning",
+	"blocked_password_protected",
+	"blocked_too_large",
+	"sensitive_content_warning",
+	"sensitive_content_block",
+	"deep_scanned_safe",
+	"deep_scanned_opened_dangerous",
+	"prompt_for_scanning",
+	"blocked_unsupported_filetype",
+	"dangerous_account_compromise",
+}
+
+// chromiumInterruptReasons is download::DownloadInterruptReason, 0 means not interrupted
+var chromiumInterruptReasons = map[int64]string{
+	1:  "file_failed",
+	2:  "file_access_denied",
+	3:  "file_no_space",
+	5:  "file_name_too_long",
+	6:  "file_too_large",
+	7:  "file_virus_infected",
+	10: "file_transient_error",
+	11: "file_blocked",
+	12: "file_security_check_failed",
+	13: "file_too_short",
+	14: "file_hash_mismatch",
+	15: "file_same_as_source",
+	20: "network_failed",
+	21: "network_timeout",
+	22: "network_disconnected",
+	23: "network_server_down",
+	24: "network_invalid_request",
+	30: "server_failed",
+	31: "server_no_range",
+	33: "server_bad_content",
+	34: "server_unauthorized",
+	35: "server_cert_problem",
+	36: "server_forbidden",
+	37: "server_unreachable",
+	38: "server_content_length_mismatch",
+	39: "server_cross_origin_redirect",
+	40: "user_canceled",
+	41: "user_shutdown",
+	50: "crash",
+}
+
+// firefoxDownloadStates is the state of the downloads/metaData annotation
+var firefoxDownloadStates = map[int64]string{
+	0: "in_progress",
+	1: "complete",
+	2: "failed",
+	3: "cancelled",
+	4: "paused",
+	6: "blocked_parental",
+	8: "blocked_reputation",
+	9: "blocked_policy",
+}
+
+// Download is a single entry of the browser download history. Url is the url the file
+// came from, the last of UrlChain or TabUrl when there is no chain, and TabUrl the page
+// the download started from.
+type Download struct {
+	ID              int64
+	TargetPath      string
+	Url             string
+	UrlChain        string
+	TabUrl          string
+	TabReferrerUrl  string
+	Referrer        string
+	SiteUrl         string
+	TotalBytes      int64
+	ReceivedBytes   int64
+	StartTime       time.Time
+	EndTime         time.Time
+	State           string
+	DangerType      string
+	InterruptReason string
+	Opened          bool
+	Deleted         bool
+	Hash            string
+	MimeType        string
 }
 
 type downloads struct {
 	mainPath  string
-	downloads []download
+	tempDir   string
+	downloads []Download
 }
 
 func NewDownloads(main, sub string) Item {
@@ -34,7 +160,11 @@ func NewDownloads(main, sub string) Item {
 }
 
 func (d *downloads) ChromeParse(key []byte) error {
-	historyDB, err := sql.Open("sqlite3", ChromeDownloadFile)
+	return d.ChromeParseContext(context.Background(), key)
+}
+
+func (d *downloads) ChromeParseContext(ctx context.Context, key []byte) error {
+	historyDB, err := sql.Open("sqlite3", filepath.Join(d.tempDir, ChromeDownloadFile))
 	if err != nil {
 		return err
 	}
@@ -43,7 +173,15 @@ func (d *downloads) ChromeParse(key []byte) error {
 			logger.Error(err)
 		}
 	}()
-	rows, err := historyDB.Query(QueryChromiumDownload)
+	columns, err := tableColumns(ctx, historyDB, "downloads")
+	if err != nil {
+		return err
+	}
+	chains, err := chromiumUrlChains(ctx, historyDB)
+	if err != nil {
+		logger.Debug(err)
+	}
+	rows, err := historyDB.QueryContext(ctx, fmt.Sprintf(QueryChromiumDownload, selectColumns(columns, chromiumDownloadColumns)))
 	if err != nil {
 		return err
 	}
@@ -52,41 +190,92 @@ func (d *downloads) ChromeParse(key []byte) error {
 			logger.Error(err)
 		}
 	}()
+	d.downloads = nil
 	for rows.Next() {
 		var (
-			targetPath, tabUrl, mimeType   string
-			totalBytes, startTime, endTime int64
+			id, totalBytes, receivedBytes, startTime, endTime int64
+			state, dangerType, interruptReason, opened        int64
+			targetPath, tabUrl, tabReferrer, referrer         string
+			siteUrl, mimeType                                 string
+			hash                                              []byte
 		)
-		err := rows.Scan(&targetPath, &tabUrl, &totalBytes, &startTime, &endTime, &mimeType)
-		data := download{
-			TargetPath: targetPath,
-			Url:        tabUrl,
-			TotalBytes: totalBytes,
-			StartTime:  filemgmt.TimeEpochFormat(startTime),
-			EndTime:    filemgmt.TimeEpochFormat(endTime),
-			MimeType:   mimeType,
-		}
+		err = rows.Scan(&id, &targetPath, &tabUrl, &tabReferrer, &referrer, &siteUrl, &totalBytes, &receivedBytes,
+			&startTime, &endTime, &state, &dangerType, &interruptReason, &opened, &hash, &mimeType)
 		if err != nil {
 			logger.Error(err)
+			continue
+		}
+		download := Download{
+			ID:              id,
+			TargetPath:      targetPath,
+			Url:             tabUrl,
+			UrlChain:        strings.Join(chains[id], downloadChainSep),
+			TabUrl:          tabUrl,
+			TabReferrerUrl:  tabReferrer,
+			Referrer:        referrer,
+			SiteUrl:         siteUrl,
+			TotalBytes:      totalBytes,
+			ReceivedBytes:   receivedBytes,
+			StartTime:       filemgmt.WebKitTime(startTime),
+			EndTime:         filemgmt.WebKitTime(endTime),
+			State:           chromiumDownloadStates[state],
+			DangerType:      "unknown",
+			InterruptReason: chromiumInterruptReasons[interruptReason],
+			Opened:          filemgmt.IntToBool(int(opened)),
+			Hash:            hex.EncodeToString(hash),
+			MimeType:        mimeType,
 		}
-		d.downloads = append(d.downloads, data)
+		if chain := chains[id]; len(chain) > 0 {
+			download.Url = chain[len(chain)-1]
+		}
+		if dangerType >= 0 && dangerType < int64(len(chromiumDangerTypes)) {
+			download.DangerType = chromiumDangerTypes[dangerType]
+		}
+		d.downloads = append(d.downloads, download)
 	}
-	return nil
+	return rows.Err()
+}
+
+// chromiumUrlChains return the redirect chain of every download id, in chain order
+func chromiumUrlChains(ctx context.Context, db *sql.DB) (map[int64][]string, error) {
+	rows, err := db.QueryContext(ctx, QueryChromiumUrlChains)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	chains := make(map[int64][]string)
+	for rows.Next() {
+		var (
+			id  int64
+			url string
+		)
+		if err = rows.Scan(&id, &url); err != nil {
+			return nil, err
+		}
+		chains[id] = append(chains[id], url)
+	}
+	return chains, rows.Err()
 }
 
 func (d *downloads) FirefoxParse() error {
+	return d.FirefoxParseContext(context.Background())
+}
+
+func (d *downloads) FirefoxParseContext(ctx context.Context) error {
 	var (
 		err          error
 		keyDB        *sql.DB
 		downloadRows *sql.Rows
-		tempMap      map[int64]string
 	)
-	tempMap = make(map[int64]string)
-	keyDB, err = sql.Open("sqlite3", FirefoxDataFile)
+	keyDB, err = sql.Open("sqlite3", filepath.Join(d.tempDir, FirefoxDataFile))
 	if err != nil {
 		return err
 	}
-	_, err = keyDB.Exec(CloseJournalMode)
+	_, err = keyDB.ExecContext(ctx, CloseJournalMode)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -95,7 +284,7 @@ func (d *downloads) FirefoxParse() error {
 			logger.Error(err)
 		}
 	}()
-	downloadRows, err = keyDB.Query(QueryFirefoxDownload)
+	downloadRows, err = keyDB.QueryContext(ctx, QueryFirefoxDownload)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -105,40 +294,82 @@ func (d *downloads) FirefoxParse() error {
 			logger.Error(err)
 		}
 	}()
+	// every annotation is a row, rows are ordered by place so a download is complete
+	// once the place id changes
+	d.downloads = nil
+	var current *Download
 	for downloadRows.Next() {
 		var (
-			content, url       string
+			name, content, url string
 			placeID, dateAdded int64
 		)
-		err = downloadRows.Scan(&placeID, &content, &url, &dateAdded)
+		err = downloadRows.Scan(&placeID, &name, &content, &url, &dateAdded)
 		if err != nil {
 			logger.Warn(err)
+			continue
 		}
-		contentList := strings.Split(content, ",{")
-		if len(contentList) > 1 {
-			path := contentList[0]
-			json := "{" + contentList[1]
-			endTime := gjson.Get(json, "endTime")
-			fileSize := gjson.Get(json, "fileSize")
-			d.downloads = append(d.downloads, download{
-				TargetPath: path,
-				Url:        url,
-				TotalBytes: fileSize.Int(),
-				StartTime:  filemgmt.TimeStampFormat(dateAdded / 1000000),
-				EndTime:    filemgmt.TimeStampFormat(endTime.Int() / 1000),
+		if current == nil || current.ID != placeID {
+			d.downloads = append(d.downloads, Download{
+				ID:        placeID,
+				Url:       url,
+				UrlChain:  url,
+				StartTime: filemgmt.PRTime(dateAdded),
 			})
+			current = &d.downloads[len(d.downloads)-1]
+		}
+		switch name {
+		case firefoxAnnoDestination:
+			current.TargetPath = fileURIToPath(content)
+		case firefoxAnnoFileName:
+			if current.TargetPath == "" {
+				current.TargetPath = content
+			}
+		case firefoxAnnoMetaData:
+			meta := gjson.Parse(content)
+			current.TotalBytes = meta.Get("fileSize").Int()
+			current.EndTime = filemgmt.UnixMilliTime(meta.Get("endTime").Int())
+			current.Deleted = meta.Get("deleted").Bool()
+			if state := meta.Get("state"); state.Exists() {
+				current.State = firefoxDownloadStates[state.Int()]
+			}
+			if current.State == "complete" {
+				current.ReceivedBytes = current.TotalBytes
+			}
 		}
-		tempMap[placeID] = url
 	}
-	return nil
+	return downloadRows.Err()
+}
+
+// fileURIToPath turn the file:// uri of the firefox destination annotation into a path
+func fileURIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	p := u.Path
+	// file:///C:/dir is a windows path
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return p
+}
+
+// Records return all the parsed downloads
+func (d *downloads) Records() []Download {
+	return d.downloads
 }
 
 func (d *downloads) CopyDB() error {
-	return CopyToLocalPath(d.mainPath, filepath.Base(d.mainPath))
+	dir, err := copyToTempDir(d.mainPath)
+	if err != nil {
+		return err
+	}
+	d.tempDir = dir
+	return nil
 }
 
 func (d *downloads) Release() error {
-	return os.Remove(filepath.Base(d.mainPath))
+	return releaseTempDir(d.tempDir)
 }
 
 func (d *downloads) OutPut(format OutputFormat, browser, dir string) error {
@@ -146,6 +377,8 @@ func (d *downloads) OutPut(format OutputFormat, browser, dir string) error {
 	case formatCSV:
 		err := d.outPutCsv(browser, dir)
 		return err
+	case formatJsonLines:
+		return d.outPutJsonLines(browser, dir)
 	case formatConsole:
 		d.outPutConsole()
 		return nil
@@ -174,6 +407,15 @@ func (d *downloads) outPutCsv(browser, dir string) error {
 	return nil
 }
 
+func (d *downloads) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameDownload, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, d.downloads); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d downloads history, filename is %s \n", filemgmt.Prefix, len(d.downloads), filename)
+	return nil
+}
+
 func (d *downloads) outPutConsole() {
 	for _, v := range d.downloads {
 		fmt.Printf("%+v\n", v)
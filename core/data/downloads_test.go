@@ -0,0 +1,68 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChromiumDownloads(t *testing.T) {
+	dir := t.TempDir()
+	// an older table without tab urls, mime type and hash
+	createDB(t, filepath.Join(dir, ChromeDownloadFile), `CREATE TABLE downloads (id INTEGER PRIMARY KEY, target_path TEXT,
+		referrer TEXT, total_bytes INTEGER, received_bytes INTEGER, start_time INTEGER, end_time INTEGER, state INTEGER,
+		danger_type INTEGER, interrupt_reason INTEGER, opened INTEGER);
+		CREATE TABLE downloads_url_chains (id INTEGER, chain_index INTEGER, url TEXT);
+		INSERT INTO downloads VALUES (1, '/tmp/a.zip', 'https://a.test/', 10, 10, 13300000000000000, 13300000001000000, 1, 6, 0, 1);
+		INSERT INTO downloads VALUES (2, '/tmp/b.exe', '', 20, 5, 13300000000000000, 0, 4, 99, 21, 0);
+		INSERT INTO downloads_url_chains VALUES (1, 1, 'https://cdn.a.test/a.zip');
+		INSERT INTO downloads_url_chains VALUES (1, 0, 'https://a.test/get?f=a.zip');`)
+	d := &downloads{tempDir: dir}
+	if err := d.ChromeParse(nil); err != nil {
+		t.Fatal(err)
+	}
+	r := d.Records()
+	if len(r) != 2 {
+		t.Fatalf("got %d downloads, want 2", len(r))
+	}
+	if v := r[0]; v.Url != "https://cdn.a.test/a.zip" || v.UrlChain != "https://a.test/get?f=a.zip -> https://cdn.a.test/a.zip" ||
+		v.State != "complete" || v.DangerType != "user_validated" || v.InterruptReason != "" || !v.Opened || v.EndTime.IsZero() {
+		t.Errorf("got %+v", v)
+	}
+	if v := r[1]; v.Url != "" || v.UrlChain != "" || v.State != "interrupted" || v.DangerType != "unknown" ||
+		v.InterruptReason != "network_timeout" || v.ReceivedBytes != 5 {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestFirefoxDownloads(t *testing.T) {
+	dir := t.TempDir()
+	createDB(t, filepath.Join(dir, FirefoxDataFile), `CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url TEXT);
+		CREATE TABLE moz_anno_attributes (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE moz_annos (id INTEGER PRIMARY KEY, place_id INTEGER, anno_attribute_id INTEGER, content TEXT, dateAdded INTEGER);
+		INSERT INTO moz_places VALUES (1, 'https://a.test/a.zip'), (2, 'https://b.test/b.pdf');
+		INSERT INTO moz_anno_attributes VALUES (1, 'downloads/destinationFileURI'), (2, 'downloads/metaData'),
+			(3, 'downloads/destinationFileName'), (4, 'bookmarkProperties/description');
+		INSERT INTO moz_annos VALUES (1, 1, 1, 'file:///C:/Users/me/a%20b.zip', 1700000000000000);
+		INSERT INTO moz_annos VALUES (2, 1, 2, '{"state":1,"endTime":1700000005000,"fileSize":42}', 1700000000000000);
+		INSERT INTO moz_annos VALUES (3, 2, 3, 'b.pdf', 1700000100000000);
+		INSERT INTO moz_annos VALUES (4, 2, 2, '{"state":3,"deleted":true,"fileSize":7}', 1700000100000000);
+		INSERT INTO moz_annos VALUES (5, 2, 4, 'not a download', 1700000100000000);`)
+	d := &downloads{tempDir: dir}
+	if err := d.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	r := d.Records()
+	if len(r) != 2 {
+		t.Fatalf("got %d downloads, want 2", len(r))
+	}
+	if v := r[0]; v.TargetPath != "C:/Users/me/a b.zip" || v.State != "complete" || v.TotalBytes != 42 || v.ReceivedBytes != 42 ||
+		v.EndTime.UnixMilli() != 1700000005000 || v.StartTime.Unix() != 1700000000 {
+		t.Errorf("got %+v", v)
+	}
+	if v := r[1]; v.TargetPath != "b.pdf" || v.State != "cancelled" || !v.Deleted || v.ReceivedBytes != 0 || v.Url != "https://b.test/b.pdf" {
+		t.Errorf("got %+v", v)
+	}
+}
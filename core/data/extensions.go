@@ -0,0 +1,453 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// extensionListSep joins the permissions, csv can't hold a list
+	extensionListSep = ", "
+
+	chromiumExtensionStateEnabled = 1
+)
+
+// chromiumExtensionLocations is extensions::mojom::ManifestLocation as saved in the preferences
+var chromiumExtensionLocations = map[int64]string{
+	1:  "internal",
+	2:  "external_pref",
+	3:  "external_registry",
+	4:  "unpacked",
+	5:  "component",
+	6:  "external_pref_download",
+	7:  "external_policy_download",
+	8:  "command_line",
+	9:  "external_policy",
+	10: "external_component",
+}
+
+// Extension is an installed extension, app or theme. Permissions are the api permissions
+// and HostPermissions the sites the extension asks access to.
+type Extension struct {
+	ID              string
+	Name            string
+	Version         string
+	Description     string
+	Type            string
+	InstallSource   string
+	Enabled         bool
+	Permissions     string
+	HostPermissions string
+	UpdateUrl       string
+	InstallTime     time.Time
+	Path            string
+}
+
+type extensions struct {
+	mainPath   string
+	subPath    string
+	tempDir    string
+	extensions []Extension
+}
+
+func NewExtensions(main, sub string) Item {
+	return &extensions{mainPath: main, subPath: sub}
+}
+
+func (e *extensions) ChromeParse(key []byte) error {
+	return e.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read extensions.settings of the Preferences and Secure Preferences,
+// the manifest comes from the settings or else from the copy CopyDB made of it
+func (e *extensions) ChromeParseContext(ctx context.Context, key []byte) error {
+	settings, err := chromiumExtensionSettings(e.tempDir)
+	if err != nil {
+		return err
+	}
+	e.extensions = nil
+	for id, setting := range settings {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		path := e.extensionPath(setting)
+		manifestDir := ""
+		manifest := setting.Get("manifest")
+		if !manifest.Exists() && path != "" && isPathName(id) {
+			manifestDir = filepath.Join(e.tempDir, ChromeExtensionDir, id)
+			manifest = readManifest(manifestDir)
+		}
+		extension := Extension{
+			ID:            id,
+			Name:          localizeManifest(manifestDir, manifest, "name"),
+			Version:       manifest.Get("version").String(),
+			Description:   localizeManifest(manifestDir, manifest, "description"),
+			Type:          chromiumExtensionType(manifest),
+			InstallSource: chromiumExtensionLocations[setting.Get("location").Int()],
+			Enabled:       chromiumExtensionEnabled(setting),
+			UpdateUrl:     manifest.Get("update_url").String(),
+			InstallTime:   filemgmt.WebKitTime(setting.Get("install_time").Int()),
+			Path:          path,
+		}
+		apis, hosts := manifestPermissions(manifest)
+		if !manifest.Exists() {
+			apis = resultStrings(setting.Get("active_permissions.api"))
+			hosts = append(resultStrings(setting.Get("active_permissions.explicit_host")),
+				resultStrings(setting.Get("active_permissions.scriptable_host"))...)
+		}
+		extension.Permissions = strings.Join(apis, extensionListSep)
+		extension.HostPermissions = strings.Join(uniqueStrings(hosts), extensionListSep)
+		e.extensions = append(e.extensions, extension)
+	}
+	return nil
+}
+
+// chromiumExtensionSettings read extensions.settings of the preferences copied to dir,
+// an extension in both files gets the fields of the Secure Preferences over the ones
+// of the Preferences
+func chromiumExtensionSettings(dir string) (map[string]gjson.Result, error) {
+	settings := make(map[string]gjson.Result)
+	for _, name := range []string{ChromePrefsFile, ChromeSecPrefsFile} {
+		prefs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if name == ChromePrefsFile {
+				return nil, err
+			}
+			continue
+		}
+		gjson.GetBytes(prefs, "extensions.settings").ForEach(func(id, value gjson.Result) bool {
+			settings[id.String()] = mergeSettings(settings[id.String()], value)
+			return true
+		})
+	}
+	return settings, nil
+}
+
+// mergeSettings return the fields of a and b in one object, b wins for the fields in both
+func mergeSettings(a, b gjson.Result) gjson.Result {
+	if !a.IsObject() {
+		return b
+	}
+	fields := make(map[string]json.RawMessage)
+	for _, r := range []gjson.Result{a, b} {
+		r.ForEach(func(k, v gjson.Result) bool {
+			fields[k.String()] = json.RawMessage(v.Raw)
+			return true
+		})
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		logger.Debug(err)
+		return b
+	}
+	return gjson.ParseBytes(merged)
+}
+
+// extensionPath return the directory of the extension in the profile, unpacked
+// extensions keep the absolute path they were loaded from
+func (e *extensions) extensionPath(setting gjson.Result) string {
+	path := setting.Get("path").String()
+	if path != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(e.mainPath), ChromeExtensionDir, path)
+	}
+	return path
+}
+
+// chromiumExtensionEnabled read the state of older chromium, recent versions only keep
+// the reasons an extension is disabled
+func chromiumExtensionEnabled(setting gjson.Result) bool {
+	if state := setting.Get("state"); state.Exists() {
+		return state.Int() == chromiumExtensionStateEnabled
+	}
+	reasons := setting.Get("disable_reasons")
+	if reasons.IsArray() {
+		return len(reasons.Array()) == 0
+	}
+	return reasons.Int() == 0
+}
+
+func chromiumExtensionType(manifest gjson.Result) string {
+	switch {
+	case manifest.Get("theme").Exists():
+		return "theme"
+	case manifest.Get("app").Exists():
+		return "app"
+	case !manifest.Exists():
+		return ""
+	}
+	return "extension"
+}
+
+func readManifest(dir string) gjson.Result {
+	manifest, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		logger.Debug(err)
+		return gjson.Result{}
+	}
+	return gjson.ParseBytes(manifest)
+}
+
+// localizeManifest return the manifest field, __MSG_key__ values are looked up in the
+// messages of the default locale
+func localizeManifest(dir string, manifest gjson.Result, field string) string {
+	value := manifest.Get(field).String()
+	if !strings.HasPrefix(value, "__MSG_") || !strings.HasSuffix(value, "__") || dir == "" {
+		return value
+	}
+	key := strings.TrimSuffix(strings.TrimPrefix(value, "__MSG_"), "__")
+	locale := manifest.Get("default_locale").String()
+	messages, err := os.ReadFile(filepath.Join(dir, "_locales", locale, "messages.json"))
+	if err != nil {
+		logger.Debug(err)
+		return value
+	}
+	var message string
+	gjson.ParseBytes(messages).ForEach(func(k, v gjson.Result) bool {
+		// message keys are case insensitive
+		if strings.EqualFold(k.String(), key) {
+			message = v.Get("message").String()
+			return false
+		}
+		return true
+	})
+	if message == "" {
+		return value
+	}
+	return message
+}
+
+// manifestPermissions split the requested permissions in api and host permissions,
+// manifest v2 mixes hosts in permissions and content scripts match hosts too
+func manifestPermissions(manifest gjson.Result) (apis, hosts []string) {
+	for _, field := range []string{"permissions", "optional_permissions"} {
+		for _, p := range resultStrings(manifest.Get(field)) {
+			if isHostPermission(p) {
+				hosts = append(hosts, p)
+			} else {
+				apis = append(apis, p)
+			}
+		}
+	}
+	hosts = append(hosts, resultStrings(manifest.Get("host_permissions"))...)
+	hosts = append(hosts, resultStrings(manifest.Get("optional_host_permissions"))...)
+	for _, script := range manifest.Get("content_scripts").Array() {
+		hosts = append(hosts, resultStrings(script.Get("matches"))...)
+	}
+	return apis, hosts
+}
+
+func isHostPermission(p string) bool {
+	return p == "<all_urls>" || strings.Contains(p, "://")
+}
+
+// resultStrings return the strings of a json array, objects (socket permissions) are skipped
+func resultStrings(r gjson.Result) []string {
+	var list []string
+	for _, v := range r.Array() {
+		if v.Type == gjson.String {
+			list = append(list, v.String())
+		}
+	}
+	return list
+}
+
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var unique []string
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+func (e *extensions) FirefoxParse() error {
+	return e.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read extensions.json, addons.json is the addons.mozilla.org metadata
+// cache and only completes the names
+func (e *extensions) FirefoxParseContext(ctx context.Context) error {
+	s, err := os.ReadFile(filepath.Join(e.tempDir, FirefoxExtFile))
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string)
+	if e.subPath != "" {
+		if addons, err := os.ReadFile(filepath.Join(e.tempDir, FirefoxAddonsFile)); err == nil {
+			for _, v := range gjson.GetBytes(addons, "addons").Array() {
+				names[v.Get("id").String()] = v.Get("name").String()
+			}
+		} else {
+			logger.Debug(err)
+		}
+	}
+	e.extensions = nil
+	for _, v := range gjson.GetBytes(s, "addons").Array() {
+		id := v.Get("id").String()
+		extension := Extension{
+			ID:              id,
+			Name:            v.Get("defaultLocale.name").String(),
+			Version:         v.Get("version").String(),
+			Description:     v.Get("defaultLocale.description").String(),
+			Type:            v.Get("type").String(),
+			InstallSource:   v.Get("location").String(),
+			Enabled:         v.Get("active").Bool(),
+			Permissions:     strings.Join(resultStrings(v.Get("userPermissions.permissions")), extensionListSep),
+			HostPermissions: strings.Join(resultStrings(v.Get("userPermissions.origins")), extensionListSep),
+			UpdateUrl:       v.Get("updateURL").String(),
+			InstallTime:     filemgmt.UnixMilliTime(v.Get("installDate").Int()),
+			Path:            v.Get("path").String(),
+		}
+		if extension.Name == "" {
+			extension.Name = names[id]
+		}
+		e.extensions = append(e.extensions, extension)
+	}
+	return ctx.Err()
+}
+
+// Records return all the parsed extensions
+func (e *extensions) Records() []Extension {
+	return e.extensions
+}
+
+// CopyDB copy the preferences, the Secure Preferences sit next to the Preferences and
+// are copied too when they exist. The manifests missing in the settings are copied to
+// Extensions/<id> with the messages of their default locale.
+func (e *extensions) CopyDB() error {
+	sub := e.subPath
+	chromium := filepath.Base(e.mainPath) == ChromePrefsFile
+	if sub == "" && chromium {
+		secure := filepath.Join(filepath.Dir(e.mainPath), ChromeSecPrefsFile)
+		if _, err := os.Stat(secure); err == nil {
+			sub = secure
+		}
+	}
+	dir, err := copyToTempDir(e.mainPath, sub)
+	if err != nil {
+		return err
+	}
+	e.tempDir = dir
+	if chromium {
+		e.copyManifests()
+	}
+	return nil
+}
+
+// copyManifests copy the manifest.json and the default locale messages.json of every
+// extension without a manifest in its settings, an extension that can't be copied only
+// loses its manifest fields
+func (e *extensions) copyManifests() {
+	settings, err := chromiumExtensionSettings(e.tempDir)
+	if err != nil {
+		logger.Debug(err)
+		return
+	}
+	for id, setting := range settings {
+		path := e.extensionPath(setting)
+		// the id and the locale become paths of the temp dir
+		if path == "" || setting.Get("manifest").Exists() || !isPathName(id) {
+			continue
+		}
+		dst := filepath.Join(e.tempDir, ChromeExtensionDir, id)
+		if err := copyExtensionFile(path, dst, "manifest.json"); err != nil {
+			logger.Debug(err)
+			continue
+		}
+		locale := readManifest(dst).Get("default_locale").String()
+		if !isPathName(locale) {
+			continue
+		}
+		if err := copyExtensionFile(path, dst, filepath.Join("_locales", locale, "messages.json")); err != nil {
+			logger.Debug(err)
+		}
+	}
+}
+
+func isPathName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
+
+func copyExtensionFile(src, dst, name string) error {
+	content, err := os.ReadFile(filepath.Join(src, name))
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filepath.Join(dst, name)), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dst, name), content, 0600)
+}
+
+func (e *extensions) Release() error {
+	return releaseTempDir(e.tempDir)
+}
+
+func (e *extensions) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(e.extensions, func(i, j int) bool {
+		return strings.ToLower(e.extensions[i].Name) < strings.ToLower(e.extensions[j].Name)
+	})
+	switch format {
+	case formatCSV:
+		err := e.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		e.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return e.outPutJsonLines(browser, dir)
+	default:
+		err := e.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (e *extensions) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameExtension, GetFormatName(formatJson))
+	err := WriteToJson(filename, e.extensions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d extensions, filename is %s \n", filemgmt.Prefix, len(e.extensions), filename)
+	return nil
+}
+
+func (e *extensions) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameExtension, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, e.extensions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d extensions, filename is %s \n", filemgmt.Prefix, len(e.extensions), filename)
+	return nil
+}
+
+func (e *extensions) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameExtension, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, e.extensions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d extensions, filename is %s \n", filemgmt.Prefix, len(e.extensions), filename)
+	return nil
+}
+
+func (e *extensions) outPutConsole() {
+	for _, v := range e.extensions {
+		fmt.Printf("%+v\n", v)
+	}
+}
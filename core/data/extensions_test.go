@@ -0,0 +1,79 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChromiumExtensions(t *testing.T) {
+	profile := t.TempDir()
+	const id, other = "abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"
+	writeTestFile(t, filepath.Join(profile, ChromePrefsFile), `{"extensions":{"settings":{
+		"`+id+`":{"path":"`+id+`/1.0_0","location":1,"install_time":"13300000000000000"},
+		"`+other+`":{"location":5,"manifest":{"name":"Built in","version":"2","permissions":["tabs","https://*/*"]}}}}}`)
+	// the secure preferences only hold some fields, the others come from the preferences
+	writeTestFile(t, filepath.Join(profile, ChromeSecPrefsFile), `{"extensions":{"settings":{
+		"`+id+`":{"disable_reasons":[1]}}}}`)
+	writeTestFile(t, filepath.Join(profile, ChromeExtensionDir, id, "1.0_0", "manifest.json"),
+		`{"name":"__MSG_appName__","version":"1.0","default_locale":"en","host_permissions":["<all_urls>"]}`)
+	writeTestFile(t, filepath.Join(profile, ChromeExtensionDir, id, "1.0_0", "_locales", "en", "messages.json"),
+		`{"APPNAME":{"message":"Localized"}}`)
+
+	e := NewExtensions(filepath.Join(profile, ChromePrefsFile), "").(*extensions)
+	if err := e.CopyDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Release()
+	// the profile may change or go away once copied
+	if err := os.RemoveAll(filepath.Join(profile, ChromeExtensionDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ChromeParse(nil); err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]Extension)
+	for _, v := range e.Records() {
+		byID[v.ID] = v
+	}
+	if len(byID) != 2 {
+		t.Fatalf("got %d extensions, want 2", len(byID))
+	}
+	v := byID[id]
+	if v.Name != "Localized" || v.Version != "1.0" || v.InstallSource != "internal" || v.InstallTime.IsZero() ||
+		v.Enabled || v.HostPermissions != "<all_urls>" || v.Path != filepath.Join(profile, ChromeExtensionDir, id, "1.0_0") {
+		t.Errorf("got %+v", v)
+	}
+	if v = byID[other]; v.Name != "Built in" || v.InstallSource != "component" || !v.Enabled || v.Permissions != "tabs" || v.HostPermissions != "https://*/*" {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestFirefoxExtensions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, FirefoxExtFile), `{"addons":[{"id":"a@test","version":"3","type":"extension",
+		"location":"app-profile","active":true,"installDate":1700000000000,"defaultLocale":{"name":""},
+		"userPermissions":{"permissions":["storage"],"origins":["https://a.test/*"]}}]}`)
+	writeTestFile(t, filepath.Join(dir, FirefoxAddonsFile), `{"addons":[{"id":"a@test","name":"From AMO"}]}`)
+	e := &extensions{tempDir: dir, subPath: FirefoxAddonsFile}
+	if err := e.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	if r := e.Records(); len(r) != 1 || r[0].Name != "From AMO" || !r[0].Enabled || r[0].Permissions != "storage" ||
+		r[0].HostPermissions != "https://a.test/*" || r[0].InstallTime.UnixMilli() != 1700000000000 {
+		t.Errorf("got %+v", r)
+	}
+}
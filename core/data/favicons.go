@@ -0,0 +1,324 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// ImageWriter is implemented by the items that hold images, WriteImages save them in
+// a <browser>_<item> directory of dir and records the file name of every image
+type ImageWriter interface {
+	WriteImages(browser, dir string) error
+}
+
+// chromiumIconTypes is favicon_base::IconType
+var chromiumIconTypes = map[int64]string{
+	1: "favicon",
+	2: "touch-icon",
+	4: "touch-precomposed-icon",
+	8: "web-manifest-icon",
+}
+
+// Favicon maps a page to the icon the browser shows for it, Width and Height are the
+// largest bitmap saved of the icon and File its name once written by WriteImages
+type Favicon struct {
+	PageUrl     string
+	IconUrl     string
+	Type        string
+	Width       int
+	Height      int
+	LastUpdated time.Time
+	File        string
+	image       []byte
+}
+
+type favicons struct {
+	mainPath string
+	tempDir  string
+	favicons []Favicon
+}
+
+func NewFavicons(main, sub string) Item {
+	return &favicons{mainPath: main}
+}
+
+func (f *favicons) ChromeParse(key []byte) error {
+	return f.ChromeParseContext(context.Background(), key)
+}
+
+type faviconBitmap struct {
+	width, height int
+	updated       int64
+	image         []byte
+}
+
+func (f *favicons) ChromeParseContext(ctx context.Context, key []byte) error {
+	iconDB, err := sql.Open("sqlite3", filepath.Join(f.tempDir, ChromeFaviconFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := iconDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	bitmaps, err := chromiumFaviconBitmaps(ctx, iconDB)
+	if err != nil {
+		return err
+	}
+	rows, err := iconDB.QueryContext(ctx, QueryChromiumIconMapping)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	f.favicons = nil
+	for rows.Next() {
+		var (
+			pageUrl, iconUrl string
+			iconID, iconType int64
+		)
+		if err = rows.Scan(&pageUrl, &iconID, &iconUrl, &iconType); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		bitmap := bitmaps[iconID]
+		f.favicons = append(f.favicons, Favicon{
+			PageUrl:     pageUrl,
+			IconUrl:     iconUrl,
+			Type:        chromiumIconTypes[iconType],
+			Width:       bitmap.width,
+			Height:      bitmap.height,
+			LastUpdated: filemgmt.WebKitTime(bitmap.updated),
+			image:       bitmap.image,
+		})
+	}
+	return rows.Err()
+}
+
+// chromiumFaviconBitmaps return the largest bitmap of every icon id
+func chromiumFaviconBitmaps(ctx context.Context, iconDB *sql.DB) (map[int64]faviconBitmap, error) {
+	rows, err := iconDB.QueryContext(ctx, QueryChromiumIconBitmaps)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	bitmaps := make(map[int64]faviconBitmap)
+	for rows.Next() {
+		var (
+			iconID int64
+			bitmap faviconBitmap
+		)
+		if err = rows.Scan(&iconID, &bitmap.width, &bitmap.height, &bitmap.updated, &bitmap.image); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		if bitmap.width >= bitmaps[iconID].width {
+			bitmaps[iconID] = bitmap
+		}
+	}
+	return bitmaps, rows.Err()
+}
+
+func (f *favicons) FirefoxParse() error {
+	return f.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read favicons.sqlite, an icon is saved once per width and the
+// largest is kept. Root icons like /favicon.ico are not linked to pages, they are used
+// for every page of their site, so the site is recorded as their PageUrl.
+func (f *favicons) FirefoxParseContext(ctx context.Context) error {
+	iconDB, err := sql.Open("sqlite3", filepath.Join(f.tempDir, FirefoxFaviconFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := iconDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := iconDB.QueryContext(ctx, QueryFirefoxIcons)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	f.favicons = nil
+	largest := make(map[[2]string]int)
+	for rows.Next() {
+		var (
+			pageUrl, iconUrl string
+			width            int
+			image            []byte
+		)
+		if err = rows.Scan(&pageUrl, &iconUrl, &width, &image); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		if pageUrl == "" {
+			u, err := url.Parse(iconUrl)
+			if err != nil || u.Host == "" {
+				continue
+			}
+			pageUrl = u.Scheme + "://" + u.Host + "/"
+		}
+		icon := Favicon{PageUrl: pageUrl, IconUrl: iconUrl, Type: "favicon", Width: width, Height: width, image: image}
+		key := [2]string{pageUrl, iconUrl}
+		if i, ok := largest[key]; ok {
+			if width > f.favicons[i].Width {
+				f.favicons[i] = icon
+			}
+			continue
+		}
+		largest[key] = len(f.favicons)
+		f.favicons = append(f.favicons, icon)
+	}
+	return rows.Err()
+}
+
+// WriteImages save the icon bitmaps, icons shared by many pages are written once
+func (f *favicons) WriteImages(browser, dir string) error {
+	imageDir, err := makeItemDir(browser, ItemNameFavicon, dir)
+	if err != nil {
+		return err
+	}
+	var count int
+	for i, v := range f.favicons {
+		if len(v.image) == 0 {
+			continue
+		}
+		if f.favicons[i].File, err = writeImage(imageDir, v.image); err != nil {
+			return err
+		}
+		count++
+	}
+	fmt.Printf("%s Get %d favicon images, directory is %s \n", filemgmt.Prefix, count, imageDir)
+	return nil
+}
+
+// writeImage name the image after its content, so writing it again is a no-op
+func writeImage(dir string, image []byte) (string, error) {
+	sum := sha1.Sum(image)
+	name := hex.EncodeToString(sum[:]) + imageExtension(image)
+	filename := filepath.Join(dir, name)
+	if _, err := os.Stat(filename); err == nil {
+		return name, nil
+	}
+	return name, os.WriteFile(filename, image, 0600)
+}
+
+// imageExtension sniff the format of an image
+func imageExtension(image []byte) string {
+	switch {
+	case bytes.HasPrefix(image, []byte("\x89PNG")):
+		return ".png"
+	case bytes.HasPrefix(image, []byte{0xFF, 0xD8, 0xFF}):
+		return ".jpg"
+	case bytes.HasPrefix(image, []byte("GIF8")):
+		return ".gif"
+	case len(image) > 12 && bytes.HasPrefix(image, []byte("RIFF")) && string(image[8:12]) == "WEBP":
+		return ".webp"
+	case bytes.HasPrefix(image, []byte{0, 0, 1, 0}):
+		return ".ico"
+	case bytes.Contains(image, []byte("<svg")):
+		return ".svg"
+	default:
+		return ".bin"
+	}
+}
+
+// Records return all the parsed favicons
+func (f *favicons) Records() []Favicon {
+	return f.favicons
+}
+
+func (f *favicons) CopyDB() error {
+	dir, err := copyToTempDir(f.mainPath)
+	if err != nil {
+		return err
+	}
+	f.tempDir = dir
+	return nil
+}
+
+func (f *favicons) Release() error {
+	return releaseTempDir(f.tempDir)
+}
+
+func (f *favicons) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(f.favicons, func(i, j int) bool {
+		return f.favicons[i].PageUrl < f.favicons[j].PageUrl
+	})
+	switch format {
+	case formatCSV:
+		err := f.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		f.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return f.outPutJsonLines(browser, dir)
+	default:
+		err := f.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (f *favicons) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameFavicon, GetFormatName(formatJson))
+	err := WriteToJson(filename, f.favicons)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d favicons, filename is %s \n", filemgmt.Prefix, len(f.favicons), filename)
+	return nil
+}
+
+func (f *favicons) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameFavicon, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, f.favicons); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d favicons, filename is %s \n", filemgmt.Prefix, len(f.favicons), filename)
+	return nil
+}
+
+func (f *favicons) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameFavicon, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, f.favicons); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d favicons, filename is %s \n", filemgmt.Prefix, len(f.favicons), filename)
+	return nil
+}
+
+func (f *favicons) outPutConsole() {
+	for _, v := range f.favicons {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -0,0 +1,88 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChromiumFavicons(t *testing.T) {
+	dir := t.TempDir()
+	createDB(t, filepath.Join(dir, ChromeFaviconFile), `CREATE TABLE favicons (id INTEGER PRIMARY KEY, url TEXT, icon_type INTEGER);
+		CREATE TABLE icon_mapping (id INTEGER PRIMARY KEY, page_url TEXT, icon_id INTEGER);
+		CREATE TABLE favicon_bitmaps (id INTEGER PRIMARY KEY, icon_id INTEGER, last_updated INTEGER, image_data BLOB, width INTEGER, height INTEGER);
+		INSERT INTO favicons VALUES (1, 'https://a.test/favicon.ico', 1), (2, 'https://b.test/touch.png', 2);
+		INSERT INTO icon_mapping VALUES (1, 'https://a.test/', 1), (2, 'https://a.test/page', 1), (3, 'https://b.test/', 2);
+		INSERT INTO favicon_bitmaps VALUES (1, 1, 13300000000000000, x'01', 16, 16);
+		INSERT INTO favicon_bitmaps VALUES (2, 1, 13300000000000000, x'89504e470d0a1a0a', 32, 32);
+		INSERT INTO favicon_bitmaps VALUES (3, 1, 13300000000000000, x'02', 24, 24);`)
+	f := &favicons{tempDir: dir}
+	if err := f.ChromeParse(nil); err != nil {
+		t.Fatal(err)
+	}
+	r := f.Records()
+	if len(r) != 3 {
+		t.Fatalf("got %d favicons, want 3", len(r))
+	}
+	for _, v := range r[:2] {
+		if v.IconUrl != "https://a.test/favicon.ico" || v.Width != 32 || v.Height != 32 || len(v.image) != 8 || v.LastUpdated.IsZero() {
+			t.Errorf("got %+v, want the 32px bitmap", v)
+		}
+	}
+	// an icon without bitmaps is still mapped
+	if v := r[2]; v.Type != "touch-icon" || v.Width != 0 || v.image != nil {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestFirefoxFavicons(t *testing.T) {
+	dir := t.TempDir()
+	createDB(t, filepath.Join(dir, FirefoxFaviconFile), `CREATE TABLE moz_icons (id INTEGER PRIMARY KEY, icon_url TEXT, width INTEGER, root INTEGER, data BLOB);
+		CREATE TABLE moz_pages_w_icons (id INTEGER PRIMARY KEY, page_url TEXT);
+		CREATE TABLE moz_icons_to_pages (page_id INTEGER, icon_id INTEGER);
+		INSERT INTO moz_icons VALUES (1, 'https://a.test/icon.png', 16, 0, x'01'), (2, 'https://a.test/icon.png', 64, 0, x'02');
+		INSERT INTO moz_icons VALUES (3, 'https://b.test:8443/favicon.ico', 32, 1, x'03'), (4, 'favicon.ico', 16, 1, x'04');
+		INSERT INTO moz_pages_w_icons VALUES (1, 'https://a.test/page');
+		INSERT INTO moz_icons_to_pages VALUES (1, 1), (1, 2);`)
+	f := &favicons{tempDir: dir}
+	if err := f.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	r := f.Records()
+	if len(r) != 2 {
+		t.Fatalf("got %+v, want the page icon and the root icon with a host", r)
+	}
+	if v := r[0]; v.PageUrl != "https://a.test/page" || v.Width != 64 || string(v.image) != "\x02" {
+		t.Errorf("got %+v, want the 64px icon", v)
+	}
+	if v := r[1]; v.PageUrl != "https://b.test:8443/" || v.IconUrl != "https://b.test:8443/favicon.ico" {
+		t.Errorf("got %+v, want the site of the root icon", v)
+	}
+}
+
+func TestChromiumTopSites(t *testing.T) {
+	for _, table := range []string{"top_sites", "thumbnails"} {
+		dir := t.TempDir()
+		schema := `CREATE TABLE ` + table + ` (url TEXT, url_rank INTEGER, title TEXT, redirects TEXT);
+			INSERT INTO ` + table + ` VALUES ('https://b.test/', 1, 'B', 'https://b.test/ https://www.b.test/');
+			INSERT INTO ` + table + ` VALUES ('https://a.test/', 0, 'A', '');`
+		if table == "thumbnails" {
+			schema += `ALTER TABLE thumbnails ADD COLUMN thumbnail BLOB;
+				UPDATE thumbnails SET thumbnail = x'ffd8ffe0' WHERE url_rank = 0;`
+		}
+		createDB(t, filepath.Join(dir, ChromeTopSitesFile), schema)
+		ts := &topSites{tempDir: dir}
+		if err := ts.ChromeParse(nil); err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		r := ts.Records()
+		if len(r) != 2 || r[0].Url != "https://a.test/" || r[1].Redirects != "https://b.test/ -> https://www.b.test/" {
+			t.Fatalf("%s: got %+v", table, r)
+		}
+		if hasThumbnail := len(r[0].image) > 0; hasThumbnail != (table == "thumbnails") {
+			t.Errorf("%s: got thumbnail %x", table, r[0].image)
+		}
+	}
+}
@@ -4,17 +4,18 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/teocci/go-chrome-cookies/filemgmt"
 	"github.com/teocci/go-chrome-cookies/logger"
-	"os"
 	"path/filepath"
 	"sort"
 	"time"
 )
 
-type history struct {
+// HistoryEntry is a single visited url with its aggregated visits
+type HistoryEntry struct {
 	Title         string
 	Url           string
 	VisitCount    int
@@ -23,7 +24,8 @@ type history struct {
 
 type historyData struct {
 	mainPath string
-	history  []history
+	tempDir  string
+	history  []HistoryEntry
 }
 
 func NewHistoryData(main, sub string) Item {
@@ -31,7 +33,21 @@ func NewHistoryData(main, sub string) Item {
 }
 
 func (h *historyData) ChromeParse(key []byte) error {
-	historyDB, err := sql.Open("sqlite3", ChromeHistoryFile)
+	return h.ChromeParseContext(context.Background(), key)
+}
+
+func (h *historyData) ChromeParseContext(ctx context.Context, key []byte) error {
+	h.history = nil
+	return h.ChromeStream(ctx, func(entry HistoryEntry) error {
+		h.history = append(h.history, entry)
+		return nil
+	})
+}
+
+// ChromeStream call fn for every row of the chromium urls table while it is scanned,
+// it stops at the first error returned by fn or when ctx is done
+func (h *historyData) ChromeStream(ctx context.Context, fn func(HistoryEntry) error) error {
+	historyDB, err := sql.Open("sqlite3", filepath.Join(h.tempDir, ChromeHistoryFile))
 	if err != nil {
 		return err
 	}
@@ -40,7 +56,7 @@ func (h *historyData) ChromeParse(key []byte) error {
 			logger.Error(err)
 		}
 	}()
-	rows, err := historyDB.Query(QueryChromiumHistory)
+	rows, err := historyDB.QueryContext(ctx, QueryChromiumHistory)
 	if err != nil {
 		return err
 	}
@@ -55,34 +71,48 @@ func (h *historyData) ChromeParse(key []byte) error {
 			visitCount    int
 			lastVisitTime int64
 		)
-		err := rows.Scan(&url, &title, &visitCount, &lastVisitTime)
-		hData := history{
+		if err := rows.Scan(&url, &title, &visitCount, &lastVisitTime); err != nil {
+			logger.Error(err)
+			continue
+		}
+		hData := HistoryEntry{
 			Url:           url,
 			Title:         title,
 			VisitCount:    visitCount,
-			LastVisitTime: filemgmt.TimeEpochFormat(lastVisitTime),
+			LastVisitTime: filemgmt.WebKitTime(lastVisitTime),
 		}
-		if err != nil {
-			logger.Error(err)
+		if err := fn(hData); err != nil {
+			return err
 		}
-		h.history = append(h.history, hData)
 	}
-	return nil
+	return rows.Err()
 }
 
 func (h *historyData) FirefoxParse() error {
+	return h.FirefoxParseContext(context.Background())
+}
+
+func (h *historyData) FirefoxParseContext(ctx context.Context) error {
+	h.history = nil
+	return h.FirefoxStream(ctx, func(entry HistoryEntry) error {
+		h.history = append(h.history, entry)
+		return nil
+	})
+}
+
+// FirefoxStream call fn for every row of the firefox moz_places table while it is scanned,
+// it stops at the first error returned by fn or when ctx is done
+func (h *historyData) FirefoxStream(ctx context.Context, fn func(HistoryEntry) error) error {
 	var (
 		err         error
 		keyDB       *sql.DB
 		historyRows *sql.Rows
-		tempMap     map[int64]string
 	)
-	tempMap = make(map[int64]string)
-	keyDB, err = sql.Open("sqlite3", FirefoxDataFile)
+	keyDB, err = sql.Open("sqlite3", filepath.Join(h.tempDir, FirefoxDataFile))
 	if err != nil {
 		return err
 	}
-	_, err = keyDB.Exec(CloseJournalMode)
+	_, err = keyDB.ExecContext(ctx, CloseJournalMode)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -91,7 +121,7 @@ func (h *historyData) FirefoxParse() error {
 			logger.Error(err)
 		}
 	}()
-	historyRows, err = keyDB.Query(QueryFirefoxHistory)
+	historyRows, err = keyDB.QueryContext(ctx, QueryFirefoxHistory)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -110,24 +140,37 @@ func (h *historyData) FirefoxParse() error {
 		err = historyRows.Scan(&id, &url, &visitDate, &title, &visitCount)
 		if err != nil {
 			logger.Warn(err)
+			continue
 		}
-		h.history = append(h.history, history{
+		err = fn(HistoryEntry{
 			Title:         title,
 			Url:           url,
 			VisitCount:    visitCount,
-			LastVisitTime: filemgmt.TimeStampFormat(visitDate / 1000000),
+			LastVisitTime: filemgmt.PRTime(visitDate),
 		})
-		tempMap[id] = url
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return historyRows.Err()
+}
+
+// Records return all the parsed history entries
+func (h *historyData) Records() []HistoryEntry {
+	return h.history
 }
 
 func (h *historyData) CopyDB() error {
-	return CopyToLocalPath(h.mainPath, filepath.Base(h.mainPath))
+	dir, err := copyToTempDir(h.mainPath)
+	if err != nil {
+		return err
+	}
+	h.tempDir = dir
+	return nil
 }
 
 func (h *historyData) Release() error {
-	return os.Remove(filepath.Base(h.mainPath))
+	return releaseTempDir(h.tempDir)
 }
 
 func (h *historyData) OutPut(format OutputFormat, browser, dir string) error {
@@ -141,6 +184,8 @@ func (h *historyData) OutPut(format OutputFormat, browser, dir string) error {
 	case formatConsole:
 		h.outPutConsole()
 		return nil
+	case formatJsonLines:
+		return h.outPutJsonLines(browser, dir)
 	default:
 		err := h.outPutJson(browser, dir)
 		return err
@@ -169,6 +214,16 @@ func (h *historyData) outPutCsv(browser, dir string) error {
 	return nil
 }
 
+func (h *historyData) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameHistory, GetFormatName(formatJsonLines))
+	records := h.history
+	if err := WriteToJsonLines(filename, records); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d history, filename is %s \n", filemgmt.Prefix, len(records), filename)
+	return nil
+}
+
 func (h *historyData) outPutConsole() {
 	for _, v := range h.history {
 		fmt.Printf("%+v\n", v)
@@ -0,0 +1,606 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+const (
+	chromiumIdbSuffix = ".indexeddb.leveldb"
+
+	// chromium IndexedDB leveldb keys start with a prefix of the database, object store and
+	// index ids, the global metadata has them all 0 and the records use the index 1
+	chromiumIdbDataIndex     = 1
+	chromiumIdbDatabaseName  = 201
+	chromiumIdbStoreMetadata = 50
+	chromiumIdbStoreName     = 0
+
+	// chromium IndexedDB key types
+	chromiumIdbKeyNull   = 0
+	chromiumIdbKeyString = 1
+	chromiumIdbKeyDate   = 2
+	chromiumIdbKeyNumber = 3
+	chromiumIdbKeyArray  = 4
+	chromiumIdbKeyMin    = 5
+	chromiumIdbKeyBinary = 6
+
+	// blink marks the IndexedDB values it wrapped with a pseudo version, the value is
+	// then stored in a blob file or snappy compressed
+	blinkWrappedVersion    = 0x11
+	blinkWrappedBlob       = 0x01
+	blinkWrappedCompressed = 0x02
+
+	// firefox copies the idb/*.sqlite of each origin to idb/<origin dir> of the temp dir
+	firefoxIdbDir = "idb"
+
+	// firefox IndexedDB key types, arrays add their type to the type of their first
+	// element and up to 3 levels are collapsed in a byte
+	firefoxKeyTerminator       = 0x00
+	firefoxKeyFloat            = 0x10
+	firefoxKeyDate             = 0x20
+	firefoxKeyString           = 0x30
+	firefoxKeyBinary           = 0x40
+	firefoxKeyArray            = 0x50
+	firefoxKeyMaxArrayCollapse = 3
+)
+
+var errIdbKeyCorrupt = errors.New("indexeddb: corrupt key")
+
+// IndexedDBRecord is a record of an object store, Key and Value are json. Value is empty
+// when the serialization format is not understood, like values kept in blob files.
+type IndexedDBRecord struct {
+	Origin      string
+	Database    string
+	ObjectStore string
+	Key         string
+	Value       string
+}
+
+type indexedDB struct {
+	mainPath string
+	tempDir  string
+	records  []IndexedDBRecord
+}
+
+func NewIndexedDB(main, sub string) Item {
+	return &indexedDB{mainPath: main}
+}
+
+func (i *indexedDB) ChromeParse(key []byte) error {
+	return i.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the <origin>.indexeddb.leveldb directories of the profile
+func (i *indexedDB) ChromeParseContext(ctx context.Context, key []byte) error {
+	dirs, err := filepath.Glob(filepath.Join(i.tempDir, "*"+chromiumIdbSuffix))
+	if err != nil {
+		return err
+	}
+	i.records = nil
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		records, err := readLevelDB(dir)
+		if err != nil {
+			logger.Debug(err)
+			continue
+		}
+		origin := chromiumOriginIdentifier(strings.TrimSuffix(filepath.Base(dir), chromiumIdbSuffix))
+		i.records = append(i.records, chromiumIndexedDB(origin, records)...)
+	}
+	return nil
+}
+
+func chromiumIndexedDB(origin string, records []levelDBRecord) []IndexedDBRecord {
+	databases := make(map[int64]string)
+	stores := make(map[[2]int64]string)
+	for _, r := range records {
+		db, store, index, rest, ok := chromiumIdbPrefix(r.Key)
+		if !ok || store != 0 || index != 0 || len(rest) == 0 {
+			continue
+		}
+		switch {
+		case db == 0 && rest[0] == chromiumIdbDatabaseName:
+			// the origin and the name of the database, the value is the database id
+			_, p, err := chromiumIdbString(rest[1:])
+			if err != nil {
+				continue
+			}
+			name, _, err := chromiumIdbString(p)
+			if err != nil {
+				continue
+			}
+			databases[littleEndianInt(r.Value)] = name
+		case db != 0 && rest[0] == chromiumIdbStoreMetadata:
+			id, n := binary.Uvarint(rest[1:])
+			if n <= 0 || len(rest) <= 1+n || rest[1+n] != chromiumIdbStoreName {
+				continue
+			}
+			stores[[2]int64{db, int64(id)}] = decodeUTF16BE(r.Value)
+		}
+	}
+	var entries []IndexedDBRecord
+	for _, r := range records {
+		db, store, index, rest, ok := chromiumIdbPrefix(r.Key)
+		if !ok || db == 0 || store == 0 || index != chromiumIdbDataIndex {
+			continue
+		}
+		entry := IndexedDBRecord{
+			Origin:      origin,
+			Database:    databases[db],
+			ObjectStore: stores[[2]int64{db, store}],
+		}
+		if k, _, err := decodeChromiumIdbKey(rest); err == nil {
+			entry.Key = toJson(k)
+		} else {
+			logger.Debug(err)
+		}
+		// the value starts with the record version
+		if _, n := binary.Uvarint(r.Value); n > 0 {
+			if v, err := chromiumIdbValue(r.Value[n:]); err == nil {
+				entry.Value = toJson(v)
+			} else {
+				logger.Debugf("%s %s: %s", entry.Database, entry.ObjectStore, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// chromiumIdbPrefix split the database, object store and index ids off a key, their
+// sizes are packed in the first byte
+func chromiumIdbPrefix(key []byte) (db, store, index int64, rest []byte, ok bool) {
+	if len(key) == 0 {
+		return 0, 0, 0, nil, false
+	}
+	sizes := [3]int{int(key[0]>>5) + 1, int(key[0]>>2&0x07) + 1, int(key[0]&0x03) + 1}
+	var ids [3]int64
+	p := key[1:]
+	for k, size := range sizes {
+		if len(p) < size {
+			return 0, 0, 0, nil, false
+		}
+		ids[k] = littleEndianInt(p[:size])
+		p = p[size:]
+	}
+	return ids[0], ids[1], ids[2], p, true
+}
+
+func littleEndianInt(b []byte) int64 {
+	var v int64
+	for k := len(b) - 1; k >= 0; k-- {
+		v = v<<8 | int64(b[k])
+	}
+	return v
+}
+
+// chromiumIdbString read a varint length prefixed big endian utf-16 string
+func chromiumIdbString(p []byte) (string, []byte, error) {
+	length, n := binary.Uvarint(p)
+	if n <= 0 || length > uint64(len(p)-n)/2 {
+		return "", nil, errIdbKeyCorrupt
+	}
+	end := n + 2*int(length)
+	return decodeUTF16BE(p[n:end]), p[end:], nil
+}
+
+func decodeUTF16BE(b []byte) string {
+	swapped := make([]byte, len(b)&^1)
+	for k := 0; k+1 < len(b); k += 2 {
+		swapped[k], swapped[k+1] = b[k+1], b[k]
+	}
+	return decodeUTF16(swapped)
+}
+
+// decodeChromiumIdbKey decode an IndexedDB key and return the rest
+func decodeChromiumIdbKey(p []byte) (interface{}, []byte, error) {
+	if len(p) == 0 {
+		return nil, nil, errIdbKeyCorrupt
+	}
+	kind, p := p[0], p[1:]
+	switch kind {
+	case chromiumIdbKeyNull, chromiumIdbKeyMin:
+		return nil, p, nil
+	case chromiumIdbKeyString:
+		return chromiumIdbString(p)
+	case chromiumIdbKeyDate, chromiumIdbKeyNumber:
+		if len(p) < 8 {
+			return nil, nil, errIdbKeyCorrupt
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(p))
+		if kind == chromiumIdbKeyDate {
+			return filemgmt.UnixMilliTime(int64(v)), p[8:], nil
+		}
+		return jsNumber(v), p[8:], nil
+	case chromiumIdbKeyArray:
+		length, n := binary.Uvarint(p)
+		if n <= 0 || length > uint64(len(p)) {
+			return nil, nil, errIdbKeyCorrupt
+		}
+		p = p[n:]
+		array := make([]interface{}, 0, length)
+		for k := uint64(0); k < length; k++ {
+			v, rest, err := decodeChromiumIdbKey(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			array = append(array, v)
+			p = rest
+		}
+		return array, p, nil
+	case chromiumIdbKeyBinary:
+		length, n := binary.Uvarint(p)
+		if n <= 0 || length > uint64(len(p)-n) {
+			return nil, nil, errIdbKeyCorrupt
+		}
+		end := n + int(length)
+		return p[n:end], p[end:], nil
+	}
+	return nil, nil, errIdbKeyCorrupt
+}
+
+// chromiumIdbValue unwrap a blink IndexedDB value and decode it
+func chromiumIdbValue(b []byte) (interface{}, error) {
+	if len(b) >= 3 && b[0] == v8Version && b[1] == blinkWrappedVersion {
+		switch b[2] {
+		case blinkWrappedBlob:
+			return nil, errors.New("value is stored in a blob file")
+		case blinkWrappedCompressed:
+			unwrapped, err := decodeSnappy(b[3:])
+			if err != nil {
+				return nil, err
+			}
+			b = unwrapped
+		}
+	}
+	return decodeV8Value(b)
+}
+
+// chromiumOriginIdentifier turn a storage identifier like https_example.com_0 into an
+// origin, the port 0 is the default one
+func chromiumOriginIdentifier(id string) string {
+	scheme, rest, ok := strings.Cut(id, "_")
+	if !ok {
+		return id
+	}
+	host, port := rest, ""
+	if k := strings.LastIndex(rest, "_"); k >= 0 {
+		host, port = rest[:k], rest[k+1:]
+	}
+	origin := scheme + "://" + host
+	if port != "" && port != "0" {
+		origin += ":" + port
+	}
+	return origin
+}
+
+func toJson(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		logger.Debug(err)
+		return ""
+	}
+	return string(b)
+}
+
+func (i *indexedDB) FirefoxParse() error {
+	return i.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the storage/default/<origin>/idb/*.sqlite databases, the
+// values are snappy compressed structured clones
+func (i *indexedDB) FirefoxParseContext(ctx context.Context) error {
+	files, err := filepath.Glob(filepath.Join(i.tempDir, firefoxIdbDir, "*", "*.sqlite"))
+	if err != nil {
+		return err
+	}
+	i.records = nil
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		records, err := firefoxIndexedDB(ctx, f)
+		if err != nil {
+			logger.Debugf("%s: %s", f, err)
+			continue
+		}
+		i.records = append(i.records, records...)
+	}
+	return nil
+}
+
+func firefoxIndexedDB(ctx context.Context, path string) ([]IndexedDBRecord, error) {
+	idbDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := idbDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var name, origin string
+	if err := idbDB.QueryRowContext(ctx, QueryFirefoxIdbDatabase).Scan(&name, &origin); err != nil {
+		return nil, err
+	}
+	if origin == "" {
+		origin = firefoxOriginDir(filepath.Base(filepath.Dir(path)))
+	}
+	stores := make(map[int64]string)
+	rows, err := idbDB.QueryContext(ctx, QueryFirefoxIdbStores)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id    int64
+			store string
+		)
+		if err := rows.Scan(&id, &store); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		stores[id] = store
+	}
+	if err := rows.Close(); err != nil {
+		logger.Debug(err)
+	}
+	rows, err = idbDB.QueryContext(ctx, QueryFirefoxIdbData)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var records []IndexedDBRecord
+	for rows.Next() {
+		var (
+			store     int64
+			key, data []byte
+		)
+		if err := rows.Scan(&store, &key, &data); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		record := IndexedDBRecord{Origin: origin, Database: name, ObjectStore: stores[store]}
+		if k, err := decodeFirefoxKey(key); err == nil {
+			record.Key = toJson(k)
+		} else {
+			logger.Debug(err)
+		}
+		if v, err := firefoxIdbValue(data); err == nil {
+			record.Value = toJson(v)
+		} else {
+			logger.Debugf("%s %s: %s", name, record.ObjectStore, err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func firefoxIdbValue(data []byte) (interface{}, error) {
+	clone, err := decodeSnappy(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStructuredClone(clone)
+}
+
+// decodeFirefoxKey decode an IndexedDB key, the encoding keeps the byte order of the keys
+func decodeFirefoxKey(b []byte) (interface{}, error) {
+	v, _, err := decodeFirefoxKeyAt(b, 0, 0)
+	return v, err
+}
+
+func decodeFirefoxKeyAt(b []byte, pos, typeOffset int) (interface{}, int, error) {
+	if pos >= len(b) {
+		return nil, pos, errIdbKeyCorrupt
+	}
+	switch kind := int(b[pos]) - typeOffset; {
+	case kind >= firefoxKeyArray:
+		typeOffset += firefoxKeyArray
+		if typeOffset == firefoxKeyArray*firefoxKeyMaxArrayCollapse {
+			pos++
+			typeOffset = 0
+		}
+		array := []interface{}{}
+		for pos < len(b) && int(b[pos])-typeOffset != firefoxKeyTerminator {
+			v, next, err := decodeFirefoxKeyAt(b, pos, typeOffset)
+			if err != nil {
+				return nil, pos, err
+			}
+			array = append(array, v)
+			pos = next
+			typeOffset = 0
+		}
+		return array, pos + 1, nil
+	case kind == firefoxKeyFloat, kind == firefoxKeyDate:
+		// big endian with the sign bit flipped, trailing zero bytes are trimmed
+		var number [8]byte
+		copy(number[:], b[pos+1:])
+		bits := binary.BigEndian.Uint64(number[:])
+		if bits&(1<<63) != 0 {
+			bits &^= 1 << 63
+		} else {
+			bits = -bits
+		}
+		v := math.Float64frombits(bits)
+		if kind == firefoxKeyDate {
+			return filemgmt.UnixMilliTime(int64(v)), pos + 9, nil
+		}
+		return jsNumber(v), pos + 9, nil
+	case kind == firefoxKeyString:
+		units, next := decodeFirefoxKeyUnits(b, pos+1)
+		return string(utf16.Decode(units)), next, nil
+	case kind == firefoxKeyBinary:
+		units, next := decodeFirefoxKeyUnits(b, pos+1)
+		value := make([]byte, len(units))
+		for k, u := range units {
+			value[k] = byte(u)
+		}
+		return value, next, nil
+	}
+	return nil, pos, fmt.Errorf("indexeddb: unknown key type 0x%02x", b[pos])
+}
+
+// decodeFirefoxKeyUnits read the code units of a string or binary key up to its
+// terminator, units are stored in 1, 2 or 3 bytes
+func decodeFirefoxKeyUnits(b []byte, pos int) ([]uint16, int) {
+	var units []uint16
+	for pos < len(b) && b[pos] != firefoxKeyTerminator {
+		c := uint32(b[pos])
+		pos++
+		switch {
+		case c&0x80 == 0:
+			units = append(units, uint16(c-1))
+		case c&0x40 == 0:
+			c = (c & 0x3F) << 8
+			if pos < len(b) {
+				c |= uint32(b[pos])
+				pos++
+			}
+			units = append(units, uint16(c+0x7F))
+		default:
+			c <<= 10
+			if pos < len(b) {
+				c |= uint32(b[pos]) << 2
+				pos++
+			}
+			if pos < len(b) {
+				c |= uint32(b[pos]) >> 6
+				pos++
+			}
+			units = append(units, uint16(c))
+		}
+	}
+	return units, pos + 1
+}
+
+// Records return all the parsed IndexedDB records
+func (i *indexedDB) Records() []IndexedDBRecord {
+	return i.records
+}
+
+// CopyDB copy the leveldb directories of the chromium IndexedDB, or the idb/*.sqlite of
+// every origin of the firefox storage/default
+func (i *indexedDB) CopyDB() error {
+	if filepath.Base(i.mainPath) == filepath.Base(FirefoxStorageDir) {
+		return i.copyFirefox()
+	}
+	dirs, err := filepath.Glob(filepath.Join(i.mainPath, "*"+chromiumIdbSuffix))
+	if err != nil {
+		return err
+	}
+	dir, err := copyDirsToTempDir(i.mainPath, dirs...)
+	if err != nil {
+		return err
+	}
+	i.tempDir = dir
+	return nil
+}
+
+func (i *indexedDB) copyFirefox() error {
+	files, err := filepath.Glob(filepath.Join(i.mainPath, "*", firefoxIdbDir, "*.sqlite"))
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "go-cc-")
+	if err != nil {
+		return err
+	}
+	i.tempDir = dir
+	for _, f := range files {
+		dst := filepath.Join(dir, firefoxIdbDir, filepath.Base(filepath.Dir(filepath.Dir(f))))
+		if err := os.MkdirAll(dst, 0700); err != nil {
+			return err
+		}
+		if err := copyWithWal(f, filepath.Join(dst, filepath.Base(f))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *indexedDB) Release() error {
+	return releaseTempDir(i.tempDir)
+}
+
+func (i *indexedDB) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(i.records, func(a, b int) bool {
+		ra, rb := i.records[a], i.records[b]
+		if ra.Origin != rb.Origin {
+			return ra.Origin < rb.Origin
+		}
+		if ra.Database != rb.Database {
+			return ra.Database < rb.Database
+		}
+		return ra.ObjectStore < rb.ObjectStore
+	})
+	switch format {
+	case formatCSV:
+		err := i.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		i.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return i.outPutJsonLines(browser, dir)
+	default:
+		err := i.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (i *indexedDB) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameIndexedDB, GetFormatName(formatJson))
+	err := WriteToJson(filename, i.records)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d indexeddb records, filename is %s \n", filemgmt.Prefix, len(i.records), filename)
+	return nil
+}
+
+func (i *indexedDB) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameIndexedDB, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, i.records); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d indexeddb records, filename is %s \n", filemgmt.Prefix, len(i.records), filename)
+	return nil
+}
+
+func (i *indexedDB) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameIndexedDB, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, i.records); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d indexeddb records, filename is %s \n", filemgmt.Prefix, len(i.records), filename)
+	return nil
+}
+
+func (i *indexedDB) outPutConsole() {
+	for _, v := range i.records {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -0,0 +1,137 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// v8Object is {"a": 1, "s": "x", "arr": [true, null]} as written by v8
+var v8Object = []byte{
+	0xFF, 0x0F, 'o',
+	'"', 1, 'a', 'I', 2,
+	'"', 1, 's', '"', 1, 'x',
+	'"', 3, 'a', 'r', 'r', 'A', 2, 'T', '0', '$', 0, 2,
+	'{', 3,
+}
+
+func TestDecodeV8Value(t *testing.T) {
+	want := `{"a":1,"arr":[true,null],"s":"x"}`
+	// blink envelope with its trailer offset, then the snappy compressed wrapper
+	blink := append([]byte{0xFF, 0x15, 0xFE}, make([]byte, blinkTrailerOffsetSize)...)
+	blink = append(blink, v8Object...)
+	compressed := []byte{v8Version, blinkWrappedVersion, blinkWrappedCompressed, byte(len(v8Object)), byte(len(v8Object)-1) << 2}
+	compressed = append(compressed, v8Object...)
+	for name, value := range map[string][]byte{"v8": v8Object, "blink": blink, "compressed": compressed} {
+		v, err := chromiumIdbValue(value)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := toJson(v); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+	// an object referencing itself through ^0 and a two byte string
+	ref := []byte{0xFF, 0x0F, 'o', '"', 4, 's', 'e', 'l', 'f', '^', 0, '"', 1, 'u', 'c', 2, 0xE9, 0x00, '{', 2}
+	v, err := decodeV8Value(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := v.(map[string]interface{})
+	if object["u"] != "é" || object["self"] == nil {
+		t.Errorf("got %v", object)
+	}
+}
+
+func TestChromiumIndexedDB(t *testing.T) {
+	utf16BE := func(s string) []byte {
+		var b []byte
+		for _, r := range s {
+			b = binary.BigEndian.AppendUint16(b, uint16(r))
+		}
+		return b
+	}
+	withLength := func(s string) []byte {
+		return append([]byte{byte(len(s))}, utf16BE(s)...)
+	}
+	name := append([]byte{0, 0, 0, 0, chromiumIdbDatabaseName}, withLength("https_a.test_0")...)
+	name = append(name, withLength("app")...)
+	store := []byte{0, 1, 0, 0, chromiumIdbStoreMetadata, 1, chromiumIdbStoreName}
+	record := append([]byte{0, 1, 1, chromiumIdbDataIndex, chromiumIdbKeyArray, 2, chromiumIdbKeyString}, withLength("id")...)
+	record = append(record, chromiumIdbKeyNumber)
+	record = binary.LittleEndian.AppendUint64(record, 0x4000000000000000)
+	records := []levelDBRecord{
+		{Key: name, Value: []byte{1}},
+		{Key: store, Value: utf16BE("notes")},
+		{Key: record, Value: append([]byte{1}, v8Object...)},
+	}
+	entries := chromiumIndexedDB(chromiumOriginIdentifier("https_a.test_0"), records)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries %+v, want 1", len(entries), entries)
+	}
+	want := IndexedDBRecord{
+		Origin:      "https://a.test",
+		Database:    "app",
+		ObjectStore: "notes",
+		Key:         `["id",2]`,
+		Value:       `{"a":1,"arr":[true,null],"s":"x"}`,
+	}
+	if entries[0] != want {
+		t.Errorf("got %+v, want %+v", entries[0], want)
+	}
+}
+
+func TestDecodeStructuredClone(t *testing.T) {
+	pair := func(b []byte, tag, data uint32) []byte {
+		return binary.LittleEndian.AppendUint64(b, uint64(tag)<<32|uint64(data))
+	}
+	var clone []byte
+	clone = pair(clone, scHeader, 0)
+	clone = pair(clone, scObjectObject, 0)
+	clone = pair(clone, scString, 1|scLatin1Flag)
+	clone = append(clone, 'k', 0, 0, 0, 0, 0, 0, 0)
+	clone = pair(clone, scString, 2)
+	clone = append(clone, 0xE9, 0, 'a', 0, 0, 0, 0, 0)
+	clone = pair(clone, scString, 1|scLatin1Flag)
+	clone = append(clone, 'n', 0, 0, 0, 0, 0, 0, 0)
+	clone = pair(clone, scArrayObject, 2)
+	clone = pair(clone, scInt32, 1)
+	clone = binary.LittleEndian.AppendUint64(clone, 0x3FF8000000000000)
+	clone = pair(clone, scEndOfKeys, 0)
+	clone = pair(clone, scEndOfKeys, 0)
+	v, err := decodeStructuredClone(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := toJson(v), `{"k":"éa","n":[null,1.5]}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDecodeFirefoxKey(t *testing.T) {
+	tests := []struct {
+		key  []byte
+		want string
+	}{
+		{[]byte{firefoxKeyString, 'a' + 1, 'b' + 1}, `"ab"`},
+		// 1.0 with the sign bit flipped and the trailing zeros trimmed
+		{[]byte{firefoxKeyFloat, 0xBF, 0xF0}, `1`},
+		// é is 0xE9, above the one byte limit
+		{[]byte{firefoxKeyString, 0x80, 0xE9 - 0x7F}, `"é"`},
+		{[]byte{firefoxKeyArray + firefoxKeyString, 'a' + 1, 0, firefoxKeyFloat, 0xBF, 0xF0, 0, 0, 0, 0, 0, 0}, `["a",1]`},
+		{[]byte{firefoxKeyArray}, `[]`},
+	}
+	for _, tt := range tests {
+		v, err := decodeFirefoxKey(tt.key)
+		if err != nil {
+			t.Errorf("%x: %v", tt.key, err)
+			continue
+		}
+		if got := toJson(v); got != tt.want {
+			t.Errorf("%x: got %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+const (
+	levelDBBlockSize  = 32 * 1024
+	levelDBHeaderSize = 7
+	// levelDBFooterSize is the metaindex and index handles padded to 40 bytes and the magic
+	levelDBFooterSize  = 48
+	levelDBTableMagic  = 0xdb4775248b80fb57
+	levelDBTrailerSize = 5
+
+	levelDBTypeDeletion = 0
+	levelDBTypeValue    = 1
+
+	levelDBLogFull   = 1
+	levelDBLogFirst  = 2
+	levelDBLogMiddle = 3
+	levelDBLogLast   = 4
+
+	levelDBNoCompression     = 0
+	levelDBSnappyCompression = 1
+)
+
+var errLevelDBCorrupt = errors.New("leveldb: corrupt file")
+
+// levelDBRecord is a key of a leveldb database, Seq orders the writes of the same key
+type levelDBRecord struct {
+	Key     []byte
+	Value   []byte
+	Seq     uint64
+	Deleted bool
+}
+
+// readLevelDB read every log and table file of a leveldb directory and return the newest
+// value of each live key sorted by key. The MANIFEST is not read, the sequence numbers are
+// enough to pick the newest record and a broken file only loses its own records.
+func readLevelDB(dir string) ([]levelDBRecord, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	newest := make(map[string]levelDBRecord)
+	add := func(r levelDBRecord) {
+		if old, ok := newest[string(r.Key)]; !ok || r.Seq >= old.Seq {
+			newest[string(r.Key)] = r
+		}
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		var read func([]byte, func(levelDBRecord)) error
+		switch filepath.Ext(f.Name()) {
+		case ".log":
+			read = readLevelDBLog
+		case ".ldb", ".sst":
+			read = readLevelDBTable
+		default:
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			logger.Debug(err)
+			continue
+		}
+		if err := read(content, add); err != nil {
+			logger.Debugf("leveldb %s: %s", f.Name(), err)
+		}
+	}
+	records := make([]levelDBRecord, 0, len(newest))
+	for _, r := range newest {
+		if !r.Deleted {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return bytes.Compare(records[i].Key, records[j].Key) < 0
+	})
+	return records, nil
+}
+
+// readLevelDBLog read the write batches of a log file, records are cut in 32KiB blocks
+// and a batch can span several of them. The checksums are not verified.
+func readLevelDBLog(content []byte, add func(levelDBRecord)) error {
+	var batch []byte
+	for block := 0; block < len(content); block += levelDBBlockSize {
+		end := block + levelDBBlockSize
+		if end > len(content) {
+			end = len(content)
+		}
+		// a block tail shorter than a header is padding
+		for i := block; i+levelDBHeaderSize <= end; {
+			length := int(binary.LittleEndian.Uint16(content[i+4:]))
+			kind := content[i+6]
+			i += levelDBHeaderSize
+			if kind == 0 && length == 0 {
+				// preallocated space that was never written
+				break
+			}
+			if i+length > end {
+				return errLevelDBCorrupt
+			}
+			chunk := content[i : i+length]
+			i += length
+			switch kind {
+			case levelDBLogFull:
+				if err := readLevelDBBatch(chunk, add); err != nil {
+					return err
+				}
+			case levelDBLogFirst:
+				batch = append([]byte{}, chunk...)
+			case levelDBLogMiddle:
+				batch = append(batch, chunk...)
+			case levelDBLogLast:
+				if err := readLevelDBBatch(append(batch, chunk...), add); err != nil {
+					return err
+				}
+				batch = nil
+			default:
+				return errLevelDBCorrupt
+			}
+		}
+	}
+	return nil
+}
+
+// readLevelDBBatch read a write batch, a sequence number and the count of the puts and
+// deletes that follow it, each of them takes the next sequence number
+func readLevelDBBatch(batch []byte, add func(levelDBRecord)) error {
+	if len(batch) < 12 {
+		return errLevelDBCorrupt
+	}
+	seq := binary.LittleEndian.Uint64(batch)
+	count := binary.LittleEndian.Uint32(batch[8:])
+	p := batch[12:]
+	for k := uint32(0); k < count; k++ {
+		if len(p) == 0 {
+			return errLevelDBCorrupt
+		}
+		kind := p[0]
+		r := levelDBRecord{Seq: seq + uint64(k)}
+		var err error
+		if r.Key, p, err = levelDBSlice(p[1:]); err != nil {
+			return err
+		}
+		switch kind {
+		case levelDBTypeValue:
+			if r.Value, p, err = levelDBSlice(p); err != nil {
+				return err
+			}
+		case levelDBTypeDeletion:
+			r.Deleted = true
+		default:
+			return errLevelDBCorrupt
+		}
+		add(r)
+	}
+	return nil
+}
+
+// levelDBSlice read a varint length prefixed slice and return the rest
+func levelDBSlice(p []byte) ([]byte, []byte, error) {
+	length, n := binary.Uvarint(p)
+	if n <= 0 || uint64(len(p)-n) < length {
+		return nil, nil, errLevelDBCorrupt
+	}
+	end := n + int(length)
+	return p[n:end], p[end:], nil
+}
+
+// readLevelDBTable read a sorted table (.ldb, .sst), the footer points to the index block
+// whose values are the handles of the data blocks. Table keys are internal keys, the user
+// key followed by the sequence number and the type packed in 8 bytes.
+func readLevelDBTable(content []byte, add func(levelDBRecord)) error {
+	if len(content) < levelDBFooterSize {
+		return errLevelDBCorrupt
+	}
+	footer := content[len(content)-levelDBFooterSize:]
+	if binary.LittleEndian.Uint64(footer[levelDBFooterSize-8:]) != levelDBTableMagic {
+		return errors.New("leveldb: bad table magic")
+	}
+	// skip the metaindex handle, the filters are not needed
+	_, _, rest, err := levelDBHandle(footer)
+	if err != nil {
+		return err
+	}
+	offset, size, _, err := levelDBHandle(rest)
+	if err != nil {
+		return err
+	}
+	index, err := levelDBBlock(content, offset, size)
+	if err != nil {
+		return err
+	}
+	return levelDBBlockEntries(index, func(_, handle []byte) error {
+		offset, size, _, err := levelDBHandle(handle)
+		if err != nil {
+			return err
+		}
+		block, err := levelDBBlock(content, offset, size)
+		if err != nil {
+			return err
+		}
+		return levelDBBlockEntries(block, func(key, value []byte) error {
+			if len(key) < 8 {
+				return errLevelDBCorrupt
+			}
+			tag := binary.LittleEndian.Uint64(key[len(key)-8:])
+			add(levelDBRecord{
+				Key:     key[:len(key)-8],
+				Value:   value,
+				Seq:     tag >> 8,
+				Deleted: tag&0xFF == levelDBTypeDeletion,
+			})
+			return nil
+		})
+	})
+}
+
+// levelDBHandle read a block handle, the offset and size varints, and return the rest
+func levelDBHandle(p []byte) (uint64, uint64, []byte, error) {
+	offset, n := binary.Uvarint(p)
+	if n <= 0 {
+		return 0, 0, nil, errLevelDBCorrupt
+	}
+	size, m := binary.Uvarint(p[n:])
+	if m <= 0 {
+		return 0, 0, nil, errLevelDBCorrupt
+	}
+	return offset, size, p[n+m:], nil
+}
+
+// levelDBBlock return the content of a block, a block is followed by its compression
+// type and a checksum
+func levelDBBlock(content []byte, offset, size uint64) ([]byte, error) {
+	if offset > uint64(len(content)) || size > uint64(len(content))-offset ||
+		offset+size+levelDBTrailerSize > uint64(len(content)) {
+		return nil, errLevelDBCorrupt
+	}
+	block := content[offset : offset+size]
+	switch content[offset+size] {
+	case levelDBNoCompression:
+		return block, nil
+	case levelDBSnappyCompression:
+		return decodeSnappy(block)
+	default:
+		return nil, errors.New("leveldb: unknown block compression")
+	}
+}
+
+// levelDBBlockEntries walk the entries of a block, keys share a prefix with the previous
+// key and the block ends with the restart offsets and their count
+func levelDBBlockEntries(block []byte, fn func(key, value []byte) error) error {
+	if len(block) < 4 {
+		return errLevelDBCorrupt
+	}
+	restarts := int(binary.LittleEndian.Uint32(block[len(block)-4:]))
+	limit := len(block) - 4 - 4*restarts
+	if restarts < 0 || limit < 0 {
+		return errLevelDBCorrupt
+	}
+	var key []byte
+	for i := 0; i < limit; {
+		var header [3]uint64
+		for k := range header {
+			v, n := binary.Uvarint(block[i:limit])
+			if n <= 0 {
+				return errLevelDBCorrupt
+			}
+			header[k] = v
+			i += n
+		}
+		shared, unshared, valueLength := header[0], header[1], header[2]
+		if shared > uint64(len(key)) || unshared+valueLength > uint64(limit-i) {
+			return errLevelDBCorrupt
+		}
+		// the key is handed out, build a new one instead of reusing the buffer
+		next := make([]byte, shared+unshared)
+		copy(next, key[:shared])
+		copy(next[shared:], block[i:i+int(unshared)])
+		key = next
+		i += int(unshared)
+		value := block[i : i+int(valueLength)]
+		i += int(valueLength)
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeSnappy(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"literal", []byte{5, 4 << 2, 'h', 'e', 'l', 'l', 'o'}, "hello"},
+		// copy with a 1 byte offset: 3 literals then 4+1 bytes from offset 3
+		{"overlapping copy", []byte{8, 2 << 2, 'a', 'b', 'c', 1<<2 | 0x01, 3}, "abcabcab"},
+		// copy with a 2 bytes offset of length 2
+		{"copy 2", []byte{4, 1 << 2, 'x', 'y', 1<<2 | 0x02, 2, 0}, "xyxy"},
+		// literal of 60 bytes needs one extra length byte
+		{"long literal", append([]byte{60, 60 << 2, 59}, bytes.Repeat([]byte("q"), 60)...), string(bytes.Repeat([]byte("q"), 60))},
+	}
+	for _, tt := range tests {
+		got, err := decodeSnappy(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if _, err := decodeSnappy([]byte{4, 0x01, 9}); err == nil {
+		t.Error("copy before any output should fail")
+	}
+}
+
+func levelDBPut(p []byte, kind byte, key, value string) []byte {
+	p = append(p, kind)
+	p = binary.AppendUvarint(p, uint64(len(key)))
+	p = append(p, key...)
+	if kind == levelDBTypeValue {
+		p = binary.AppendUvarint(p, uint64(len(value)))
+		p = append(p, value...)
+	}
+	return p
+}
+
+// levelDBTestLog write a log holding one batch, split in a first and a last fragment
+func levelDBTestLog(seq uint64, puts ...[3]string) []byte {
+	batch := binary.LittleEndian.AppendUint64(nil, seq)
+	batch = binary.LittleEndian.AppendUint32(batch, uint32(len(puts)))
+	for _, put := range puts {
+		kind := byte(levelDBTypeValue)
+		if put[0] == "del" {
+			kind = levelDBTypeDeletion
+		}
+		batch = levelDBPut(batch, kind, put[1], put[2])
+	}
+	var log []byte
+	half := len(batch) / 2
+	for i, chunk := range [][]byte{batch[:half], batch[half:]} {
+		log = append(log, 0, 0, 0, 0)
+		log = binary.LittleEndian.AppendUint16(log, uint16(len(chunk)))
+		log = append(log, byte(levelDBLogFirst+2*i))
+		log = append(log, chunk...)
+	}
+	return log
+}
+
+// levelDBTestTable write a table with one data block, snappy compressed as a literal
+func levelDBTestTable(seq uint64, kv ...string) []byte {
+	var block []byte
+	for i := 0; i < len(kv); i += 2 {
+		key := binary.LittleEndian.AppendUint64([]byte(kv[i]), seq<<8|levelDBTypeValue)
+		block = binary.AppendUvarint(block, 0)
+		block = binary.AppendUvarint(block, uint64(len(key)))
+		block = binary.AppendUvarint(block, uint64(len(kv[i+1])))
+		block = append(block, key...)
+		block = append(block, kv[i+1]...)
+	}
+	block = binary.LittleEndian.AppendUint32(block, 0)
+	block = binary.LittleEndian.AppendUint32(block, 1)
+	compressed := binary.AppendUvarint(nil, uint64(len(block)))
+	compressed = append(compressed, 61<<2)
+	compressed = binary.LittleEndian.AppendUint16(compressed, uint16(len(block)-1))
+	compressed = append(compressed, block...)
+
+	table := append(compressed, levelDBSnappyCompression, 0, 0, 0, 0)
+	handle := binary.AppendUvarint(nil, 0)
+	handle = binary.AppendUvarint(handle, uint64(len(compressed)))
+	var index []byte
+	index = binary.AppendUvarint(index, 0)
+	index = binary.AppendUvarint(index, 1)
+	index = binary.AppendUvarint(index, uint64(len(handle)))
+	index = append(index, '~')
+	index = append(index, handle...)
+	index = binary.LittleEndian.AppendUint32(index, 0)
+	index = binary.LittleEndian.AppendUint32(index, 1)
+	indexOffset := len(table)
+	table = append(table, index...)
+	table = append(table, levelDBNoCompression, 0, 0, 0, 0)
+
+	footer := binary.AppendUvarint(nil, 0)
+	footer = binary.AppendUvarint(footer, 0)
+	footer = binary.AppendUvarint(footer, uint64(indexOffset))
+	footer = binary.AppendUvarint(footer, uint64(len(index)))
+	footer = append(footer, make([]byte, 40-len(footer))...)
+	footer = binary.LittleEndian.AppendUint64(footer, levelDBTableMagic)
+	return append(table, footer...)
+}
+
+func utf16Bytes(s string) string {
+	var b []byte
+	for _, r := range s {
+		b = binary.LittleEndian.AppendUint16(b, uint16(r))
+	}
+	return string(b)
+}
+
+func TestChromiumLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	// the table is older than the log, the log overwrites token and deletes gone
+	table := levelDBTestTable(1,
+		"META:https://a.test", "\x08\x80\x80\x80\x80\x80\x80\x80\x17",
+		"_https://a.test\x00\x01gone", "\x01x",
+		"_https://a.test\x00\x01token", "\x01old",
+	)
+	log := levelDBTestLog(10,
+		[3]string{"put", "_https://a.test\x00\x01token", "\x00" + utf16Bytes("néw")},
+		[3]string{"del", "_https://a.test\x00\x01gone", ""},
+		[3]string{"put", "_https://b.test\x00\x00" + utf16Bytes("k"), "\x01caf\xe9"},
+	)
+	if err := os.WriteFile(filepath.Join(dir, "000005.ldb"), table, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "000006.log"), log, 0600); err != nil {
+		t.Fatal(err)
+	}
+	records, err := readLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := chromiumLocalStorage(records)
+	want := []StorageEntry{
+		{Origin: "https://a.test", Key: "token", Value: "néw", Storage: StorageLocal},
+		{Origin: "https://b.test", Key: "k", Value: "café", Storage: StorageLocal},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries %+v, want %d", len(entries), entries, len(want))
+	}
+	for i := range want {
+		got := entries[i]
+		if got.Origin != want[i].Origin || got.Key != want[i].Key || got.Value != want[i].Value || got.Storage != want[i].Storage {
+			t.Errorf("entry %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+	if entries[0].LastModified.IsZero() || !entries[1].LastModified.IsZero() {
+		t.Errorf("last modified: got %v and %v", entries[0].LastModified, entries[1].LastModified)
+	}
+}
+
+func TestChromiumSessionStorage(t *testing.T) {
+	guid := "0b7b1f2a_9c1d_4e5f_8a6b_7c8d9e0f1a2b"
+	records := []levelDBRecord{
+		{Key: []byte("map-3-" + utf16Bytes("tab")), Value: []byte(utf16Bytes("2"))},
+		{Key: []byte("map-4-" + utf16Bytes("orphan")), Value: []byte(utf16Bytes("x"))},
+		{Key: []byte("namespace-" + guid + "-https://a.test/"), Value: []byte("3")},
+	}
+	entries := chromiumSessionStorage(records)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries %+v, want 1", len(entries), entries)
+	}
+	if e := entries[0]; e.Origin != "https://a.test/" || e.Key != "tab" || e.Value != "2" || e.Storage != StorageSession {
+		t.Errorf("got %+v", e)
+	}
+}
@@ -0,0 +1,104 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// mozLz4Magic starts the lz4 files of firefox (.mozlz4, .jsonlz4, .baklz4), it is
+// followed by the decompressed size as a little endian uint32 and a single lz4 block
+var mozLz4Magic = []byte("mozLz40\x00")
+
+// mozLz4MaxSize bounds the announced decompressed size, firefox never writes more
+var mozLz4MaxSize = 1 << 30
+
+var errLz4Corrupt = errors.New("lz4: corrupt block")
+
+// DecodeMozLz4 decompress a firefox mozlz4 file
+func DecodeMozLz4(src []byte) ([]byte, error) {
+	header := len(mozLz4Magic) + 4
+	if len(src) < header || !bytes.Equal(src[:len(mozLz4Magic)], mozLz4Magic) {
+		return nil, errors.New("mozlz4: bad magic")
+	}
+	size := int(binary.LittleEndian.Uint32(src[len(mozLz4Magic):header]))
+	if size > mozLz4MaxSize {
+		return nil, fmt.Errorf("mozlz4: decompressed size %d is too large", size)
+	}
+	dst, err := decodeLz4Block(src[header:], size)
+	if err != nil {
+		return nil, err
+	}
+	if len(dst) != size {
+		return nil, fmt.Errorf("mozlz4: got %d bytes, want %d", len(dst), size)
+	}
+	return dst, nil
+}
+
+// decodeLz4Block decompress a raw lz4 block, size is the expected output size
+func decodeLz4Block(src []byte, size int) ([]byte, error) {
+	dst := make([]byte, 0, size)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+		// literals
+		n, next, err := lz4Length(src, i, int(token>>4))
+		if err != nil {
+			return nil, err
+		}
+		i = next
+		if i+n > len(src) || len(dst)+n > size {
+			return nil, errLz4Corrupt
+		}
+		dst = append(dst, src[i:i+n]...)
+		i += n
+		// the last sequence has no match
+		if i == len(src) {
+			break
+		}
+		if i+2 > len(src) {
+			return nil, errLz4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, errLz4Corrupt
+		}
+		n, i, err = lz4Length(src, i, int(token&0x0F))
+		if err != nil {
+			return nil, err
+		}
+		n += 4
+		if len(dst)+n > size {
+			return nil, errLz4Corrupt
+		}
+		// the match can overlap the bytes it produces, copy one by one
+		start := len(dst) - offset
+		for k := 0; k < n; k++ {
+			dst = append(dst, dst[start+k])
+		}
+	}
+	return dst, nil
+}
+
+// lz4Length read the extra length bytes of a token nibble, 15 means more bytes follow
+func lz4Length(src []byte, i, n int) (int, int, error) {
+	if n != 15 {
+		return n, i, nil
+	}
+	for {
+		if i >= len(src) {
+			return 0, i, errLz4Corrupt
+		}
+		b := src[i]
+		i++
+		n += int(b)
+		if b != 255 {
+			return n, i, nil
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func mozLz4File(size int, block []byte) []byte {
+	src := append([]byte{}, mozLz4Magic...)
+	src = binary.LittleEndian.AppendUint32(src, uint32(size))
+	return append(src, block...)
+}
+
+func TestDecodeMozLz4(t *testing.T) {
+	long := bytes.Repeat([]byte("0123456789"), 30)
+	// 300 literals need the 15 nibble and 285 = 255 + 30 extra bytes
+	longBlock := append([]byte{0xF0, 255, 30}, long...)
+	tests := []struct {
+		name  string
+		block []byte
+		want  []byte
+	}{
+		{"literals", []byte{0x50, 'h', 'e', 'l', 'l', 'o'}, []byte("hello")},
+		{"overlapping match", []byte{0x38, 'a', 'b', 'c', 3, 0, 0x10, 'x'}, []byte("abcabcabcabcabcx")},
+		{"long literals", longBlock, long},
+		{"long match", []byte{0x1F, 'z', 1, 0, 1, 0x10, '!'}, append(bytes.Repeat([]byte("z"), 21), '!')},
+	}
+	for _, tt := range tests {
+		got, err := DecodeMozLz4(mozLz4File(len(tt.want), tt.block))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMozLz4Corrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"bad magic", []byte("notmozlz4file")},
+		{"offset before start", mozLz4File(8, []byte{0x14, 'a', 2, 0})},
+		{"zero offset", mozLz4File(8, []byte{0x14, 'a', 0, 0})},
+		{"truncated literals", mozLz4File(8, []byte{0x80, 'a'})},
+		{"output larger than announced", mozLz4File(2, []byte{0x50, 'h', 'e', 'l', 'l', 'o'})},
+		{"output shorter than announced", mozLz4File(9, []byte{0x50, 'h', 'e', 'l', 'l', 'o'})},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeMozLz4(tt.src); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	NetworkHSTS         = "hsts"
+	NetworkHPKP         = "hpkp"
+	NetworkExpectCT     = "expect-ct"
+	NetworkCertOverride = "cert-override"
+	NetworkAltSvc       = "alt-svc"
+	NetworkBrokenAltSvc = "broken-alt-svc"
+
+	networkListSep = ", "
+)
+
+// firefoxSiteSecurityStates is the SecurityPropertyState of a site security entry
+var firefoxSiteSecurityStates = map[string]string{
+	"0": "unset",
+	"1": "set",
+	"2": "knockout",
+	"3": "negative",
+}
+
+// firefoxOverrideBits are the letters of the override bits of cert_override.txt
+var firefoxOverrideBits = map[rune]string{
+	'M': "mismatch",
+	'U': "untrusted",
+	'T': "time",
+}
+
+// NetworkState is a per host network entry of the profile: HSTS, HPKP and Expect-CT
+// entries, certificate exception overrides and the alternative services (QUIC) learned
+// by chromium. Chromium saves the HSTS hosts hashed, HashedHost is set when the host
+// could not be matched against the hosts of the history.
+type NetworkState struct {
+	Host              string
+	Kind              string
+	Value             string
+	Detail            string
+	IncludeSubdomains bool
+	HashedHost        bool
+	Observed          time.Time
+	Expires           time.Time
+}
+
+type networkState struct {
+	mainPath string
+	tempDir  string
+	states   []NetworkState
+}
+
+func NewNetworkState(main, sub string) Item {
+	return &networkState{mainPath: main}
+}
+
+func (n *networkState) ChromeParse(key []byte) error {
+	return n.ChromeParseContext(context.Background(), key)
+}
+
+func (n *networkState) ChromeParseContext(ctx context.Context, key []byte) error {
+	n.states = nil
+	transport, err := os.ReadFile(filepath.Join(n.tempDir, filepath.Base(ChromeTransportFile)))
+	if err != nil {
+		return err
+	}
+	netState, err := os.ReadFile(filepath.Join(n.tempDir, ChromeNetStateFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	hosts := n.chromiumHistoryHosts(ctx)
+	servers := chromiumAltServices(gjson.ParseBytes(netState))
+	for _, v := range servers {
+		addHashedHost(hosts, v.Host)
+	}
+	n.states = append(chromiumTransportSecurity(gjson.ParseBytes(transport), hosts), servers...)
+	return ctx.Err()
+}
+
+// chromiumHostHash is the key of a TransportSecurity entry, the sha256 of the host in
+// DNS wire format
+func chromiumHostHash(host string) string {
+	var wire []byte
+	for _, label := range strings.Split(strings.TrimSuffix(strings.ToLower(host), "."), ".") {
+		if label == "" || len(label) > 63 {
+			return ""
+		}
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	sum := sha256.Sum256(append(wire, 0))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// addHashedHost add the host and its parent domains, the parents can have an entry
+// that includes their subdomains
+func addHashedHost(hosts map[string]string, host string) {
+	for host != "" {
+		if hash := chromiumHostHash(host); hash != "" {
+			if _, ok := hosts[hash]; ok {
+				return
+			}
+			hosts[hash] = host
+		}
+		_, host, _ = strings.Cut(host, ".")
+	}
+}
+
+// chromiumHistoryHosts return the hash to host map of the hosts found in the history
+func (n *networkState) chromiumHistoryHosts(ctx context.Context) map[string]string {
+	hosts := make(map[string]string)
+	historyPath := filepath.Join(n.tempDir, ChromeHistoryFile)
+	if _, err := os.Stat(historyPath); err != nil {
+		return hosts
+	}
+	historyDB, err := sql.Open("sqlite3", historyPath)
+	if err != nil {
+		logger.Debug(err)
+		return hosts
+	}
+	defer func() {
+		if err := historyDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := historyDB.QueryContext(ctx, QueryChromiumHistoryUrls)
+	if err != nil {
+		logger.Debug(err)
+		return hosts
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	for rows.Next() {
+		var raw string
+		if err = rows.Scan(&raw); err != nil {
+			continue
+		}
+		if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+			addHashedHost(hosts, u.Hostname())
+		}
+	}
+	return hosts
+}
+
+// chromiumTransportSecurity read the sts and expect_ct lists of TransportSecurity, the
+// files before version 2 are an object of entries by hashed host
+func chromiumTransportSecurity(transport gjson.Result, hosts map[string]string) []NetworkState {
+	var states []NetworkState
+	host := func(hash string) (string, bool) {
+		if h, ok := hosts[hash]; ok {
+			return h, false
+		}
+		return hash, true
+	}
+	if !transport.Get("version").Exists() {
+		transport.ForEach(func(hash, entry gjson.Result) bool {
+			h, hashed := host(hash.String())
+			if mode := entry.Get("mode").String(); mode != "" && mode != "default" {
+				states = append(states, NetworkState{
+					Host:              h,
+					Kind:              NetworkHSTS,
+					Value:             mode,
+					IncludeSubdomains: entry.Get("sts_include_subdomains").Bool() || entry.Get("include_subdomains").Bool(),
+					HashedHost:        hashed,
+					Observed:          doubleTime(entry.Get("sts_observed").Float()),
+					Expires:           doubleTime(entry.Get("expiry").Float()),
+				})
+			}
+			if expectCT := entry.Get("expect_ct"); expectCT.Exists() {
+				states = append(states, chromiumExpectCT(h, hashed, expectCT))
+			}
+			return true
+		})
+		return states
+	}
+	for _, entry := range transport.Get("sts").Array() {
+		h, hashed := host(entry.Get("host").String())
+		states = append(states, NetworkState{
+			Host:              h,
+			Kind:              NetworkHSTS,
+			Value:             entry.Get("mode").String(),
+			IncludeSubdomains: entry.Get("sts_include_subdomains").Bool(),
+			HashedHost:        hashed,
+			Observed:          doubleTime(entry.Get("sts_observed").Float()),
+			Expires:           doubleTime(entry.Get("expiry").Float()),
+		})
+	}
+	for _, entry := range transport.Get("expect_ct").Array() {
+		h, hashed := host(entry.Get("host").String())
+		states = append(states, chromiumExpectCT(h, hashed, entry))
+	}
+	return states
+}
+
+func chromiumExpectCT(host string, hashed bool, entry gjson.Result) NetworkState {
+	value := "report"
+	if entry.Get("expect_ct_enforce").Bool() {
+		value = "enforce"
+	}
+	return NetworkState{
+		Host:       host,
+		Kind:       NetworkExpectCT,
+		Value:      value,
+		Detail:     entry.Get("expect_ct_report_uri").String(),
+		HashedHost: hashed,
+		Observed:   doubleTime(entry.Get("expect_ct_observed").Float()),
+		Expires:    doubleTime(entry.Get("expect_ct_expiry").Float()),
+	}
+}
+
+// doubleTime convert the seconds since the unix epoch chromium saves as a double
+func doubleTime(seconds float64) time.Time {
+	whole, frac := math.Modf(seconds)
+	if whole == 0 && frac == 0 {
+		return time.Time{}
+	}
+	return filemgmt.UnixMilliTime(int64(whole)*1000 + int64(frac*1000))
+}
+
+// chromiumAltServices read the alternative services of the http server properties,
+// servers are objects with a server field, or a single host:port key before version 5
+func chromiumAltServices(netState gjson.Result) []NetworkState {
+	var states []NetworkState
+	properties := netState.Get("net.http_server_properties")
+	for _, server := range properties.Get("servers").Array() {
+		name, props := server.Get("server").String(), server
+		if name == "" {
+			server.ForEach(func(key, value gjson.Result) bool {
+				name, props = key.String(), value
+				return false
+			})
+		}
+		host := name
+		if u, err := url.Parse(name); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		} else if h, _, ok := strings.Cut(name, ":"); ok {
+			host = h
+		}
+		for _, alt := range props.Get("alternative_service").Array() {
+			value := fmt.Sprintf("%s:%d", alt.Get("protocol_str").String(), alt.Get("port").Int())
+			if alt.Get("host").String() != "" {
+				value = fmt.Sprintf("%s:%s:%d", alt.Get("protocol_str").String(), alt.Get("host").String(), alt.Get("port").Int())
+			}
+			var alpns []string
+			for _, a := range alt.Get("advertised_alpns").Array() {
+				alpns = append(alpns, a.String())
+			}
+			states = append(states, NetworkState{
+				Host:    host,
+				Kind:    NetworkAltSvc,
+				Value:   value,
+				Detail:  strings.Join(alpns, networkListSep),
+				Expires: filemgmt.WebKitTime(alt.Get("expiration").Int()),
+			})
+		}
+	}
+	for _, broken := range properties.Get("broken_alternative_services").Array() {
+		states = append(states, NetworkState{
+			Host:    broken.Get("host").String(),
+			Kind:    NetworkBrokenAltSvc,
+			Value:   fmt.Sprintf("%s:%d", broken.Get("protocol_str").String(), broken.Get("port").Int()),
+			Detail:  fmt.Sprintf("broken %d times", broken.Get("broken_count").Int()),
+			Expires: filemgmt.UnixTime(broken.Get("broken_until").Int()),
+		})
+	}
+	return states
+}
+
+func (n *networkState) FirefoxParse() error {
+	return n.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read SiteSecurityServiceState.txt and cert_override.txt, the
+// SiteSecurityServiceState.bin of the recent versions is not read
+func (n *networkState) FirefoxParseContext(ctx context.Context) error {
+	n.states = nil
+	if siteSecurity, err := os.ReadFile(filepath.Join(n.tempDir, FirefoxSiteSecFile)); err == nil {
+		n.states = append(n.states, firefoxSiteSecurity(siteSecurity)...)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if overrides, err := os.ReadFile(filepath.Join(n.tempDir, FirefoxCertOverrideFile)); err == nil {
+		n.states = append(n.states, firefoxCertOverrides(overrides)...)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// firefoxSiteSecurity read the host:HSTS or host:HPKP lines, each one is the key, the
+// score, the last access day and the value, the value of HSTS is the expiry in ms, the
+// state and the include subdomains flag
+func firefoxSiteSecurity(src []byte) []NetworkState {
+	var states []NetworkState
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		host, kind, ok := strings.Cut(fields[0], ":")
+		if !ok {
+			continue
+		}
+		// origin attributes like ^privateBrowsingId=1 follow the host
+		host, _, _ = strings.Cut(host, "^")
+		value := strings.Split(fields[3], ",")
+		state := NetworkState{Host: host, Kind: strings.ToLower(kind)}
+		if day, err := strconv.ParseInt(fields[2], 10, 64); err == nil && day > 0 {
+			state.Observed = filemgmt.UnixTime(day * 24 * 60 * 60)
+		}
+		if len(value) >= 3 {
+			expiry, _ := strconv.ParseInt(value[0], 10, 64)
+			state.Expires = filemgmt.UnixMilliTime(expiry)
+			state.Value = firefoxSiteSecurityStates[value[1]]
+			state.IncludeSubdomains = value[2] == "1"
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// firefoxCertOverrides read the host:port lines of cert_override.txt, the fields are the
+// host, the fingerprint algorithm, the fingerprint, the override bits and the db key.
+// Newer versions end the host with the origin attributes and save no bits.
+func firefoxCertOverrides(src []byte) []NetworkState {
+	var states []NetworkState
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		hostPort, _, _ := strings.Cut(fields[0], ":^")
+		hostPort = strings.TrimSuffix(hostPort, ":")
+		state := NetworkState{Host: hostPort, Kind: NetworkCertOverride, Value: fields[2]}
+		if len(fields) > 3 {
+			var bits []string
+			for _, b := range fields[3] {
+				if name, ok := firefoxOverrideBits[b]; ok {
+					bits = append(bits, name)
+				}
+			}
+			state.Detail = strings.Join(bits, networkListSep)
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// Records return all the parsed network entries
+func (n *networkState) Records() []NetworkState {
+	return n.states
+}
+
+// CopyDB copy the main file with its siblings, chromium also gets the History of the
+// profile to match the hashed hosts of TransportSecurity
+// CopyDB copy the chromium TransportSecurity with the files next to it. Firefox has no
+// file in every profile, the item is found by prefs.js and only SiteSecurityServiceState.txt
+// and cert_override.txt are copied, the item is skipped when the profile has none of them.
+func (n *networkState) CopyDB() error {
+	dir := filepath.Dir(n.mainPath)
+	if filepath.Base(n.mainPath) != filepath.Base(ChromeTransportFile) {
+		var files []string
+		for _, name := range []string{FirefoxSiteSecFile, FirefoxCertOverrideFile} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				files = append(files, filepath.Join(dir, name))
+			}
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("%s and %s: %w", FirefoxSiteSecFile, FirefoxCertOverrideFile, os.ErrNotExist)
+		}
+		tempDir, err := copyToTempDir(files[0], files[1:]...)
+		if err != nil {
+			return err
+		}
+		n.tempDir = tempDir
+		return nil
+	}
+	subs := []string{
+		filepath.Join(dir, ChromeNetStateFile),
+		filepath.Join(filepath.Dir(dir), ChromeHistoryFile),
+	}
+	for i, sub := range subs {
+		if _, err := os.Stat(sub); err != nil {
+			subs[i] = ""
+		}
+	}
+	tempDir, err := copyToTempDir(n.mainPath, subs...)
+	if err != nil {
+		return err
+	}
+	n.tempDir = tempDir
+	return nil
+}
+
+func (n *networkState) Release() error {
+	return releaseTempDir(n.tempDir)
+}
+
+func (n *networkState) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(n.states, func(i, j int) bool {
+		if n.states[i].Kind != n.states[j].Kind {
+			return n.states[i].Kind < n.states[j].Kind
+		}
+		return n.states[i].Host < n.states[j].Host
+	})
+	switch format {
+	case formatCSV:
+		err := n.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		n.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return n.outPutJsonLines(browser, dir)
+	default:
+		err := n.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (n *networkState) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameNetwork, GetFormatName(formatJson))
+	err := WriteToJson(filename, n.states)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d network entries, filename is %s \n", filemgmt.Prefix, len(n.states), filename)
+	return nil
+}
+
+func (n *networkState) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameNetwork, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, n.states); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d network entries, filename is %s \n", filemgmt.Prefix, len(n.states), filename)
+	return nil
+}
+
+func (n *networkState) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameNetwork, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, n.states); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d network entries, filename is %s \n", filemgmt.Prefix, len(n.states), filename)
+	return nil
+}
+
+func (n *networkState) outPutConsole() {
+	for _, v := range n.states {
+		fmt.Printf("%+v\n", v)
+	}
+}
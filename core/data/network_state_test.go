@@ -0,0 +1,73 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestChromiumTransportSecurity(t *testing.T) {
+	const hash = "IqSIDV+GGZHsSdniHujfcVpvB/iyxSLXsf+RCx1n7nw="
+	if got := chromiumHostHash("A.test."); got != hash {
+		t.Fatalf("got hash %s, want %s", got, hash)
+	}
+	hosts := make(map[string]string)
+	addHashedHost(hosts, "www.a.test")
+	transport := gjson.Parse(`{"sts":[{"host":"` + hash + `","mode":"force-https","sts_include_subdomains":true,"expiry":1700000000.5},
+		{"host":"other=","mode":"force-https"}],"version":2}`)
+	states := chromiumTransportSecurity(transport, hosts)
+	if len(states) != 2 {
+		t.Fatalf("got %d states %+v, want 2", len(states), states)
+	}
+	if s := states[0]; s.Host != "a.test" || s.HashedHost || !s.IncludeSubdomains || s.Expires.UnixMilli() != 1700000000500 {
+		t.Errorf("got %+v", s)
+	}
+	if s := states[1]; s.Host != "other=" || !s.HashedHost {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestFirefoxCertOverrides(t *testing.T) {
+	src := "# PSM Certificate Override Settings file\n" +
+		"old.test:443\tOID.2.16.840.1.101.3.4.2.1\tAB:CD\tMUT\tdbkey\n" +
+		"new.test:8443:^privateBrowsingId=1\tOID.2.16.840.1.101.3.4.2.1\tEF:01\t\n"
+	states := firefoxCertOverrides([]byte(src))
+	if len(states) != 2 {
+		t.Fatalf("got %d states %+v, want 2", len(states), states)
+	}
+	if s := states[0]; s.Host != "old.test:443" || s.Value != "AB:CD" || s.Detail != "mismatch, untrusted, time" {
+		t.Errorf("got %+v", s)
+	}
+	if s := states[1]; s.Host != "new.test:8443" || s.Value != "EF:01" || s.Detail != "" {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestFirefoxNetworkStateFiles(t *testing.T) {
+	profile := t.TempDir()
+	writeTestFile(t, filepath.Join(profile, FirefoxPrefsFile), "")
+	// recent versions keep the site security state in a .bin file that is not read
+	writeTestFile(t, filepath.Join(profile, "SiteSecurityServiceState.bin"), "\x00")
+	n := NewNetworkState(filepath.Join(profile, FirefoxPrefsFile), "").(*networkState)
+	if err := n.CopyDB(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want the item skipped", err)
+	}
+
+	writeTestFile(t, filepath.Join(profile, FirefoxCertOverrideFile), "old.test:443\tOID.2.16.840.1.101.3.4.2.1\tAB:CD\tU\tdbkey\n")
+	if err := n.CopyDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Release()
+	if err := n.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	if s := n.Records(); len(s) != 1 || s[0].Host != "old.test:443" {
+		t.Errorf("got %+v", s)
+	}
+}
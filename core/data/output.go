@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"github.com/teocci/go-chrome-cookies/filemgmt"
 	"os"
+	"reflect"
 
 	"github.com/jszwec/csvutil"
 )
@@ -18,20 +19,26 @@ const (
 	formatJson OutputFormat = iota
 	formatCSV
 	formatConsole
+	formatJsonLines
+	formatHTML
 )
 
 const (
-	FormatNameJson    = "json"
-	FormatNameCSV     = "csv"
-	FormatNameConsole = "console"
+	FormatNameJson      = "json"
+	FormatNameCSV       = "csv"
+	FormatNameConsole   = "console"
+	FormatNameJsonLines = "jsonl"
+	FormatNameHTML      = "html"
 )
 
 var (
 	utf8Bom = []byte{239, 187, 191}
 	formats = map[string]OutputFormat{
-		FormatNameJson:    formatJson,
-		FormatNameCSV:     formatCSV,
-		FormatNameConsole: formatConsole,
+		FormatNameJson:      formatJson,
+		FormatNameCSV:       formatCSV,
+		FormatNameConsole:   formatConsole,
+		FormatNameJsonLines: formatJsonLines,
+		FormatNameHTML:      formatHTML,
 	}
 )
 
@@ -44,7 +51,7 @@ func GetFormatName(format OutputFormat) string {
 }
 
 func formatNames() []string {
-	return []string{FormatNameJson, FormatNameCSV, FormatNameConsole}
+	return []string{FormatNameJson, FormatNameCSV, FormatNameConsole, FormatNameJsonLines, FormatNameHTML}
 }
 
 func WriteToJson(filename string, data interface{}) error {
@@ -69,6 +76,24 @@ func WriteToJson(filename string, data interface{}) error {
 	return nil
 }
 
+// WriteToJsonLines write every record of the slice as a single JSON line
+func WriteToJsonLines(filename string, records interface{}) error {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fnc := filemgmt.CloseFile()
+	defer fnc(f)
+	w := NewJsonLinesWriter(f)
+	v := reflect.ValueOf(records)
+	for i := 0; i < v.Len(); i++ {
+		if err = w.Write(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
 func WriteToCsv(filename string, data interface{}) error {
 	var d []byte
 	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
@@ -4,8 +4,10 @@
 package data
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -16,56 +18,274 @@ const (
 	ItemNameBookmark   = "bookmark"
 	ItemNameCookie     = "cookie"
 	ItemNameHistory    = "history"
+	ItemNameVisit      = "visit"
 	ItemNameDownload   = "downloads"
 	ItemNamePassword   = "password"
 	ItemNameCreditCard = "credit-card"
+	ItemNameAutofill   = "autofill"
+	ItemNameAddress    = "address"
+	ItemNameSearch     = "search-engine"
+	ItemNameShortcut   = "shortcut"
+	ItemNamePredictor  = "predictor"
+	ItemNameExtension  = "extension"
+	ItemNameStorage    = "local-storage"
+	ItemNameIndexedDB  = "indexeddb"
+	ItemNameSession    = "session"
+	ItemNamePermission = "permission"
+	ItemNameTopSite    = "top-site"
+	ItemNameFavicon    = "favicon"
+	ItemNameSetting    = "setting"
+	ItemNameNetwork    = "network-state"
+	ItemNameCache      = "cache"
 )
 
 type Item interface {
 	// ChromeParse parse chrome items, Password and Cookie need secret key
 	ChromeParse(key []byte) error
 
+	// ChromeParseContext is ChromeParse bounded by ctx, database scans stop when ctx is done
+	ChromeParseContext(ctx context.Context, key []byte) error
+
 	// FirefoxParse parse firefox items
 	FirefoxParse() error
 
+	// FirefoxParseContext is FirefoxParse bounded by ctx
+	FirefoxParseContext(ctx context.Context) error
+
 	// OutPut file name and format type
 	OutPut(format OutputFormat, browser, dir string) error
 
-	// CopyDB is copy item db file to current dir
+	// CopyDB is copy item db file to a private temp dir
 	CopyDB() error
 
-	// Release is delete item db file
+	// Release is delete item db file and its temp dir
 	Release() error
 }
 
+// RecordItem is an Item that exposes its parsed records, every item implements it for
+// its own record type, like RecordItem[Cookie] for the cookie item
+type RecordItem[T any] interface {
+	Item
+
+	// Records return the records read by the last parse
+	Records() []T
+}
+
+// Records return the records of item when it holds records of type T
+func Records[T any](item Item) ([]T, bool) {
+	r, ok := item.(RecordItem[T])
+	if !ok {
+		return nil, false
+	}
+	return r.Records(), true
+}
+
+var (
+	_ RecordItem[Address]         = (*addresses)(nil)
+	_ RecordItem[FormEntry]       = (*autofill)(nil)
+	_ RecordItem[Bookmark]        = (*bookmarks)(nil)
+	_ RecordItem[CacheEntry]      = (*cache)(nil)
+	_ RecordItem[Cookie]          = (*cookies)(nil)
+	_ RecordItem[CreditCard]      = (*creditCards)(nil)
+	_ RecordItem[Download]        = (*downloads)(nil)
+	_ RecordItem[Extension]       = (*extensions)(nil)
+	_ RecordItem[Favicon]         = (*favicons)(nil)
+	_ RecordItem[HistoryEntry]    = (*historyData)(nil)
+	_ RecordItem[IndexedDBRecord] = (*indexedDB)(nil)
+	_ RecordItem[NetworkState]    = (*networkState)(nil)
+	_ RecordItem[Login]           = (*passwords)(nil)
+	_ RecordItem[Permission]      = (*permissions)(nil)
+	_ RecordItem[Prediction]      = (*predictor)(nil)
+	_ RecordItem[SearchEngine]    = (*searchEngines)(nil)
+	_ RecordItem[SessionTab]      = (*sessions)(nil)
+	_ RecordItem[Setting]         = (*settings)(nil)
+	_ RecordItem[Shortcut]        = (*shortcuts)(nil)
+	_ RecordItem[TopSite]         = (*topSites)(nil)
+	_ RecordItem[Visit]           = (*visits)(nil)
+	_ RecordItem[StorageEntry]    = (*webStorage)(nil)
+)
+
 const (
-	ChromeCreditFile   = "Web Data"
-	ChromePasswordFile = "Login Data"
-	ChromeHistoryFile  = "History"
-	ChromeDownloadFile = "History"
-	ChromeCookieFile   = "Cookies"
-	ChromeBookmarkFile = "Bookmarks"
-	FirefoxCookieFile  = "cookies.sqlite"
-	FirefoxKey4File    = "key4.db"
-	FirefoxLoginFile   = "logins.json"
-	FirefoxDataFile    = "places.sqlite"
+	ChromeCreditFile        = "Web Data"
+	ChromeWebDataFile       = "Web Data"
+	ChromeShortcutFile      = "Shortcuts"
+	ChromePredictFile       = "Network Action Predictor"
+	ChromePrefsFile         = "Preferences"
+	ChromeSecPrefsFile      = "Secure Preferences"
+	ChromeExtensionDir      = "Extensions"
+	ChromeStorageDir        = "Local Storage/leveldb"
+	ChromeSessionDir        = "Session Storage"
+	ChromeIndexedDBDir      = "IndexedDB"
+	ChromeSessionsDir       = "Sessions"
+	FirefoxStorageDir       = "storage/default"
+	FirefoxWebappsFile      = "webappsstore.sqlite"
+	FirefoxSessionDir       = "sessionstore-backups"
+	FirefoxPermFile         = "permissions.sqlite"
+	ChromeTopSitesFile      = "Top Sites"
+	ChromeFaviconFile       = "Favicons"
+	FirefoxFaviconFile      = "favicons.sqlite"
+	ChromeLocalState        = "Local State"
+	FirefoxPrefsFile        = "prefs.js"
+	FirefoxUserJsFile       = "user.js"
+	ChromeTransportFile     = "Network/TransportSecurity"
+	ChromeNetStateFile      = "Network Persistent State"
+	FirefoxSiteSecFile      = "SiteSecurityServiceState.txt"
+	FirefoxCertOverrideFile = "cert_override.txt"
+	ChromeCacheDir          = "Cache/Cache_Data"
+	ChromeCacheIndexDir     = "index-dir"
+	FirefoxCacheDir         = "cache2/entries"
+	ChromePasswordFile      = "Login Data"
+	ChromeHistoryFile       = "History"
+	ChromeDownloadFile      = "History"
+	ChromeCookieFile        = "Cookies"
+	ChromeBookmarkFile      = "Bookmarks"
+	FirefoxCookieFile       = "cookies.sqlite"
+	FirefoxKey4File         = "key4.db"
+	FirefoxLoginFile        = "logins.json"
+	FirefoxDataFile         = "places.sqlite"
+	FirefoxFormFile         = "formhistory.sqlite"
+	FirefoxAddressFile      = "autofill-profiles.json"
+	FirefoxSearchFile       = "search.json.mozlz4"
+	FirefoxExtFile          = "extensions.json"
+	FirefoxAddonsFile       = "addons.json"
 )
 
 const (
-	QueryChromiumCredit   = `SELECT guid, name_on_card, expiration_month, expiration_year, card_number_encrypted FROM credit_cards`
-	QueryChromiumLogin    = `SELECT origin_url, username_value, password_value, date_created FROM logins`
-	QueryChromiumHistory  = `SELECT url, title, visit_count, last_visit_time FROM urls`
-	QueryChromiumDownload = `SELECT target_path, tab_url, total_bytes, start_time, end_time, mime_type FROM downloads`
-	QueryChromiumCookie   = `SELECT name, encrypted_value, host_key, path, creation_utc, expires_utc, is_secure, is_httponly, has_expires, is_persistent FROM cookies`
-	QueryFirefoxHistory   = `SELECT id, url, last_visit_date, title, visit_count FROM moz_places`
-	QueryFirefoxDownload  = `SELECT place_id, GROUP_CONCAT(content), url, dateAdded FROM (SELECT * FROM moz_annos INNER JOIN moz_places ON moz_annos.place_id=moz_places.id) t GROUP BY place_id`
-	QueryFirefoxBookMarks = `SELECT id, url, type, dateAdded, title FROM (SELECT * FROM moz_bookmarks INNER JOIN moz_places ON moz_bookmarks.fk=moz_places.id)`
-	QueryFirefoxCookie    = `SELECT name, value, host, path, creationTime, expiry, isSecure, isHttpOnly FROM moz_cookies`
-	QueryMetaData         = `SELECT item1, item2 FROM metaData WHERE id = 'password'`
-	QueryNssPrivate       = `SELECT a11, a102 from nssPrivate`
-	CloseJournalMode      = `PRAGMA journal_mode=off`
+	QueryChromiumCredit      = `SELECT guid, name_on_card, expiration_month, expiration_year, card_number_encrypted FROM credit_cards`
+	QueryChromiumLogin       = `SELECT %s FROM logins`
+	QueryChromiumHistory     = `SELECT url, title, visit_count, last_visit_time FROM urls`
+	QueryChromiumDownload    = `SELECT %s FROM downloads`
+	QueryChromiumUrlChains   = `SELECT id, url FROM downloads_url_chains ORDER BY id, chain_index`
+	QueryChromiumCookie      = `SELECT name, encrypted_value, host_key, path, creation_utc, expires_utc, is_secure, is_httponly, has_expires, is_persistent FROM cookies`
+	QueryFirefoxHistory      = `SELECT id, url, last_visit_date, title, visit_count FROM moz_places`
+	QueryFirefoxDownload     = `SELECT a.place_id, n.name, a.content, p.url, a.dateAdded FROM moz_annos a INNER JOIN moz_anno_attributes n ON a.anno_attribute_id = n.id INNER JOIN moz_places p ON a.place_id = p.id WHERE n.name LIKE 'downloads/%' ORDER BY a.place_id`
+	QueryFirefoxBookMarks    = `SELECT b.id, b.parent, b.type, b.position, b.title, b.dateAdded, b.lastModified, b.guid, p.url, p.last_visit_date FROM moz_bookmarks b LEFT JOIN moz_places p ON b.fk = p.id ORDER BY b.parent, b.position`
+	QueryFirefoxCookie       = `SELECT name, value, host, path, creationTime, expiry, isSecure, isHttpOnly FROM moz_cookies`
+	QueryChromiumVisits      = `SELECT v.id, u.url, IFNULL(u.title, ''), v.visit_time, IFNULL(v.from_visit, 0), v.transition, v.visit_duration, %s, IFNULL(s.source, 1) FROM visits v INNER JOIN urls u ON v.url = u.id LEFT JOIN visit_source s ON v.id = s.id ORDER BY v.visit_time`
+	QueryFirefoxVisits       = `SELECT v.id, p.url, IFNULL(p.title, ''), v.visit_date, IFNULL(v.from_visit, 0), v.visit_type, %s FROM moz_historyvisits v INNER JOIN moz_places p ON v.place_id = p.id ORDER BY v.visit_date`
+	QueryChromiumAutofill    = `SELECT name, value, count, date_created, date_last_used FROM autofill`
+	QueryFirefoxFormHistory  = `SELECT fieldname, value, timesUsed, IFNULL(firstUsed, 0), IFNULL(lastUsed, 0) FROM moz_formhistory`
+	QueryChromiumAddresses   = `SELECT %s FROM %s`
+	QueryChromiumAddrTokens  = `SELECT guid, type, value FROM local_addresses_type_tokens`
+	QueryChromiumAddrNames   = `SELECT guid, first_name, middle_name, last_name, full_name FROM autofill_profile_names`
+	QueryChromiumAddrEmails  = `SELECT guid, email FROM autofill_profile_emails`
+	QueryChromiumAddrPhones  = `SELECT guid, number FROM autofill_profile_phones`
+	QueryChromiumKeywords    = `SELECT %s FROM keywords`
+	QueryChromiumShortcuts   = `SELECT text, fill_into_edit, url, contents, description, transition, type, keyword, last_access_time, number_of_hits FROM omni_box_shortcuts`
+	QueryChromiumPredictor   = `SELECT user_text, url, number_of_hits, number_of_misses FROM network_action_predictor`
+	QueryFirefoxWebapps      = `SELECT %s, %s, key, value FROM webappsstore2`
+	QueryFirefoxLsOrigin     = `SELECT origin FROM database`
+	QueryFirefoxLsData       = `SELECT key, value, %s, %s FROM data`
+	QueryFirefoxIdbDatabase  = `SELECT name, origin FROM database`
+	QueryFirefoxIdbStores    = `SELECT id, name FROM object_store`
+	QueryFirefoxIdbData      = `SELECT object_store_id, key, data FROM object_data`
+	QueryFirefoxPermissions  = `SELECT origin, type, permission, expireType, expireTime, IFNULL(modificationTime, 0) FROM moz_perms`
+	QueryFirefoxNeverSave    = `SELECT origin, IFNULL(modificationTime, 0) FROM moz_perms WHERE type = 'login-saving' AND permission = 2`
+	QueryChromiumTopSites    = `SELECT %s FROM %s ORDER BY url_rank`
+	QueryFirefoxTopSites     = `SELECT url, IFNULL(title, '') FROM moz_places WHERE hidden = 0 AND frecency > 0 AND url LIKE 'http%' ORDER BY frecency DESC LIMIT ?`
+	QueryChromiumIconMapping = `SELECT m.page_url, f.id, f.url, f.icon_type FROM icon_mapping m INNER JOIN favicons f ON m.icon_id = f.id`
+	QueryChromiumIconBitmaps = `SELECT icon_id, width, height, last_updated, image_data FROM favicon_bitmaps`
+	QueryFirefoxIcons        = `SELECT p.page_url, i.icon_url, i.width, i.data FROM moz_icons_to_pages t INNER JOIN moz_pages_w_icons p ON t.page_id = p.id INNER JOIN moz_icons i ON t.icon_id = i.id UNION ALL SELECT '', icon_url, width, data FROM moz_icons WHERE root = 1`
+	QueryChromiumHistoryUrls = `SELECT url FROM urls`
+	QueryChromiumMetaVersion = `SELECT value FROM meta WHERE key = 'version'`
+	QueryMetaData            = `SELECT item1, item2 FROM metaData WHERE id = 'password'`
+	QueryNssPrivate          = `SELECT a11, a102 from nssPrivate`
+	CloseJournalMode         = `PRAGMA journal_mode=off`
 )
 
+// copyToTempDir copy the item files into a new private directory, so items of different
+// browsers and profiles never share their working copies. The main file must exist, empty
+// sub files are ignored and the sqlite -wal file is copied along when it exists.
+func copyToTempDir(main string, subs ...string) (string, error) {
+	dir, err := os.MkdirTemp("", "go-cc-")
+	if err != nil {
+		return "", err
+	}
+	for i, p := range append([]string{main}, subs...) {
+		if i > 0 && p == "" {
+			continue
+		}
+		if err := copyWithWal(p, filepath.Join(dir, filepath.Base(p))); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// copyWithWal copy a sqlite file to dst with its write-ahead log when there is one
+func copyWithWal(src, dst string) error {
+	for _, suffix := range []string{"", "-wal"} {
+		sourceFile, err := os.ReadFile(src + suffix)
+		if err != nil {
+			if suffix != "" && os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err = os.WriteFile(dst+suffix, sourceFile, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDirsToTempDir copy the files of the directories to a private temp dir, each one in a
+// directory with its base name. The lock file is left behind, missing dirs after the
+// first are skipped.
+func copyDirsToTempDir(main string, subs ...string) (string, error) {
+	dir, err := os.MkdirTemp("", "go-cc-")
+	if err != nil {
+		return "", err
+	}
+	for i, src := range append([]string{main}, subs...) {
+		files, err := os.ReadDir(src)
+		if err != nil {
+			if i > 0 && (src == "" || os.IsNotExist(err)) {
+				continue
+			}
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+		dst := filepath.Join(dir, filepath.Base(src))
+		if err := os.Mkdir(dst, 0700); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+		for _, f := range files {
+			if !f.Type().IsRegular() || f.Name() == "LOCK" {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(src, f.Name()))
+			if err != nil {
+				_ = os.RemoveAll(dir)
+				return "", err
+			}
+			if err := os.WriteFile(filepath.Join(dst, f.Name()), content, 0600); err != nil {
+				_ = os.RemoveAll(dir)
+				return "", err
+			}
+		}
+	}
+	return dir, nil
+}
+
+// makeItemDir create the <browser>_<item> directory an item writes its files to, like
+// the images or the cached bodies
+func makeItemDir(browser, item, dir string) (string, error) {
+	name := strings.Replace(strings.TrimSpace(strings.ToLower(browser)), " ", "_", -1)
+	itemDir := filepath.Join(dir, name+"_"+item)
+	return itemDir, os.MkdirAll(itemDir, 0700)
+}
+
+// releaseTempDir delete the directory created by copyToTempDir
+func releaseTempDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
 func CopyToLocalPath(src, dst string) error {
 	locals, _ := filepath.Glob("*")
 	for _, v := range locals {
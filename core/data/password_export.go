@@ -0,0 +1,371 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/throw"
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+)
+
+const (
+	PasswordProfileChrome    = "chrome"
+	PasswordProfileFirefox   = "firefox"
+	PasswordProfileBitwarden = "bitwarden"
+	PasswordProfileKeePass   = "keepass"
+)
+
+// PasswordProfile writes the logins in the import layout of a password manager
+type PasswordProfile interface {
+	// Name is the name the profile is selected with
+	Name() string
+
+	// Ext is the extension of the written file
+	Ext() string
+
+	// Write the logins, browser is the name of the browser they come from
+	Write(w io.Writer, browser string, logins []Login) error
+}
+
+// ProfileWriter is implemented by the items that can be written with a PasswordProfile
+type ProfileWriter interface {
+	OutPutProfile(profile, browser, dir string) error
+}
+
+var passwordProfiles = make(map[string]PasswordProfile)
+
+func init() {
+	for _, p := range []PasswordProfile{chromePasswordCsv{}, firefoxPasswordCsv{}, bitwardenJson{}, keePassXml{}} {
+		RegisterPasswordProfile(p)
+	}
+}
+
+// RegisterPasswordProfile add a profile, it replaces the one with the same name
+func RegisterPasswordProfile(p PasswordProfile) {
+	passwordProfiles[p.Name()] = p
+}
+
+// GetPasswordProfile return the profile registered with the name
+func GetPasswordProfile(name string) (PasswordProfile, error) {
+	p, ok := passwordProfiles[name]
+	if !ok {
+		return nil, throw.ErrorPasswordProfileNotSupported(name)
+	}
+	return p, nil
+}
+
+// PasswordProfileNames return the names of the registered profiles
+func PasswordProfileNames() []string {
+	var names []string
+	for name := range passwordProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WritePasswordProfile write the logins to a <browser>_password_<profile> file of dir,
+// the never saved sites are left out, they have no credentials to import
+func WritePasswordProfile(p PasswordProfile, browser, dir string, logins []Login) (string, error) {
+	var saved []Login
+	for _, v := range logins {
+		if !v.Blacklisted {
+			saved = append(saved, v)
+		}
+	}
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword+"_"+p.Name(), p.Ext())
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	fnc := filemgmt.CloseFile()
+	defer fnc(f)
+	w := bufio.NewWriter(f)
+	if err = p.Write(w, browser, saved); err != nil {
+		return "", err
+	}
+	return filename, w.Flush()
+}
+
+// loginOrigin return the login url without its path and its host, the android logins
+// keep the hash of their signing certificate, like android://hash@com.app
+func loginOrigin(login Login) (origin, host string) {
+	u, err := url.Parse(login.LoginUrl)
+	if err != nil || u.Host == "" {
+		return login.LoginUrl, login.LoginUrl
+	}
+	origin = u.Scheme + "://" + u.Host
+	if u.User != nil {
+		origin = u.Scheme + "://" + u.User.String() + "@" + u.Host
+	}
+	return origin, u.Hostname()
+}
+
+// chromePasswordCsv is the layout of chrome://password-manager/settings
+type chromePasswordCsv struct{}
+
+func (chromePasswordCsv) Name() string { return PasswordProfileChrome }
+
+func (chromePasswordCsv) Ext() string { return FormatNameCSV }
+
+func (chromePasswordCsv) Write(w io.Writer, browser string, logins []Login) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"name", "url", "username", "password", "note"})
+	for _, v := range logins {
+		_, host := loginOrigin(v)
+		_ = cw.Write([]string{host, v.LoginUrl, v.UserName, v.Password, ""})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// firefoxPasswordCsv is the layout of about:logins, every field is quoted and the times
+// are unix milliseconds
+type firefoxPasswordCsv struct{}
+
+func (firefoxPasswordCsv) Name() string { return PasswordProfileFirefox }
+
+func (firefoxPasswordCsv) Ext() string { return FormatNameCSV }
+
+func (firefoxPasswordCsv) Write(w io.Writer, browser string, logins []Login) error {
+	fields := []string{"url", "username", "password", "httpRealm", "formActionOrigin", "guid", "timeCreated", "timeLastUsed", "timePasswordChanged"}
+	if err := writeFirefoxCsvLine(w, fields); err != nil {
+		return err
+	}
+	for _, v := range logins {
+		origin, _ := loginOrigin(v)
+		// a login has either an http realm or a form action origin
+		action := ""
+		if v.HttpRealm == "" {
+			action = origin
+			if v.ActionUrl != "" {
+				action, _ = loginOrigin(Login{LoginUrl: v.ActionUrl})
+			}
+		}
+		created := firefoxCsvTime(v.CreateDate, time.Time{})
+		err := writeFirefoxCsvLine(w, []string{origin, v.UserName, v.Password, v.HttpRealm, action, v.Guid,
+			created, firefoxCsvTime(v.LastUsed, v.CreateDate), firefoxCsvTime(v.PasswordChanged, v.CreateDate)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firefoxCsvTime return t in unix milliseconds, or dflt when t is not set
+func firefoxCsvTime(t, dflt time.Time) string {
+	if t.IsZero() {
+		t = dflt
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(filemgmt.ToUnixMilliTime(t), 10)
+}
+
+func writeFirefoxCsvLine(w io.Writer, fields []string) error {
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = `"` + strings.ReplaceAll(f, `"`, `""`) + `"`
+	}
+	_, err := io.WriteString(w, strings.Join(quoted, ",")+"\r\n")
+	return err
+}
+
+// bitwardenJson is the unencrypted Bitwarden json export, items of type 1 are logins
+type bitwardenJson struct{}
+
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Folders   []bitwardenName `json:"folders"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenName struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	Type         int            `json:"type"`
+	Name         string         `json:"name"`
+	Notes        *string        `json:"notes"`
+	Favorite     bool           `json:"favorite"`
+	FolderID     string         `json:"folderId"`
+	Login        bitwardenLogin `json:"login"`
+	CreationDate string         `json:"creationDate,omitempty"`
+}
+
+type bitwardenLogin struct {
+	Uris                 []bitwardenUri `json:"uris"`
+	Username             string         `json:"username"`
+	Password             string         `json:"password"`
+	PasswordRevisionDate string         `json:"passwordRevisionDate,omitempty"`
+	Totp                 *string        `json:"totp"`
+}
+
+type bitwardenUri struct {
+	Match *int   `json:"match"`
+	Uri   string `json:"uri"`
+}
+
+func (bitwardenJson) Name() string { return PasswordProfileBitwarden }
+
+func (bitwardenJson) Ext() string { return FormatNameJson }
+
+// Write put the logins in a folder named after the browser
+func (bitwardenJson) Write(w io.Writer, browser string, logins []Login) error {
+	folder := bitwardenName{ID: loginUUID(browser).String(), Name: browser}
+	export := bitwardenExport{Folders: []bitwardenName{folder}, Items: []bitwardenItem{}}
+	for _, v := range logins {
+		_, host := loginOrigin(v)
+		item := bitwardenItem{
+			Type:     1,
+			Name:     host,
+			FolderID: folder.ID,
+			Login: bitwardenLogin{
+				Uris:     []bitwardenUri{{Uri: v.LoginUrl}},
+				Username: v.UserName,
+				Password: v.Password,
+			},
+		}
+		if !v.CreateDate.IsZero() {
+			item.CreationDate = v.CreateDate.UTC().Format(time.RFC3339)
+		}
+		if !v.PasswordChanged.IsZero() {
+			item.Login.PasswordRevisionDate = v.PasswordChanged.UTC().Format(time.RFC3339)
+		}
+		export.Items = append(export.Items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// keePassXml is the KeePass 2 XML layout, the logins are entries of a group named
+// after the browser
+type keePassXml struct{}
+
+type keePassFile struct {
+	XMLName   xml.Name     `xml:"KeePassFile"`
+	Generator string       `xml:"Meta>Generator"`
+	Group     keePassGroup `xml:"Root>Group"`
+}
+
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+}
+
+type keePassEntry struct {
+	UUID    string          `xml:"UUID"`
+	Times   keePassTimes    `xml:"Times"`
+	Strings []keePassString `xml:"String"`
+}
+
+type keePassTimes struct {
+	CreationTime         string `xml:"CreationTime,omitempty"`
+	LastModificationTime string `xml:"LastModificationTime,omitempty"`
+	LastAccessTime       string `xml:"LastAccessTime,omitempty"`
+	UsageCount           int64  `xml:"UsageCount"`
+}
+
+type keePassString struct {
+	Key   string       `xml:"Key"`
+	Value keePassValue `xml:"Value"`
+}
+
+type keePassValue struct {
+	Protect string `xml:"ProtectInMemory,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+func (keePassXml) Name() string { return PasswordProfileKeePass }
+
+func (keePassXml) Ext() string { return "xml" }
+
+func (keePassXml) Write(w io.Writer, browser string, logins []Login) error {
+	file := keePassFile{
+		Generator: "go-chrome-cookies",
+		Group:     keePassGroup{UUID: loginUUID(browser).base64(), Name: browser},
+	}
+	for _, v := range logins {
+		_, host := loginOrigin(v)
+		entry := keePassEntry{
+			UUID: loginUUID(browser, v.LoginUrl, v.ActionUrl, v.SignonRealm, v.UserName).base64(),
+			Strings: []keePassString{
+				{Key: "Title", Value: keePassValue{Value: host}},
+				{Key: "UserName", Value: keePassValue{Value: v.UserName}},
+				{Key: "Password", Value: keePassValue{Protect: "True", Value: v.Password}},
+				{Key: "URL", Value: keePassValue{Value: v.LoginUrl}},
+				{Key: "Notes", Value: keePassValue{}},
+			},
+		}
+		entry.Times = keePassTimes{
+			CreationTime:         keePassTime(v.CreateDate),
+			LastModificationTime: keePassTime(v.PasswordChanged),
+			LastAccessTime:       keePassTime(v.LastUsed),
+			UsageCount:           v.TimesUsed,
+		}
+		file.Group.Entries = append(file.Group.Entries, entry)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func keePassTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// uuid is a name based identifier, the same login gets the same one on every export so
+// a second import can be matched with the first
+type uuid [16]byte
+
+func loginUUID(names ...string) uuid {
+	h := md5.New()
+	for _, n := range names {
+		_, _ = io.WriteString(h, n)
+		_, _ = h.Write([]byte{0})
+	}
+	var u uuid
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x30 // version 3
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+func (u uuid) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+func (u uuid) base64() string {
+	return base64.StdEncoding.EncodeToString(u[:])
+}
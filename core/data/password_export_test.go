@@ -0,0 +1,132 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPasswordProfiles(t *testing.T) {
+	logins := []Login{
+		{LoginUrl: "https://a.test:8443/login", UserName: "ann", Password: `p,"1"`, CreateDate: time.UnixMilli(1700000000000)},
+		{LoginUrl: "android://hash@com.app/", UserName: "bob", Password: "<&>"},
+	}
+	want := map[string]string{
+		PasswordProfileChrome: "name,url,username,password,note\n" +
+			"a.test,https://a.test:8443/login,ann,\"p,\"\"1\"\"\",\n" +
+			"com.app,android://hash@com.app/,bob,<&>,\n",
+		PasswordProfileFirefox: `"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"` + "\r\n" +
+			`"https://a.test:8443","ann","p,""1""","","https://a.test:8443","","1700000000000","1700000000000","1700000000000"` + "\r\n" +
+			`"android://hash@com.app","bob","<&>","","android://hash@com.app","","","",""` + "\r\n",
+	}
+	for name, w := range want {
+		p, err := GetPasswordProfile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err = p.Write(&buf, "Chrome", logins); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != w {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, buf.String(), w)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := (bitwardenJson{}).Write(&buf, "Chrome", logins); err != nil {
+		t.Fatal(err)
+	}
+	var export bitwardenExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Items) != 2 || export.Items[0].Login.Password != `p,"1"` || export.Items[0].FolderID != export.Folders[0].ID {
+		t.Errorf("got %+v", export)
+	}
+
+	buf.Reset()
+	if err := (keePassXml{}).Write(&buf, "Chrome", logins); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, `<Value ProtectInMemory="True">&lt;&amp;&gt;</Value>`) || !strings.Contains(s, "<CreationTime>2023-11-14T22:13:20Z</CreationTime>") {
+		t.Errorf("got %s", s)
+	}
+
+	firefox, _ := GetPasswordProfile(PasswordProfileFirefox)
+	filename, err := WritePasswordProfile(firefox, "Chrome", t.TempDir(), []Login{
+		{LoginUrl: "https://b.test/admin", UserName: "ann", HttpRealm: "Admin Area"},
+		{LoginUrl: "https://never.test/", Blacklisted: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(string(b), "\r\n"); len(lines) != 3 || lines[1] != `"https://b.test","ann","","Admin Area","","","","",""` {
+		t.Errorf("got %q", lines)
+	}
+
+	if _, err := GetPasswordProfile("lastpass"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	f.n--
+	return len(p), nil
+}
+
+func TestFirefoxPasswordCsvWriteError(t *testing.T) {
+	logins := []Login{{LoginUrl: "https://a.test/", UserName: "me", Password: "pw"}}
+	for n := 0; n < 2; n++ {
+		if err := (firefoxPasswordCsv{}).Write(&failingWriter{n: n}, "Firefox", logins); err == nil {
+			t.Errorf("write %d failed without an error", n)
+		}
+	}
+}
+
+func TestKeePassUUIDs(t *testing.T) {
+	// the same user on the same page, once for the form and once for the http auth realm
+	logins := []Login{
+		{LoginUrl: "https://a.test/", SignonRealm: "https://a.test/", UserName: "me"},
+		{LoginUrl: "https://a.test/", SignonRealm: "https://a.test/ Admin", HttpRealm: "Admin", UserName: "me"},
+		// the same user on the same firefox origin with two forms
+		{LoginUrl: "https://b.test", ActionUrl: "https://b.test/login", SignonRealm: "https://b.test/", UserName: "me"},
+		{LoginUrl: "https://b.test", ActionUrl: "https://b.test/admin", SignonRealm: "https://b.test/", UserName: "me"},
+	}
+	var buf bytes.Buffer
+	if err := (keePassXml{}).Write(&buf, "Chrome", logins); err != nil {
+		t.Fatal(err)
+	}
+	var file keePassFile
+	if err := xml.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+	e := file.Group.Entries
+	if len(e) != len(logins) {
+		t.Fatalf("got %d entries, want %d", len(e), len(logins))
+	}
+	seen := make(map[string]bool)
+	for _, v := range e {
+		if seen[v.UUID] {
+			t.Errorf("got entries %+v, want distinct uuids", e)
+		}
+		seen[v.UUID] = true
+	}
+}
@@ -5,6 +5,7 @@ package data
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"fmt"
@@ -16,22 +17,60 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 )
 
-type loginData struct {
-	UserName    string
-	encryptPass []byte
-	encryptUser []byte
-	Password    string
-	LoginUrl    string
-	CreateDate  time.Time
+// Login is a single saved login with its decrypted credentials. LoginUrl is the page
+// or origin the login was saved for, ActionUrl the url the form was submitted to.
+// HttpRealm is only set for HTTP authentication logins. Blacklisted logins are the
+// sites the user chose to never save a password for, they have no credentials.
+type Login struct {
+	UserName        string
+	encryptPass     []byte
+	encryptUser     []byte
+	Password        string
+	LoginUrl        string
+	ActionUrl       string
+	SignonRealm     string
+	HttpRealm       string
+	UsernameElement string
+	PasswordElement string
+	Guid            string
+	TimesUsed       int64
+	Blacklisted     bool
+	CreateDate      time.Time
+	LastUsed        time.Time
+	PasswordChanged time.Time
 }
 
+// chromiumLoginColumns are read when present, date_last_used and date_password_modified
+// came later than the other columns
+var chromiumLoginColumns = []columnDefault{
+	{"origin_url", "''"},
+	{"action_url", "''"},
+	{"signon_realm", "''"},
+	{"username_element", "''"},
+	{"username_value", "''"},
+	{"password_element", "''"},
+	{"password_value", "x''"},
+	{"scheme", "0"},
+	{"times_used", "0"},
+	{"blacklisted_by_user", "0"},
+	{"date_created", "0"},
+	{"date_last_used", "0"},
+	{"date_password_modified", "0"},
+}
+
+// chromiumSchemeHtml is PasswordForm::Scheme of the logins saved from a form, the other
+// schemes are HTTP authentication
+const chromiumSchemeHtml = 0
+
 type passwords struct {
 	mainPath string
 	subPath  string
-	logins   []loginData
+	tempDir  string
+	logins   []Login
 }
 
 func NewFPasswords(main, sub string) Item {
@@ -43,7 +82,11 @@ func NewCPasswords(main, sub string) Item {
 }
 
 func (p *passwords) ChromeParse(key []byte) error {
-	loginDB, err := sql.Open("sqlite3", ChromePasswordFile)
+	return p.ChromeParseContext(context.Background(), key)
+}
+
+func (p *passwords) ChromeParseContext(ctx context.Context, key []byte) error {
+	loginDB, err := sql.Open("sqlite3", filepath.Join(p.tempDir, ChromePasswordFile))
 	if err != nil {
 		return err
 	}
@@ -52,7 +95,11 @@ func (p *passwords) ChromeParse(key []byte) error {
 			logger.Debug(err)
 		}
 	}()
-	rows, err := loginDB.Query(QueryChromiumLogin)
+	columns, err := tableColumns(ctx, loginDB, "logins")
+	if err != nil {
+		return err
+	}
+	rows, err := loginDB.QueryContext(ctx, fmt.Sprintf(QueryChromiumLogin, selectColumns(columns, chromiumLoginColumns)))
 	if err != nil {
 		return err
 	}
@@ -61,42 +108,74 @@ func (p *passwords) ChromeParse(key []byte) error {
 			logger.Debug(err)
 		}
 	}()
+	p.logins = nil
 	for rows.Next() {
 		var (
-			url, username string
-			pwd, password []byte
-			create        int64
+			url, action, realm, userElement, username, pwdElement string
+			pwd, password                                         []byte
+			scheme, timesUsed, blacklisted                        int64
+			create, lastUsed, modified                            int64
 		)
-		err = rows.Scan(&url, &username, &pwd, &create)
+		err = rows.Scan(&url, &action, &realm, &userElement, &username, &pwdElement, &pwd,
+			&scheme, &timesUsed, &blacklisted, &create, &lastUsed, &modified)
 		if err != nil {
 			logger.Error(err)
+			continue
 		}
-		login := loginData{
-			UserName:    username,
-			encryptPass: pwd,
-			LoginUrl:    url,
-		}
-		if key == nil {
-			password, err = decrypt.DPApi(pwd)
-		} else {
-			password, err = decrypt.ChromePass(key, pwd)
+		login := Login{
+			UserName:        username,
+			encryptPass:     pwd,
+			LoginUrl:        url,
+			ActionUrl:       action,
+			SignonRealm:     realm,
+			UsernameElement: userElement,
+			PasswordElement: pwdElement,
+			TimesUsed:       timesUsed,
+			Blacklisted:     blacklisted != 0,
+			CreateDate:      filemgmt.WebKitTime(create),
+			LastUsed:        filemgmt.WebKitTime(lastUsed),
+			PasswordChanged: filemgmt.WebKitTime(modified),
 		}
-		if err != nil {
-			logger.Debugf("%s have empty password %s", login.LoginUrl, err.Error())
+		if scheme != chromiumSchemeHtml {
+			login.HttpRealm = chromiumHttpRealm(realm)
 		}
-		if create > time.Now().Unix() {
-			login.CreateDate = filemgmt.TimeEpochFormat(create)
-		} else {
-			login.CreateDate = filemgmt.TimeStampFormat(create)
+		if len(pwd) > 0 {
+			if key == nil {
+				password, err = decrypt.DPApi(pwd)
+			} else {
+				password, err = decrypt.ChromePass(key, pwd)
+			}
+			if err != nil {
+				logger.Debugf("%s have empty password %s", login.LoginUrl, err.Error())
+			}
 		}
 		login.Password = string(password)
 		p.logins = append(p.logins, login)
 	}
-	return nil
+	return rows.Err()
+}
+
+// chromiumHttpRealm return the realm of an HTTP authentication signon realm, it is the
+// origin followed by the realm, like https://a.test/Restricted Area
+func chromiumHttpRealm(signonRealm string) string {
+	i := strings.Index(signonRealm, "://")
+	if i < 0 {
+		return signonRealm
+	}
+	j := strings.IndexByte(signonRealm[i+3:], '/')
+	if j < 0 {
+		return ""
+	}
+	return signonRealm[i+3+j+1:]
 }
 
 func (p *passwords) FirefoxParse() error {
-	globalSalt, metaBytes, nssA11, nssA102, err := getFirefoxDecryptKey()
+	return p.FirefoxParseContext(context.Background())
+}
+
+func (p *passwords) FirefoxParseContext(ctx context.Context) error {
+	p.logins = nil
+	globalSalt, metaBytes, nssA11, nssA102, err := getFirefoxDecryptKey(ctx, p.tempDir)
 	if err != nil {
 		return err
 	}
@@ -128,7 +207,7 @@ func (p *passwords) FirefoxParse() error {
 				logger.Error("get firefox finally key failed")
 				return err
 			}
-			allLogins, err := getFirefoxLoginData()
+			allLogins, err := getFirefoxLoginData(p.tempDir)
 			if err != nil {
 				return err
 			}
@@ -150,38 +229,45 @@ func (p *passwords) FirefoxParse() error {
 					logger.Error(err)
 				}
 				logger.Debug("decrypt firefox success")
-				p.logins = append(p.logins, loginData{
-					LoginUrl:   v.LoginUrl,
-					UserName:   string(decrypt.PKCS5UnPadding(user)),
-					Password:   string(decrypt.PKCS5UnPadding(pwd)),
-					CreateDate: v.CreateDate,
-				})
+				v.UserName = string(decrypt.PKCS5UnPadding(user))
+				v.Password = string(decrypt.PKCS5UnPadding(pwd))
+				v.encryptUser, v.encryptPass = nil, nil
+				p.logins = append(p.logins, v)
 			}
 		}
 	}
+	neverSave, err := getFirefoxNeverSave(ctx, p.tempDir)
+	if err != nil {
+		logger.Debug(err)
+	}
+	p.logins = append(p.logins, neverSave...)
 	return nil
 }
 
+// Records return all the parsed logins
+func (p *passwords) Records() []Login {
+	return p.logins
+}
+
+// CopyDB copy the login files, firefox keeps the never saved sites in permissions.sqlite
 func (p *passwords) CopyDB() error {
-	err := CopyToLocalPath(p.mainPath, filepath.Base(p.mainPath))
-	if err != nil {
-		logger.Error(err)
-	}
+	var perm string
 	if p.subPath != "" {
-		err = CopyToLocalPath(p.subPath, filepath.Base(p.subPath))
+		perm = filepath.Join(filepath.Dir(p.mainPath), FirefoxPermFile)
+		if _, err := os.Stat(perm); err != nil {
+			perm = ""
+		}
 	}
-	return err
+	dir, err := copyToTempDir(p.mainPath, p.subPath, perm)
+	if err != nil {
+		return err
+	}
+	p.tempDir = dir
+	return nil
 }
 
 func (p *passwords) Release() error {
-	err := os.Remove(filepath.Base(p.mainPath))
-	if err != nil {
-		logger.Error(err)
-	}
-	if p.subPath != "" {
-		err = os.Remove(filepath.Base(p.subPath))
-	}
-	return err
+	return releaseTempDir(p.tempDir)
 }
 
 func (p *passwords) OutPut(format OutputFormat, browser, dir string) error {
@@ -193,6 +279,8 @@ func (p *passwords) OutPut(format OutputFormat, browser, dir string) error {
 	case formatConsole:
 		p.outPutConsole()
 		return nil
+	case formatJsonLines:
+		return p.outPutJsonLines(browser, dir)
 	default:
 		err := p.outPutJson(browser, dir)
 		return err
@@ -200,7 +288,7 @@ func (p *passwords) OutPut(format OutputFormat, browser, dir string) error {
 }
 
 func (p *passwords) outPutCsv(browser, dir string) error {
-	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword, GetFormatName(formatJson))
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword, GetFormatName(formatCSV))
 	if err := WriteToCsv(filename, p.logins); err != nil {
 		return err
 	}
@@ -209,7 +297,7 @@ func (p *passwords) outPutCsv(browser, dir string) error {
 }
 
 func (p *passwords) outPutJson(browser, dir string) error {
-	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword, GetFormatName(formatCSV))
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword, GetFormatName(formatJson))
 	err := WriteToJson(filename, p.logins)
 	if err != nil {
 		return err
@@ -218,6 +306,31 @@ func (p *passwords) outPutJson(browser, dir string) error {
 	return nil
 }
 
+func (p *passwords) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePassword, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, p.logins); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d passwords, filename is %s \n", filemgmt.Prefix, len(p.logins), filename)
+	return nil
+}
+
+// OutPutProfile write the logins in the import layout of a password manager, see
+// PasswordProfileNames
+func (p *passwords) OutPutProfile(profile, browser, dir string) error {
+	sort.Sort(p)
+	pp, err := GetPasswordProfile(profile)
+	if err != nil {
+		return err
+	}
+	filename, err := WritePasswordProfile(pp, browser, dir, p.logins)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d passwords, filename is %s \n", filemgmt.Prefix, len(p.logins), filename)
+	return nil
+}
+
 func (p *passwords) outPutConsole() {
 	for _, v := range p.logins {
 		fmt.Printf("%+v\n", v)
@@ -237,13 +350,13 @@ func (p passwords) Swap(i, j int) {
 }
 
 // getFirefoxDecryptKey get value from key4.db
-func getFirefoxDecryptKey() (item1, item2, a11, a102 []byte, err error) {
+func getFirefoxDecryptKey(ctx context.Context, dir string) (item1, item2, a11, a102 []byte, err error) {
 	var (
 		keyDB   *sql.DB
 		pwdRows *sql.Rows
 		nssRows *sql.Rows
 	)
-	keyDB, err = sql.Open("sqlite3", FirefoxKey4File)
+	keyDB, err = sql.Open("sqlite3", filepath.Join(dir, FirefoxKey4File))
 	if err != nil {
 		logger.Error(err)
 		return nil, nil, nil, nil, err
@@ -254,7 +367,10 @@ func getFirefoxDecryptKey() (item1, item2, a11, a102 []byte, err error) {
 		}
 	}()
 
-	pwdRows, err = keyDB.Query(QueryMetaData)
+	pwdRows, err = keyDB.QueryContext(ctx, QueryMetaData)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 	defer func() {
 		if err := pwdRows.Close(); err != nil {
 			logger.Debug(err)
@@ -266,10 +382,10 @@ func getFirefoxDecryptKey() (item1, item2, a11, a102 []byte, err error) {
 			continue
 		}
 	}
+	nssRows, err = keyDB.QueryContext(ctx, QueryNssPrivate)
 	if err != nil {
-		logger.Error(err)
+		return nil, nil, nil, nil, err
 	}
-	nssRows, err = keyDB.Query(QueryNssPrivate)
 	defer func() {
 		if err := nssRows.Close(); err != nil {
 			logger.Debug(err)
@@ -283,9 +399,11 @@ func getFirefoxDecryptKey() (item1, item2, a11, a102 []byte, err error) {
 	return item1, item2, a11, a102, nil
 }
 
-// getFirefoxLoginData used to get firefox
-func getFirefoxLoginData() (l []loginData, err error) {
-	s, err := ioutil.ReadFile(FirefoxLoginFile)
+// getFirefoxLoginData used to get firefox, the hostname is the origin of the login and
+// formSubmitURL is empty for the HTTP authentication logins that have an httpRealm. The
+// signon realm is built like the one of chromium, the origin followed by / and the realm.
+func getFirefoxLoginData(dir string) (l []Login, err error) {
+	s, err := ioutil.ReadFile(filepath.Join(dir, FirefoxLoginFile))
 	if err != nil {
 		return nil, err
 	}
@@ -293,11 +411,23 @@ func getFirefoxLoginData() (l []loginData, err error) {
 	if h.Exists() {
 		for _, v := range h.Array() {
 			var (
-				m loginData
+				m Login
 				u []byte
 				p []byte
 			)
-			m.LoginUrl = v.Get("formSubmitURL").String()
+			m.LoginUrl = v.Get("hostname").String()
+			m.ActionUrl = v.Get("formSubmitURL").String()
+			m.HttpRealm = v.Get("httpRealm").String()
+			if m.LoginUrl != "" {
+				m.SignonRealm = m.LoginUrl + "/" + m.HttpRealm
+			}
+			m.UsernameElement = v.Get("usernameField").String()
+			m.PasswordElement = v.Get("passwordField").String()
+			m.Guid = v.Get("guid").String()
+			m.TimesUsed = v.Get("timesUsed").Int()
+			if m.LoginUrl == "" {
+				m.LoginUrl = m.ActionUrl
+			}
 			u, err = base64.StdEncoding.DecodeString(v.Get("encryptedUsername").String())
 			m.encryptUser = u
 			if err != nil {
@@ -305,9 +435,56 @@ func getFirefoxLoginData() (l []loginData, err error) {
 			}
 			p, err = base64.StdEncoding.DecodeString(v.Get("encryptedPassword").String())
 			m.encryptPass = p
-			m.CreateDate = filemgmt.TimeStampFormat(v.Get("timeCreated").Int() / 1000)
+			m.CreateDate = filemgmt.UnixMilliTime(v.Get("timeCreated").Int())
+			m.LastUsed = filemgmt.UnixMilliTime(v.Get("timeLastUsed").Int())
+			m.PasswordChanged = filemgmt.UnixMilliTime(v.Get("timePasswordChanged").Int())
 			l = append(l, m)
 		}
 	}
 	return
 }
+
+// getFirefoxNeverSave return the sites with a denied login-saving permission as
+// blacklisted logins, like the never saved sites of chromium
+func getFirefoxNeverSave(ctx context.Context, dir string) ([]Login, error) {
+	path := filepath.Join(dir, FirefoxPermFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	permDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := permDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := permDB.QueryContext(ctx, QueryFirefoxNeverSave)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var logins []Login
+	for rows.Next() {
+		var (
+			origin   string
+			modified int64
+		)
+		if err = rows.Scan(&origin, &modified); err != nil {
+			logger.Debug(err)
+			continue
+		}
+		logins = append(logins, Login{
+			LoginUrl:    origin,
+			SignonRealm: origin + "/",
+			Blacklisted: true,
+			CreateDate:  filemgmt.UnixMilliTime(modified),
+		})
+	}
+	return logins, rows.Err()
+}
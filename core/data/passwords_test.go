@@ -0,0 +1,73 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChromiumLoginsOldSchema(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, ChromePasswordFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the schema before date_last_used and date_password_modified
+	_, err = db.Exec(`CREATE TABLE logins (origin_url VARCHAR NOT NULL, action_url VARCHAR, username_element VARCHAR,
+		username_value VARCHAR, password_element VARCHAR, password_value BLOB, signon_realm VARCHAR NOT NULL,
+		date_created INTEGER NOT NULL, blacklisted_by_user INTEGER NOT NULL, scheme INTEGER NOT NULL, times_used INTEGER);
+		INSERT INTO logins VALUES ('https://a.test/', NULL, NULL, '', NULL, x'', 'https://a.test/', 13300000000000000, 1, 0, 0);
+		INSERT INTO logins VALUES ('https://b.test/admin', '', '', 'ann', '', x'', 'https://b.test/Admin Area', 13300000000000000, 0, 1, 3);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &passwords{tempDir: dir}
+	if err = p.ChromeParse([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	logins := p.Records()
+	if len(logins) != 2 {
+		t.Fatalf("got %d logins, want 2", len(logins))
+	}
+	if l := logins[0]; !l.Blacklisted || l.LoginUrl != "https://a.test/" || l.HttpRealm != "" {
+		t.Errorf("got %+v", l)
+	}
+	if l := logins[1]; l.Blacklisted || l.HttpRealm != "Admin Area" || l.TimesUsed != 3 || !l.LastUsed.IsZero() || l.CreateDate.IsZero() {
+		t.Errorf("got %+v", l)
+	}
+}
+
+func TestFirefoxLoginData(t *testing.T) {
+	dir := t.TempDir()
+	logins := `{"nextId":3,"logins":[
+		{"id":1,"hostname":"https://a.test","httpRealm":null,"formSubmitURL":"https://a.test/login","usernameField":"user",
+		 "passwordField":"pass","encryptedUsername":"","encryptedPassword":"","guid":"{g1}","encType":1,
+		 "timeCreated":1700000000000,"timeLastUsed":1700000100000,"timePasswordChanged":1700000050000,"timesUsed":4},
+		{"id":2,"hostname":"https://b.test","httpRealm":"Admin Area","formSubmitURL":null,"usernameField":"","passwordField":"",
+		 "encryptedUsername":"","encryptedPassword":"","guid":"{g2}","encType":1,"timeCreated":1700000000000}]}`
+	if err := os.WriteFile(filepath.Join(dir, FirefoxLoginFile), []byte(logins), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := getFirefoxLoginData(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 {
+		t.Fatalf("got %d logins, want 2", len(l))
+	}
+	if v := l[0]; v.LoginUrl != "https://a.test" || v.SignonRealm != "https://a.test/" || v.ActionUrl != "https://a.test/login" || v.UsernameElement != "user" ||
+		v.Guid != "{g1}" || v.TimesUsed != 4 || v.LastUsed.UnixMilli() != 1700000100000 || v.PasswordChanged.UnixMilli() != 1700000050000 {
+		t.Errorf("got %+v", v)
+	}
+	if v := l[1]; v.HttpRealm != "Admin Area" || v.SignonRealm != "https://b.test/Admin Area" || v.ActionUrl != "" {
+		t.Errorf("got %+v", v)
+	}
+}
@@ -0,0 +1,271 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	PermissionAllow   = "allow"
+	PermissionBlock   = "block"
+	PermissionAsk     = "ask"
+	PermissionSession = "session"
+	PermissionDetect  = "detect"
+
+	// firefox expireType of a permission that ends at expireTime
+	firefoxPermExpireTime = 2
+)
+
+// chromiumPermissionNames and firefoxPermissionNames give the common permissions the
+// same name, other content settings keep the name of the browser
+var (
+	chromiumPermissionNames = map[string]string{
+		"media_stream_camera": "camera",
+		"media_stream_mic":    "microphone",
+		"notifications":       "notifications",
+		"geolocation":         "geolocation",
+		"clipboard":           "clipboard",
+		"popups":              "popups",
+		"cookies":             "cookies",
+		"autoplay":            "autoplay",
+		"durable_storage":     "persistent-storage",
+		"midi_sysex":          "midi",
+	}
+	firefoxPermissionNames = map[string]string{
+		"camera":               "camera",
+		"microphone":           "microphone",
+		"desktop-notification": "notifications",
+		"geo":                  "geolocation",
+		"popup":                "popups",
+		"cookie":               "cookies",
+		"autoplay-media":       "autoplay",
+		"persistent-storage":   "persistent-storage",
+		"midi-sysex":           "midi",
+	}
+	// ContentSetting of chromium
+	chromiumPermissionSettings = map[int64]string{
+		1: PermissionAllow,
+		2: PermissionBlock,
+		3: PermissionAsk,
+		4: PermissionSession,
+		5: PermissionDetect,
+	}
+	// nsIPermissionManager actions of firefox, 8 allows cookies for the session
+	firefoxPermissionSettings = map[int64]string{
+		1: PermissionAllow,
+		2: PermissionBlock,
+		3: PermissionAsk,
+		8: PermissionSession,
+	}
+)
+
+// Permission is a per site grant, Origin is the pattern the browser saved, chromium
+// patterns can hold wildcards like https://[*.]example.com
+type Permission struct {
+	Origin       string
+	Permission   string
+	Setting      string
+	Expires      time.Time
+	LastModified time.Time
+}
+
+type permissions struct {
+	mainPath    string
+	tempDir     string
+	permissions []Permission
+}
+
+func NewPermissions(main, sub string) Item {
+	return &permissions{mainPath: main}
+}
+
+func (p *permissions) ChromeParse(key []byte) error {
+	return p.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read profile.content_settings.exceptions of the Preferences, the
+// settings that are not a plain ContentSetting, like site engagement scores, are skipped
+func (p *permissions) ChromeParseContext(ctx context.Context, key []byte) error {
+	prefs, err := os.ReadFile(filepath.Join(p.tempDir, ChromePrefsFile))
+	if err != nil {
+		return err
+	}
+	p.permissions = nil
+	exceptions := gjson.GetBytes(prefs, "profile.content_settings.exceptions")
+	exceptions.ForEach(func(kind, sites gjson.Result) bool {
+		name := kind.String()
+		if common, ok := chromiumPermissionNames[name]; ok {
+			name = common
+		}
+		sites.ForEach(func(pattern, exception gjson.Result) bool {
+			setting := exception.Get("setting")
+			if setting.Type != gjson.Number {
+				return true
+			}
+			p.permissions = append(p.permissions, Permission{
+				Origin:       chromiumPermissionOrigin(pattern.String()),
+				Permission:   name,
+				Setting:      permissionSetting(chromiumPermissionSettings, setting.Int()),
+				Expires:      filemgmt.WebKitTime(exception.Get("expiration").Int()),
+				LastModified: filemgmt.WebKitTime(exception.Get("last_modified").Int()),
+			})
+			return true
+		})
+		return ctx.Err() == nil
+	})
+	return ctx.Err()
+}
+
+// chromiumPermissionOrigin drop the secondary pattern when it matches every site
+func chromiumPermissionOrigin(pattern string) string {
+	primary, secondary, ok := strings.Cut(pattern, ",")
+	if !ok || secondary == "*" {
+		return primary
+	}
+	return pattern
+}
+
+func permissionSetting(settings map[int64]string, setting int64) string {
+	if name, ok := settings[setting]; ok {
+		return name
+	}
+	return fmt.Sprint(setting)
+}
+
+func (p *permissions) FirefoxParse() error {
+	return p.FirefoxParseContext(context.Background())
+}
+
+func (p *permissions) FirefoxParseContext(ctx context.Context) error {
+	permDB, err := sql.Open("sqlite3", filepath.Join(p.tempDir, FirefoxPermFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := permDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := permDB.QueryContext(ctx, QueryFirefoxPermissions)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	p.permissions = nil
+	for rows.Next() {
+		var (
+			origin, kind         string
+			setting, expireType  int64
+			expireTime, modified int64
+		)
+		if err = rows.Scan(&origin, &kind, &setting, &expireType, &expireTime, &modified); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		if common, ok := firefoxPermissionNames[kind]; ok {
+			kind = common
+		}
+		permission := Permission{
+			Origin:       origin,
+			Permission:   kind,
+			Setting:      permissionSetting(firefoxPermissionSettings, setting),
+			LastModified: filemgmt.UnixMilliTime(modified),
+		}
+		if expireType == firefoxPermExpireTime {
+			permission.Expires = filemgmt.UnixMilliTime(expireTime)
+		}
+		p.permissions = append(p.permissions, permission)
+	}
+	return rows.Err()
+}
+
+// Records return all the parsed permissions
+func (p *permissions) Records() []Permission {
+	return p.permissions
+}
+
+func (p *permissions) CopyDB() error {
+	dir, err := copyToTempDir(p.mainPath)
+	if err != nil {
+		return err
+	}
+	p.tempDir = dir
+	return nil
+}
+
+func (p *permissions) Release() error {
+	return releaseTempDir(p.tempDir)
+}
+
+func (p *permissions) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(p.permissions, func(i, j int) bool {
+		if p.permissions[i].Origin != p.permissions[j].Origin {
+			return p.permissions[i].Origin < p.permissions[j].Origin
+		}
+		return p.permissions[i].Permission < p.permissions[j].Permission
+	})
+	switch format {
+	case formatCSV:
+		err := p.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		p.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return p.outPutJsonLines(browser, dir)
+	default:
+		err := p.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (p *permissions) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePermission, GetFormatName(formatJson))
+	err := WriteToJson(filename, p.permissions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d permissions, filename is %s \n", filemgmt.Prefix, len(p.permissions), filename)
+	return nil
+}
+
+func (p *permissions) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePermission, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, p.permissions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d permissions, filename is %s \n", filemgmt.Prefix, len(p.permissions), filename)
+	return nil
+}
+
+func (p *permissions) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePermission, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, p.permissions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d permissions, filename is %s \n", filemgmt.Prefix, len(p.permissions), filename)
+	return nil
+}
+
+func (p *permissions) outPutConsole() {
+	for _, v := range p.permissions {
+		fmt.Printf("%+v\n", v)
+	}
+}
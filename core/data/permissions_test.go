@@ -0,0 +1,65 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChromiumPermissions(t *testing.T) {
+	dir := t.TempDir()
+	// chromium saves the times as strings, engagement scores are objects and not settings
+	writeTestFile(t, filepath.Join(dir, ChromePrefsFile), `{"profile":{"content_settings":{"exceptions":{
+		"media_stream_camera":{"https://a.test:443,*":{"setting":1,"last_modified":"13300000000000000","expiration":"0"}},
+		"notifications":{"https://[*.]b.test,https://c.test":{"setting":2,"expiration":"13400000000000000"}},
+		"site_engagement":{"https://a.test:443,*":{"setting":{"rawScore":3.5}}},
+		"unknown_kind":{"https://d.test,*":{"setting":9}}}}}}`)
+	p := &permissions{tempDir: dir}
+	if err := p.ChromeParse(nil); err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Permission)
+	for _, v := range p.Records() {
+		byName[v.Permission] = v
+	}
+	if len(byName) != 3 {
+		t.Fatalf("got %+v, want the 3 numeric settings", p.Records())
+	}
+	if v := byName["camera"]; v.Origin != "https://a.test:443" || v.Setting != PermissionAllow || v.LastModified.IsZero() || !v.Expires.IsZero() {
+		t.Errorf("got %+v", v)
+	}
+	if v := byName["notifications"]; v.Origin != "https://[*.]b.test,https://c.test" || v.Setting != PermissionBlock || v.Expires.IsZero() || !v.LastModified.IsZero() {
+		t.Errorf("got %+v", v)
+	}
+	if v := byName["unknown_kind"]; v.Origin != "https://d.test" || v.Setting != "9" {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestFirefoxPermissions(t *testing.T) {
+	dir := t.TempDir()
+	createDB(t, filepath.Join(dir, FirefoxPermFile), `CREATE TABLE moz_perms (id INTEGER PRIMARY KEY, origin TEXT, type TEXT,
+		permission INTEGER, expireType INTEGER, expireTime INTEGER, modificationTime INTEGER);
+		INSERT INTO moz_perms VALUES (1, 'https://a.test', 'desktop-notification', 1, 0, 0, 1700000000000);
+		INSERT INTO moz_perms VALUES (2, 'https://b.test', 'cookie', 8, 2, 1800000000000, NULL);
+		INSERT INTO moz_perms VALUES (3, 'https://c.test', 'login-saving', 2, 0, 0, 1700000000000);`)
+	p := &permissions{tempDir: dir}
+	if err := p.FirefoxParse(); err != nil {
+		t.Fatal(err)
+	}
+	r := p.Records()
+	if len(r) != 3 {
+		t.Fatalf("got %d permissions, want 3", len(r))
+	}
+	if v := r[0]; v.Permission != "notifications" || v.Setting != PermissionAllow || v.LastModified.UnixMilli() != 1700000000000 || !v.Expires.IsZero() {
+		t.Errorf("got %+v", v)
+	}
+	if v := r[1]; v.Permission != "cookies" || v.Setting != PermissionSession || v.Expires.UnixMilli() != 1800000000000 || !v.LastModified.IsZero() {
+		t.Errorf("got %+v", v)
+	}
+	if v := r[2]; v.Permission != "login-saving" || v.Setting != PermissionBlock {
+		t.Errorf("got %+v", v)
+	}
+}
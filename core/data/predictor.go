@@ -0,0 +1,149 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// Prediction is a row of the chromium network action predictor, the omnibox text typed
+// and how often navigating to Url followed it
+type Prediction struct {
+	UserText       string
+	Url            string
+	NumberOfHits   int
+	NumberOfMisses int
+}
+
+type predictor struct {
+	mainPath    string
+	tempDir     string
+	predictions []Prediction
+}
+
+func NewPredictor(main, sub string) Item {
+	return &predictor{mainPath: main}
+}
+
+func (p *predictor) FirefoxParse() error {
+	return p.FirefoxParseContext(context.Background())
+}
+
+func (p *predictor) FirefoxParseContext(ctx context.Context) error {
+	return nil // Firefox has no network action predictor
+}
+
+func (p *predictor) ChromeParse(key []byte) error {
+	return p.ChromeParseContext(context.Background(), key)
+}
+
+func (p *predictor) ChromeParseContext(ctx context.Context, key []byte) error {
+	predictorDB, err := sql.Open("sqlite3", filepath.Join(p.tempDir, ChromePredictFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := predictorDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := predictorDB.QueryContext(ctx, QueryChromiumPredictor)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	p.predictions = nil
+	for rows.Next() {
+		var prediction Prediction
+		err = rows.Scan(&prediction.UserText, &prediction.Url, &prediction.NumberOfHits, &prediction.NumberOfMisses)
+		if err != nil {
+			logger.Warn(err)
+			continue
+		}
+		p.predictions = append(p.predictions, prediction)
+	}
+	return rows.Err()
+}
+
+// Records return all the parsed predictions
+func (p *predictor) Records() []Prediction {
+	return p.predictions
+}
+
+func (p *predictor) CopyDB() error {
+	dir, err := copyToTempDir(p.mainPath)
+	if err != nil {
+		return err
+	}
+	p.tempDir = dir
+	return nil
+}
+
+func (p *predictor) Release() error {
+	return releaseTempDir(p.tempDir)
+}
+
+func (p *predictor) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(p.predictions, func(i, j int) bool {
+		return p.predictions[i].NumberOfHits > p.predictions[j].NumberOfHits
+	})
+	switch format {
+	case formatCSV:
+		err := p.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		p.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return p.outPutJsonLines(browser, dir)
+	default:
+		err := p.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (p *predictor) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePredictor, GetFormatName(formatJson))
+	err := WriteToJson(filename, p.predictions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d predictions, filename is %s \n", filemgmt.Prefix, len(p.predictions), filename)
+	return nil
+}
+
+func (p *predictor) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePredictor, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, p.predictions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d predictions, filename is %s \n", filemgmt.Prefix, len(p.predictions), filename)
+	return nil
+}
+
+func (p *predictor) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNamePredictor, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, p.predictions); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d predictions, filename is %s \n", filemgmt.Prefix, len(p.predictions), filename)
+	return nil
+}
+
+func (p *predictor) outPutConsole() {
+	for _, v := range p.predictions {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -0,0 +1,34 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+
+//go:build !windows
+// +build !windows
+
+package data
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// fileLocked report if another process holds a write lock on the file
+func fileLocked(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	lock := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: 0}
+	if err = syscall.FcntlFlock(f.Fd(), syscall.F_GETLK, &lock); err != nil {
+		logger.Debug(err)
+		return false
+	}
+	return lock.Type != syscall.F_UNLCK
+}
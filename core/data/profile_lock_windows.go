@@ -0,0 +1,11 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+
+package data
+
+// fileLocked is only used for the fcntl lock files, Windows browsers delete their lock
+// files on close so the file being there is enough
+func fileLocked(path string) bool {
+	return true
+}
@@ -0,0 +1,232 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+// chromiumKeywordColumns are read when present, the keywords table grew over the years
+var chromiumKeywordColumns = []columnDefault{
+	{"short_name", "''"},
+	{"keyword", "''"},
+	{"url", "''"},
+	{"favicon_url", "''"},
+	{"usage_count", "0"},
+	{"prepopulate_id", "0"},
+	{"created_by_policy", "0"},
+	{"is_active", "0"},
+	{"date_created", "0"},
+	{"last_modified", "0"},
+	{"last_visited", "0"},
+}
+
+// chromiumKeywordInactive is the is_active value of a search engine the user turned off
+const chromiumKeywordInactive = 2
+
+// SearchEngine is a configured search engine, Url is the query template with {searchTerms}.
+// IsDefault is only known for firefox, chromium keeps its default in the Preferences.
+type SearchEngine struct {
+	Name         string
+	Keyword      string
+	Url          string
+	FaviconUrl   string
+	UsageCount   int
+	IsDefault    bool
+	IsBuiltIn    bool
+	IsPolicy     bool
+	IsHidden     bool
+	DateCreated  time.Time
+	DateModified time.Time
+	LastVisited  time.Time
+}
+
+type searchEngines struct {
+	mainPath string
+	tempDir  string
+	engines  []SearchEngine
+}
+
+func NewSearchEngines(main, sub string) Item {
+	return &searchEngines{mainPath: main}
+}
+
+func (s *searchEngines) ChromeParse(key []byte) error {
+	return s.ChromeParseContext(context.Background(), key)
+}
+
+func (s *searchEngines) ChromeParseContext(ctx context.Context, key []byte) error {
+	webDB, err := sql.Open("sqlite3", filepath.Join(s.tempDir, ChromeWebDataFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := webDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	columns, err := tableColumns(ctx, webDB, "keywords")
+	if err != nil {
+		return err
+	}
+	rows, err := webDB.QueryContext(ctx, fmt.Sprintf(QueryChromiumKeywords, selectColumns(columns, chromiumKeywordColumns)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	s.engines = nil
+	for rows.Next() {
+		var (
+			name, keyword, url, favicon          string
+			usage, prepopulate, byPolicy, active int
+			created, modified, lastVisited       int64
+		)
+		err = rows.Scan(&name, &keyword, &url, &favicon, &usage, &prepopulate, &byPolicy, &active,
+			&created, &modified, &lastVisited)
+		if err != nil {
+			logger.Warn(err)
+			continue
+		}
+		s.engines = append(s.engines, SearchEngine{
+			Name:         name,
+			Keyword:      keyword,
+			Url:          url,
+			FaviconUrl:   favicon,
+			UsageCount:   usage,
+			IsBuiltIn:    prepopulate > 0,
+			IsPolicy:     filemgmt.IntToBool(byPolicy),
+			IsHidden:     active == chromiumKeywordInactive,
+			DateCreated:  filemgmt.UnixTime(created),
+			DateModified: filemgmt.UnixTime(modified),
+			LastVisited:  filemgmt.WebKitTime(lastVisited),
+		})
+	}
+	return rows.Err()
+}
+
+func (s *searchEngines) FirefoxParse() error {
+	return s.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the engines of the lz4 compressed search.json.mozlz4
+func (s *searchEngines) FirefoxParseContext(ctx context.Context) error {
+	src, err := os.ReadFile(filepath.Join(s.tempDir, FirefoxSearchFile))
+	if err != nil {
+		return err
+	}
+	search, err := DecodeMozLz4(src)
+	if err != nil {
+		return err
+	}
+	r := gjson.ParseBytes(search)
+	defaultID := r.Get("metaData.defaultEngineId").String()
+	// before the engine ids the default was saved by name
+	defaultName := r.Get("metaData.current").String()
+	s.engines = nil
+	for _, v := range r.Get("engines").Array() {
+		engine := SearchEngine{
+			Name:       v.Get("_name").String(),
+			Keyword:    v.Get("_metaData.alias").String(),
+			FaviconUrl: v.Get("_iconURL").String(),
+			IsBuiltIn:  v.Get("_isAppProvided").Bool() || v.Get("_isBuiltin").Bool(),
+			IsPolicy:   v.Get("_loadPath").String() == "[policy]",
+			IsHidden:   v.Get("_metaData.hidden").Bool(),
+		}
+		if engine.Keyword == "" {
+			engine.Keyword = v.Get("_definedAliases.0").String()
+		}
+		id := v.Get("id").String()
+		engine.IsDefault = (defaultID != "" && id == defaultID) || (defaultName != "" && engine.Name == defaultName)
+		for _, u := range v.Get("_urls").Array() {
+			urlType := u.Get("type").String()
+			if urlType == "" || urlType == "text/html" {
+				engine.Url = u.Get("template").String()
+				break
+			}
+		}
+		s.engines = append(s.engines, engine)
+	}
+	return ctx.Err()
+}
+
+// Records return all the parsed search engines
+func (s *searchEngines) Records() []SearchEngine {
+	return s.engines
+}
+
+func (s *searchEngines) CopyDB() error {
+	dir, err := copyToTempDir(s.mainPath)
+	if err != nil {
+		return err
+	}
+	s.tempDir = dir
+	return nil
+}
+
+func (s *searchEngines) Release() error {
+	return releaseTempDir(s.tempDir)
+}
+
+func (s *searchEngines) OutPut(format OutputFormat, browser, dir string) error {
+	switch format {
+	case formatCSV:
+		err := s.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		s.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return s.outPutJsonLines(browser, dir)
+	default:
+		err := s.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (s *searchEngines) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSearch, GetFormatName(formatJson))
+	err := WriteToJson(filename, s.engines)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d search engines, filename is %s \n", filemgmt.Prefix, len(s.engines), filename)
+	return nil
+}
+
+func (s *searchEngines) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSearch, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, s.engines); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d search engines, filename is %s \n", filemgmt.Prefix, len(s.engines), filename)
+	return nil
+}
+
+func (s *searchEngines) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSearch, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, s.engines); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d search engines, filename is %s \n", filemgmt.Prefix, len(s.engines), filename)
+	return nil
+}
+
+func (s *searchEngines) outPutConsole() {
+	for _, v := range s.engines {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -0,0 +1,598 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// sessionHistorySep joins the navigation urls of a tab, csv can't hold a list
+	sessionHistorySep = " -> "
+
+	chromiumSessionPrefix = "Session_"
+	chromiumTabsPrefix    = "Tabs_"
+
+	// SessionService commands of the Session_* files
+	snssSetTabWindow            = 0
+	snssSetTabIndexInWindow     = 2
+	snssPrunedFromBack          = 5
+	snssUpdateTabNavigation     = 6
+	snssSetSelectedNavigation   = 7
+	snssSetSelectedTabInIndex   = 8
+	snssPrunedFromFront         = 11
+	snssSetPinnedState          = 12
+	snssTabClosed               = 16
+	snssWindowClosed            = 17
+	snssLastActiveTime          = 21
+	snssTabNavigationPathPruned = 24
+
+	// TabRestoreService commands of the Tabs_* files
+	snssRestoreUpdateNavigation   = 1
+	snssRestoreRestoredEntry      = 2
+	snssRestoreSelectedNavigation = 4
+	snssRestorePinnedState        = 5
+)
+
+var snssMagic = []byte("SNSS")
+
+// firefoxSessionFiles are the session files of the temp dir, sessionstore is written at
+// shutdown, recovery is the running session and previous the one before it
+var firefoxSessionFiles = []string{
+	"sessionstore.jsonlz4",
+	filepath.Join(FirefoxSessionDir, "recovery.jsonlz4"),
+	filepath.Join(FirefoxSessionDir, "previous.jsonlz4"),
+}
+
+// SessionTab is a tab of a saved session, Window and Index place it in its window and
+// History holds the urls of its navigations. Closed tabs come from the recently closed
+// list, LastActive is their close time.
+type SessionTab struct {
+	Source     string
+	Window     int
+	Index      int
+	Selected   bool
+	Pinned     bool
+	Closed     bool
+	Url        string
+	Title      string
+	History    string
+	LastActive time.Time
+}
+
+type sessions struct {
+	mainPath string
+	tempDir  string
+	tabs     []SessionTab
+}
+
+func NewSessions(main, sub string) Item {
+	return &sessions{mainPath: main}
+}
+
+func (s *sessions) ChromeParse(key []byte) error {
+	return s.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the Session_* and Tabs_* command files of the Sessions directory
+func (s *sessions) ChromeParseContext(ctx context.Context, key []byte) error {
+	dir := filepath.Join(s.tempDir, filepath.Base(s.mainPath))
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	s.tabs = nil
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := f.Name()
+		if !strings.HasPrefix(name, chromiumSessionPrefix) && !strings.HasPrefix(name, chromiumTabsPrefix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			logger.Debug(err)
+			continue
+		}
+		commands, err := readSNSS(content)
+		if err != nil {
+			logger.Debugf("%s: %s", name, err)
+			continue
+		}
+		if strings.HasPrefix(name, chromiumSessionPrefix) {
+			s.tabs = append(s.tabs, chromiumSession(name, commands)...)
+		} else {
+			s.tabs = append(s.tabs, chromiumClosedTabs(name, commands)...)
+		}
+	}
+	return nil
+}
+
+type snssCommand struct {
+	id      byte
+	payload []byte
+}
+
+// readSNSS split a session file in its commands, each one is a size, an id and a payload.
+// A command cut by a crash ends the file.
+func readSNSS(content []byte) ([]snssCommand, error) {
+	if len(content) < 8 || !bytes.Equal(content[:4], snssMagic) {
+		return nil, errors.New("snss: bad magic")
+	}
+	// versions 2 and 4 are encrypted
+	if version := binary.LittleEndian.Uint32(content[4:]); version != 1 && version != 3 {
+		return nil, fmt.Errorf("snss: unsupported version %d", version)
+	}
+	var commands []snssCommand
+	for p := content[8:]; len(p) >= 2; {
+		size := int(binary.LittleEndian.Uint16(p))
+		p = p[2:]
+		if size == 0 || size > len(p) {
+			break
+		}
+		commands = append(commands, snssCommand{id: p[0], payload: p[1:size]})
+		p = p[size:]
+	}
+	return commands, nil
+}
+
+// snssInts read the int32 fields of a fixed size command payload
+func snssInts(payload []byte, n int) ([]int32, bool) {
+	if len(payload) < 4*n {
+		return nil, false
+	}
+	ints := make([]int32, n)
+	for i := range ints {
+		ints[i] = int32(binary.LittleEndian.Uint32(payload[4*i:]))
+	}
+	return ints, true
+}
+
+// snssTime read the int64 after the id of a payload, the struct pads it to 8 bytes
+func snssTime(payload []byte) (time.Time, bool) {
+	if len(payload) < 16 {
+		return time.Time{}, false
+	}
+	return filemgmt.WebKitTime(int64(binary.LittleEndian.Uint64(payload[8:]))), true
+}
+
+// pickle read the fields of a base::Pickle, fields are aligned to 4 bytes after a
+// payload size header
+type pickle struct {
+	b   []byte
+	pos int
+}
+
+func newPickle(b []byte) *pickle {
+	return &pickle{b: b, pos: 4}
+}
+
+func (p *pickle) readBytes(n int) ([]byte, bool) {
+	if n < 0 || n > len(p.b)-p.pos {
+		return nil, false
+	}
+	b := p.b[p.pos : p.pos+n]
+	p.pos += (n + 3) &^ 3
+	return b, true
+}
+
+func (p *pickle) readInt() (int32, bool) {
+	b, ok := p.readBytes(4)
+	if !ok {
+		return 0, false
+	}
+	return int32(binary.LittleEndian.Uint32(b)), true
+}
+
+func (p *pickle) readInt64() (int64, bool) {
+	b, ok := p.readBytes(8)
+	if !ok {
+		return 0, false
+	}
+	return int64(binary.LittleEndian.Uint64(b)), true
+}
+
+func (p *pickle) readString() (string, bool) {
+	n, ok := p.readInt()
+	if !ok {
+		return "", false
+	}
+	b, ok := p.readBytes(int(n))
+	return string(b), ok
+}
+
+func (p *pickle) readString16() (string, bool) {
+	n, ok := p.readInt()
+	if !ok {
+		return "", false
+	}
+	b, ok := p.readBytes(2 * int(n))
+	return decodeUTF16(b), ok
+}
+
+type sessionNavigation struct {
+	url   string
+	title string
+}
+
+type sessionTabState struct {
+	id          int32
+	window      int32
+	index       int32
+	selected    int32
+	pinned      bool
+	closed      bool
+	lastActive  time.Time
+	navigations map[int32]sessionNavigation
+}
+
+// readNavigation read the tab id, the navigation index, the url and the title that start
+// a serialized navigation
+func readNavigation(payload []byte) (int32, int32, sessionNavigation, bool) {
+	p := newPickle(payload)
+	id, ok := p.readInt()
+	if !ok {
+		return 0, 0, sessionNavigation{}, false
+	}
+	index, ok := p.readInt()
+	if !ok {
+		return 0, 0, sessionNavigation{}, false
+	}
+	var nav sessionNavigation
+	if nav.url, ok = p.readString(); !ok {
+		return 0, 0, sessionNavigation{}, false
+	}
+	nav.title, _ = p.readString16()
+	return id, index, nav, true
+}
+
+// chromiumSession replay the commands of a Session_* file, closed tabs and windows are gone
+func chromiumSession(source string, commands []snssCommand) []SessionTab {
+	tabs := make(map[int32]*sessionTabState)
+	tab := func(id int32) *sessionTabState {
+		if t, ok := tabs[id]; ok {
+			return t
+		}
+		t := &sessionTabState{id: id, selected: -1, navigations: make(map[int32]sessionNavigation)}
+		tabs[id] = t
+		return t
+	}
+	selectedTabs := make(map[int32]int32)
+	closedWindows := make(map[int32]bool)
+	for _, c := range commands {
+		switch c.id {
+		case snssSetTabWindow:
+			if v, ok := snssInts(c.payload, 2); ok {
+				tab(v[1]).window = v[0]
+			}
+		case snssSetTabIndexInWindow:
+			if v, ok := snssInts(c.payload, 2); ok {
+				tab(v[0]).index = v[1]
+			}
+		case snssUpdateTabNavigation:
+			if id, index, nav, ok := readNavigation(c.payload); ok {
+				tab(id).navigations[index] = nav
+			}
+		case snssSetSelectedNavigation:
+			if v, ok := snssInts(c.payload, 2); ok {
+				tab(v[0]).selected = v[1]
+			}
+		case snssSetSelectedTabInIndex:
+			if v, ok := snssInts(c.payload, 2); ok {
+				selectedTabs[v[0]] = v[1]
+			}
+		case snssSetPinnedState:
+			if len(c.payload) >= 5 {
+				tab(int32(binary.LittleEndian.Uint32(c.payload))).pinned = c.payload[4] != 0
+			}
+		case snssTabClosed:
+			if v, ok := snssInts(c.payload, 1); ok {
+				tab(v[0]).closed = true
+			}
+		case snssWindowClosed:
+			if v, ok := snssInts(c.payload, 1); ok {
+				closedWindows[v[0]] = true
+			}
+		case snssLastActiveTime:
+			if v, ok := snssInts(c.payload, 1); ok {
+				if t, ok := snssTime(c.payload); ok {
+					tab(v[0]).lastActive = t
+				}
+			}
+		case snssPrunedFromBack:
+			// navigations from index on are removed
+			if v, ok := snssInts(c.payload, 2); ok {
+				pruneNavigations(tab(v[0]), v[1], -1)
+			}
+		case snssPrunedFromFront:
+			if v, ok := snssInts(c.payload, 2); ok {
+				pruneNavigations(tab(v[0]), 0, v[1])
+			}
+		case snssTabNavigationPathPruned:
+			if v, ok := snssInts(c.payload, 3); ok {
+				pruneNavigations(tab(v[0]), v[1], v[2])
+			}
+		}
+	}
+	var list []SessionTab
+	for _, t := range tabs {
+		if t.closed || closedWindows[t.window] || len(t.navigations) == 0 {
+			continue
+		}
+		entry := sessionTab(source, t)
+		if selected, ok := selectedTabs[t.window]; ok {
+			entry.Selected = selected == t.index
+		}
+		list = append(list, entry)
+	}
+	sortSessionTabs(list)
+	return list
+}
+
+// pruneNavigations remove count navigations from index and shift the ones after them,
+// a negative count removes everything from index on
+func pruneNavigations(t *sessionTabState, index, count int32) {
+	pruned := make(map[int32]sessionNavigation)
+	for i, nav := range t.navigations {
+		switch {
+		case i < index:
+			pruned[i] = nav
+		case count >= 0 && i >= index+count:
+			pruned[i-count] = nav
+		}
+	}
+	t.navigations = pruned
+	if count >= 0 && t.selected >= index+count {
+		t.selected -= count
+	}
+}
+
+// chromiumClosedTabs replay the commands of a Tabs_* file, the recently closed tabs
+func chromiumClosedTabs(source string, commands []snssCommand) []SessionTab {
+	tabs := make(map[int32]*sessionTabState)
+	tab := func(id int32) *sessionTabState {
+		if t, ok := tabs[id]; ok {
+			return t
+		}
+		t := &sessionTabState{id: id, selected: -1, closed: true, navigations: make(map[int32]sessionNavigation)}
+		tabs[id] = t
+		return t
+	}
+	for _, c := range commands {
+		switch c.id {
+		case snssRestoreUpdateNavigation:
+			if id, index, nav, ok := readNavigation(c.payload); ok {
+				tab(id).navigations[index] = nav
+			}
+		case snssRestoreSelectedNavigation:
+			// the id, the selected navigation and the close time
+			if v, ok := snssInts(c.payload, 2); ok {
+				t := tab(v[0])
+				t.selected = v[1]
+				if closed, ok := snssTime(c.payload); ok {
+					t.lastActive = closed
+				}
+			}
+		case snssRestorePinnedState:
+			if len(c.payload) >= 5 {
+				tab(int32(binary.LittleEndian.Uint32(c.payload))).pinned = c.payload[4] != 0
+			}
+		case snssRestoreRestoredEntry:
+			// the tab was reopened
+			if v, ok := snssInts(c.payload, 1); ok {
+				delete(tabs, v[0])
+			}
+		}
+	}
+	var list []SessionTab
+	for _, t := range tabs {
+		if len(t.navigations) > 0 {
+			list = append(list, sessionTab(source, t))
+		}
+	}
+	sortSessionTabs(list)
+	return list
+}
+
+func sessionTab(source string, t *sessionTabState) SessionTab {
+	indexes := make([]int32, 0, len(t.navigations))
+	for i := range t.navigations {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+	history := make([]string, len(indexes))
+	for i, index := range indexes {
+		history[i] = t.navigations[index].url
+	}
+	current, ok := t.navigations[t.selected]
+	if !ok {
+		current = t.navigations[indexes[len(indexes)-1]]
+	}
+	return SessionTab{
+		Source:     source,
+		Window:     int(t.window),
+		Index:      int(t.index),
+		Pinned:     t.pinned,
+		Closed:     t.closed,
+		Url:        current.url,
+		Title:      current.title,
+		History:    strings.Join(history, sessionHistorySep),
+		LastActive: t.lastActive,
+	}
+}
+
+func sortSessionTabs(tabs []SessionTab) {
+	sort.SliceStable(tabs, func(i, j int) bool {
+		if tabs[i].Window != tabs[j].Window {
+			return tabs[i].Window < tabs[j].Window
+		}
+		return tabs[i].Index < tabs[j].Index
+	})
+}
+
+func (s *sessions) FirefoxParse() error {
+	return s.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the mozlz4 session files, the windows and closed windows with
+// their tabs and closed tabs
+func (s *sessions) FirefoxParseContext(ctx context.Context) error {
+	s.tabs = nil
+	for _, name := range firefoxSessionFiles {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		content, err := os.ReadFile(filepath.Join(s.tempDir, name))
+		if err != nil {
+			continue
+		}
+		session, err := DecodeMozLz4(content)
+		if err != nil {
+			logger.Debugf("%s: %s", name, err)
+			continue
+		}
+		s.tabs = append(s.tabs, firefoxSession(filepath.Base(name), gjson.ParseBytes(session))...)
+	}
+	return nil
+}
+
+func firefoxSession(source string, session gjson.Result) []SessionTab {
+	var tabs []SessionTab
+	windows := session.Get("windows").Array()
+	closedWindows := session.Get("_closedWindows").Array()
+	for w, window := range append(windows, closedWindows...) {
+		windowClosed := w >= len(windows)
+		selected := int(window.Get("selected").Int()) - 1
+		for i, tab := range window.Get("tabs").Array() {
+			t := firefoxSessionTab(source, w, i, tab)
+			t.Selected = i == selected
+			t.Closed = windowClosed
+			tabs = append(tabs, t)
+		}
+		for i, closed := range window.Get("_closedTabs").Array() {
+			t := firefoxSessionTab(source, w, i, closed.Get("state"))
+			t.Closed = true
+			t.LastActive = filemgmt.UnixMilliTime(closed.Get("closedAt").Int())
+			tabs = append(tabs, t)
+		}
+	}
+	return tabs
+}
+
+// firefoxSessionTab read a tab state, index is the 1 based current entry
+func firefoxSessionTab(source string, window, index int, tab gjson.Result) SessionTab {
+	entries := tab.Get("entries").Array()
+	history := make([]string, len(entries))
+	for i, e := range entries {
+		history[i] = e.Get("url").String()
+	}
+	t := SessionTab{
+		Source:     source,
+		Window:     window,
+		Index:      index,
+		Pinned:     tab.Get("pinned").Bool(),
+		History:    strings.Join(history, sessionHistorySep),
+		LastActive: filemgmt.UnixMilliTime(tab.Get("lastAccessed").Int()),
+	}
+	current := int(tab.Get("index").Int()) - 1
+	if current < 0 || current >= len(entries) {
+		current = len(entries) - 1
+	}
+	if current >= 0 {
+		t.Url = entries[current].Get("url").String()
+		t.Title = entries[current].Get("title").String()
+	}
+	return t
+}
+
+// Records return all the parsed session tabs
+func (s *sessions) Records() []SessionTab {
+	return s.tabs
+}
+
+// CopyDB copy the chromium Sessions directory, or the firefox sessionstore-backups
+// directory and the sessionstore.jsonlz4 written at shutdown
+func (s *sessions) CopyDB() error {
+	dir, err := copyDirsToTempDir(s.mainPath)
+	if err != nil {
+		return err
+	}
+	s.tempDir = dir
+	if filepath.Base(s.mainPath) != FirefoxSessionDir {
+		return nil
+	}
+	shutdown := filepath.Join(filepath.Dir(s.mainPath), firefoxSessionFiles[0])
+	if _, err := os.Stat(shutdown); err != nil {
+		return nil
+	}
+	return copyWithWal(shutdown, filepath.Join(dir, firefoxSessionFiles[0]))
+}
+
+func (s *sessions) Release() error {
+	return releaseTempDir(s.tempDir)
+}
+
+func (s *sessions) OutPut(format OutputFormat, browser, dir string) error {
+	switch format {
+	case formatCSV:
+		err := s.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		s.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return s.outPutJsonLines(browser, dir)
+	default:
+		err := s.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (s *sessions) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSession, GetFormatName(formatJson))
+	err := WriteToJson(filename, s.tabs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d session tabs, filename is %s \n", filemgmt.Prefix, len(s.tabs), filename)
+	return nil
+}
+
+func (s *sessions) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSession, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, s.tabs); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d session tabs, filename is %s \n", filemgmt.Prefix, len(s.tabs), filename)
+	return nil
+}
+
+func (s *sessions) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSession, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, s.tabs); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d session tabs, filename is %s \n", filemgmt.Prefix, len(s.tabs), filename)
+	return nil
+}
+
+func (s *sessions) outPutConsole() {
+	for _, v := range s.tabs {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -0,0 +1,99 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func snssFile(commands ...snssCommand) []byte {
+	file := append([]byte{}, snssMagic...)
+	file = binary.LittleEndian.AppendUint32(file, 1)
+	for _, c := range commands {
+		file = binary.LittleEndian.AppendUint16(file, uint16(len(c.payload)+1))
+		file = append(file, c.id)
+		file = append(file, c.payload...)
+	}
+	return file
+}
+
+func snssStruct(ints ...int32) []byte {
+	var b []byte
+	for _, v := range ints {
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return b
+}
+
+func snssNavigation(tab, index int32, url, title string) []byte {
+	field := func(b []byte, data []byte, length int) []byte {
+		b = binary.LittleEndian.AppendUint32(b, uint32(length))
+		b = append(b, data...)
+		return append(b, make([]byte, (4-len(data)%4)%4)...)
+	}
+	body := snssStruct(tab, index)
+	body = field(body, []byte(url), len(url))
+	body = field(body, []byte(utf16Bytes(title)), len(title))
+	return append(snssStruct(int32(len(body))), body...)
+}
+
+func TestChromiumSession(t *testing.T) {
+	file := snssFile(
+		snssCommand{snssSetTabWindow, snssStruct(1, 10)},
+		snssCommand{snssSetTabIndexInWindow, snssStruct(10, 0)},
+		snssCommand{snssUpdateTabNavigation, snssNavigation(10, 0, "https://a.test/", "A")},
+		snssCommand{snssUpdateTabNavigation, snssNavigation(10, 1, "https://a.test/next", "Next")},
+		snssCommand{snssUpdateTabNavigation, snssNavigation(10, 2, "https://a.test/last", "Last")},
+		snssCommand{snssSetSelectedNavigation, snssStruct(10, 1)},
+		// the forward history is dropped after navigating back
+		snssCommand{snssTabNavigationPathPruned, snssStruct(10, 2, 1)},
+		snssCommand{snssSetPinnedState, []byte{10, 0, 0, 0, 1, 0, 0, 0}},
+		snssCommand{snssSetTabWindow, snssStruct(1, 11)},
+		snssCommand{snssSetTabIndexInWindow, snssStruct(11, 1)},
+		snssCommand{snssUpdateTabNavigation, snssNavigation(11, 0, "https://b.test/", "B")},
+		snssCommand{snssSetSelectedTabInIndex, snssStruct(1, 1)},
+		snssCommand{snssSetTabWindow, snssStruct(1, 12)},
+		snssCommand{snssUpdateTabNavigation, snssNavigation(12, 0, "https://closed.test/", "C")},
+		snssCommand{snssTabClosed, snssStruct(12, 0, 0, 0)},
+	)
+	commands, err := readSNSS(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tabs := chromiumSession("Session_1", commands)
+	if len(tabs) != 2 {
+		t.Fatalf("got %d tabs %+v, want 2", len(tabs), tabs)
+	}
+	first := tabs[0]
+	if first.Url != "https://a.test/next" || first.Title != "Next" || !first.Pinned || first.Selected ||
+		first.History != "https://a.test/ -> https://a.test/next" {
+		t.Errorf("got %+v", first)
+	}
+	if second := tabs[1]; second.Url != "https://b.test/" || second.Index != 1 || !second.Selected {
+		t.Errorf("got %+v", second)
+	}
+}
+
+func TestFirefoxSession(t *testing.T) {
+	session := gjson.Parse(`{"windows":[{"selected":2,"tabs":[
+		{"entries":[{"url":"https://a.test/","title":"A"},{"url":"https://a.test/b","title":"B"}],"index":1,"lastAccessed":1600000000000},
+		{"entries":[{"url":"https://c.test/","title":"C"}],"index":1,"pinned":true}],
+		"_closedTabs":[{"state":{"entries":[{"url":"https://d.test/"}],"index":1},"closedAt":1600000000000}]}]}`)
+	tabs := firefoxSession("recovery.jsonlz4", session)
+	if len(tabs) != 3 {
+		t.Fatalf("got %d tabs %+v, want 3", len(tabs), tabs)
+	}
+	if tabs[0].Url != "https://a.test/" || tabs[0].History != "https://a.test/ -> https://a.test/b" || tabs[0].LastActive.IsZero() {
+		t.Errorf("got %+v", tabs[0])
+	}
+	if !tabs[1].Selected || !tabs[1].Pinned {
+		t.Errorf("got %+v", tabs[1])
+	}
+	if !tabs[2].Closed || tabs[2].Url != "https://d.test/" {
+		t.Errorf("got %+v", tabs[2])
+	}
+}
@@ -0,0 +1,364 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	SummaryHomepage      = "homepage"
+	SummaryDefaultSearch = "default-search"
+	SummaryProxy         = "proxy"
+	SummaryDownloadDir   = "download-dir"
+	SummarySyncAccount   = "sync-account"
+	SummaryPolicy        = "policy"
+
+	settingsPolicyDir = "policies"
+)
+
+// settingSummaries are the settings most asked about when a profile misbehaves, keys
+// ending with a dot match every setting under them
+var settingSummaries = []struct {
+	summary string
+	key     string
+}{
+	{SummaryHomepage, "homepage"},
+	{SummaryHomepage, "homepage_is_newtabpage"},
+	{SummaryHomepage, "session.restore_on_startup"},
+	{SummaryHomepage, "session.startup_urls"},
+	{SummaryHomepage, "browser.startup.homepage"},
+	{SummaryHomepage, "browser.startup.page"},
+	{SummaryDefaultSearch, "default_search_provider.name"},
+	{SummaryDefaultSearch, "default_search_provider.search_url"},
+	{SummaryDefaultSearch, "default_search_provider_data.template_url_data.short_name"},
+	{SummaryDefaultSearch, "default_search_provider_data.template_url_data.url"},
+	{SummaryDefaultSearch, "browser.search.defaultenginename"},
+	{SummaryDefaultSearch, "browser.urlbar.placeholderName"},
+	{SummaryProxy, "proxy."},
+	{SummaryProxy, "network.proxy."},
+	{SummaryDownloadDir, "download.default_directory"},
+	{SummaryDownloadDir, "download.prompt_for_download"},
+	{SummaryDownloadDir, "savefile.default_directory"},
+	{SummaryDownloadDir, "browser.download.dir"},
+	{SummaryDownloadDir, "browser.download.lastDir"},
+	{SummaryDownloadDir, "browser.download.folderList"},
+	{SummaryDownloadDir, "browser.download.useDownloadDir"},
+	{SummarySyncAccount, "account_info"},
+	{SummarySyncAccount, "google.services.last_username"},
+	{SummarySyncAccount, "google.services.last_signed_in_username"},
+	{SummarySyncAccount, "services.sync.username"},
+}
+
+// settingsDenyList are the settings holding key material or tokens, they are never
+// exported. Keys ending with a dot match every setting under them.
+var settingsDenyList = []string{
+	// the key encrypting the cookies and passwords, app bound or not
+	"os_crypt.",
+	// the macs that protect the Secure Preferences
+	"protection.",
+	// the nigori keys of chrome sync
+	"sync.encryption_bootstrap_token",
+	"sync.keystore_encryption_bootstrap_token",
+	"sync.encryption_bootstrap_token_per_account.",
+	// the salt of the media device ids given to the sites
+	"media.device_id_salt",
+	"media.media_storage_id_salt",
+}
+
+// chromiumPolicyDirs are the linux policy directories of the chromium browsers, by the
+// name of their user data directory. Windows and macOS keep the policies in the
+// registry and in plists, they are not read.
+var chromiumPolicyDirs = map[string]string{
+	"google-chrome":          "/etc/opt/chrome/policies",
+	"google-chrome-beta":     "/etc/opt/chrome/policies",
+	"google-chrome-unstable": "/etc/opt/chrome/policies",
+	"chromium":               "/etc/chromium/policies",
+	"microsoft-edge":         "/etc/opt/edge/policies",
+	"Brave-Browser":          "/etc/brave/policies",
+}
+
+// firefoxPolicyFiles are the usual places of the policies.json of firefox
+var firefoxPolicyFiles = []string{
+	"/etc/firefox/policies/policies.json",
+	"/usr/lib/firefox/distribution/policies.json",
+	"/Applications/Firefox.app/Contents/Resources/distribution/policies.json",
+	"C:/Program Files/Mozilla Firefox/distribution/policies.json",
+}
+
+// Setting is a single preference, Source is the file it was read from. Summary names
+// the curated settings, like the homepage or the proxy, and every managed policy.
+type Setting struct {
+	Source  string
+	Key     string
+	Value   string
+	Summary string
+}
+
+type settings struct {
+	mainPath    string
+	tempDir     string
+	policyFiles []string
+	settings    []Setting
+}
+
+func NewSettings(main, sub string) Item {
+	return &settings{mainPath: main}
+}
+
+func (s *settings) ChromeParse(key []byte) error {
+	return s.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext flatten the Preferences, Secure Preferences and Local State JSON
+// files into dotted keys, arrays are kept as JSON values
+func (s *settings) ChromeParseContext(ctx context.Context, key []byte) error {
+	s.settings = nil
+	for _, name := range []string{ChromePrefsFile, ChromeSecPrefsFile, ChromeLocalState} {
+		prefs, err := os.ReadFile(filepath.Join(s.tempDir, name))
+		if err != nil {
+			if name != ChromePrefsFile && os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		s.addJson(name, "", gjson.ParseBytes(prefs))
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return s.parsePolicies()
+}
+
+func (s *settings) FirefoxParse() error {
+	return s.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the user_pref() calls of prefs.js and user.js, user.js is
+// applied over prefs.js when firefox starts
+func (s *settings) FirefoxParseContext(ctx context.Context) error {
+	s.settings = nil
+	for _, name := range []string{FirefoxPrefsFile, FirefoxUserJsFile} {
+		src, err := os.ReadFile(filepath.Join(s.tempDir, name))
+		if err != nil {
+			if name != FirefoxPrefsFile && os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		prefs, err := parsePrefsJs(src)
+		if err != nil {
+			logger.Warnf("%s: %v", name, err)
+		}
+		for _, p := range prefs {
+			s.add(name, p.Name, p.Value)
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return s.parsePolicies()
+}
+
+func (s *settings) parsePolicies() error {
+	for i, source := range s.policyFiles {
+		policies, err := os.ReadFile(filepath.Join(s.tempDir, settingsPolicyDir, fmt.Sprint(i)))
+		if err != nil {
+			return err
+		}
+		start := len(s.settings)
+		s.addJson(source, "", gjson.ParseBytes(policies))
+		for j := start; j < len(s.settings); j++ {
+			s.settings[j].Summary = SummaryPolicy
+		}
+	}
+	return nil
+}
+
+// addJson add the leaves of a JSON object under their dotted keys
+func (s *settings) addJson(source, prefix string, value gjson.Result) {
+	if !value.IsObject() || len(value.Map()) == 0 {
+		if value.Type == gjson.String {
+			s.add(source, prefix, value.String())
+		} else {
+			s.add(source, prefix, value.Raw)
+		}
+		return
+	}
+	value.ForEach(func(key, child gjson.Result) bool {
+		name := key.String()
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		s.addJson(source, name, child)
+		return true
+	})
+}
+
+func (s *settings) add(source, key, value string) {
+	if matchSettingKey(settingsDenyList, key) {
+		return
+	}
+	s.settings = append(s.settings, Setting{Source: source, Key: key, Value: value, Summary: settingSummary(key)})
+}
+
+func settingSummary(key string) string {
+	for _, v := range settingSummaries {
+		if matchSettingKey([]string{v.key}, key) {
+			return v.summary
+		}
+	}
+	return ""
+}
+
+// matchSettingKey reports if key is one of the keys, or under one ending with a dot
+func matchSettingKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if key == k || strings.HasSuffix(k, ".") && strings.HasPrefix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Records return all the parsed settings
+func (s *settings) Records() []Setting {
+	return s.settings
+}
+
+// Summary return the curated settings and the policies only
+func (s *settings) Summary() []Setting {
+	var summary []Setting
+	for _, v := range s.settings {
+		if v.Summary != "" {
+			summary = append(summary, v)
+		}
+	}
+	return summary
+}
+
+// CopyDB copy the profile files and the policy files found for the browser, chromium
+// keeps Local State in the parent of the profile directory
+func (s *settings) CopyDB() error {
+	var subs, policies []string
+	if filepath.Base(s.mainPath) == ChromePrefsFile {
+		profileDir := filepath.Dir(s.mainPath)
+		subs = []string{
+			filepath.Join(profileDir, ChromeSecPrefsFile),
+			filepath.Join(filepath.Dir(profileDir), ChromeLocalState),
+		}
+		if dir, ok := chromiumPolicyDirs[filepath.Base(filepath.Dir(profileDir))]; ok {
+			for _, level := range []string{"managed", "recommended"} {
+				files, _ := filepath.Glob(filepath.Join(dir, level, "*.json"))
+				policies = append(policies, files...)
+			}
+		}
+	} else {
+		subs = []string{filepath.Join(filepath.Dir(s.mainPath), FirefoxUserJsFile)}
+		policies = firefoxPolicyFiles
+	}
+	for i, sub := range subs {
+		if _, err := os.Stat(sub); err != nil {
+			subs[i] = ""
+		}
+	}
+	dir, err := copyToTempDir(s.mainPath, subs...)
+	if err != nil {
+		return err
+	}
+	s.tempDir = dir
+	s.policyFiles = nil
+	for _, p := range policies {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if err = os.MkdirAll(filepath.Join(dir, settingsPolicyDir), 0700); err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, settingsPolicyDir, fmt.Sprint(len(s.policyFiles)))
+		if err = os.WriteFile(dst, content, 0600); err != nil {
+			return err
+		}
+		s.policyFiles = append(s.policyFiles, p)
+	}
+	return nil
+}
+
+func (s *settings) Release() error {
+	return releaseTempDir(s.tempDir)
+}
+
+// OutPut write the curated settings first
+func (s *settings) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(s.settings, func(i, j int) bool {
+		a, b := s.settings[i], s.settings[j]
+		if (a.Summary == "") != (b.Summary == "") {
+			return a.Summary != ""
+		}
+		if a.Summary != b.Summary {
+			return a.Summary < b.Summary
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Key < b.Key
+	})
+	switch format {
+	case formatCSV:
+		err := s.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		s.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return s.outPutJsonLines(browser, dir)
+	default:
+		err := s.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (s *settings) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSetting, GetFormatName(formatJson))
+	err := WriteToJson(filename, s.settings)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d settings, filename is %s \n", filemgmt.Prefix, len(s.settings), filename)
+	return nil
+}
+
+func (s *settings) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSetting, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, s.settings); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d settings, filename is %s \n", filemgmt.Prefix, len(s.settings), filename)
+	return nil
+}
+
+func (s *settings) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameSetting, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, s.settings); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d settings, filename is %s \n", filemgmt.Prefix, len(s.settings), filename)
+	return nil
+}
+
+func (s *settings) outPutConsole() {
+	for _, v := range s.settings {
+		fmt.Printf("%+v\n", v)
+	}
+}
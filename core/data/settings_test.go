@@ -0,0 +1,37 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChromiumSettingsDenyList(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, ChromePrefsFile), `{"homepage":"https://a.test/","media":{"device_id_salt":"salt"},
+		"sync":{"encryption_bootstrap_token":"token","requested":true},"protection":{"macs":{"homepage":"ABCD"}}}`)
+	writeTestFile(t, filepath.Join(dir, ChromeLocalState), `{"os_crypt":{"encrypted_key":"RFBBUEk=","app_bound_encrypted_key":"QVBQQg=="},
+		"browser":{"enabled_labs_experiments":[]}}`)
+	s := &settings{tempDir: dir}
+	if err := s.ChromeParse(nil); err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]Setting)
+	for _, v := range s.Records() {
+		keys[v.Key] = v
+	}
+	for _, key := range []string{"media.device_id_salt", "sync.encryption_bootstrap_token", "protection.macs.homepage",
+		"os_crypt.encrypted_key", "os_crypt.app_bound_encrypted_key"} {
+		if _, ok := keys[key]; ok {
+			t.Errorf("%s is exported", key)
+		}
+	}
+	if v := keys["homepage"]; v.Value != "https://a.test/" || v.Summary != SummaryHomepage {
+		t.Errorf("got %+v", v)
+	}
+	if len(keys) != 3 {
+		t.Errorf("got %+v, want homepage, sync.requested and the labs", s.Records())
+	}
+}
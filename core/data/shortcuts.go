@@ -0,0 +1,172 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// Shortcut is a chromium omnibox shortcut, the text the user typed and the suggestion
+// picked for it. MatchType is the chromium AutocompleteMatchType of the suggestion.
+type Shortcut struct {
+	Text           string
+	FillIntoEdit   string
+	Url            string
+	Contents       string
+	Description    string
+	Transition     string
+	MatchType      int
+	Keyword        string
+	LastAccessTime time.Time
+	NumberOfHits   int
+}
+
+type shortcuts struct {
+	mainPath  string
+	tempDir   string
+	shortcuts []Shortcut
+}
+
+func NewShortcuts(main, sub string) Item {
+	return &shortcuts{mainPath: main}
+}
+
+func (s *shortcuts) FirefoxParse() error {
+	return s.FirefoxParseContext(context.Background())
+}
+
+func (s *shortcuts) FirefoxParseContext(ctx context.Context) error {
+	return nil // Firefox keeps no omnibox shortcuts
+}
+
+func (s *shortcuts) ChromeParse(key []byte) error {
+	return s.ChromeParseContext(context.Background(), key)
+}
+
+func (s *shortcuts) ChromeParseContext(ctx context.Context, key []byte) error {
+	shortcutDB, err := sql.Open("sqlite3", filepath.Join(s.tempDir, ChromeShortcutFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shortcutDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := shortcutDB.QueryContext(ctx, QueryChromiumShortcuts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	s.shortcuts = nil
+	for rows.Next() {
+		var (
+			text, fill, url, contents, description, keyword string
+			transition, lastAccess                          int64
+			matchType, hits                                 int
+		)
+		err = rows.Scan(&text, &fill, &url, &contents, &description, &transition, &matchType, &keyword, &lastAccess, &hits)
+		if err != nil {
+			logger.Warn(err)
+			continue
+		}
+		core, _ := ChromiumTransition(transition)
+		s.shortcuts = append(s.shortcuts, Shortcut{
+			Text:           text,
+			FillIntoEdit:   fill,
+			Url:            url,
+			Contents:       contents,
+			Description:    description,
+			Transition:     core,
+			MatchType:      matchType,
+			Keyword:        keyword,
+			LastAccessTime: filemgmt.WebKitTime(lastAccess),
+			NumberOfHits:   hits,
+		})
+	}
+	return rows.Err()
+}
+
+// Records return all the parsed shortcuts
+func (s *shortcuts) Records() []Shortcut {
+	return s.shortcuts
+}
+
+func (s *shortcuts) CopyDB() error {
+	dir, err := copyToTempDir(s.mainPath)
+	if err != nil {
+		return err
+	}
+	s.tempDir = dir
+	return nil
+}
+
+func (s *shortcuts) Release() error {
+	return releaseTempDir(s.tempDir)
+}
+
+func (s *shortcuts) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(s.shortcuts, func(i, j int) bool {
+		return s.shortcuts[i].LastAccessTime.After(s.shortcuts[j].LastAccessTime)
+	})
+	switch format {
+	case formatCSV:
+		err := s.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		s.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return s.outPutJsonLines(browser, dir)
+	default:
+		err := s.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (s *shortcuts) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameShortcut, GetFormatName(formatJson))
+	err := WriteToJson(filename, s.shortcuts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d shortcuts, filename is %s \n", filemgmt.Prefix, len(s.shortcuts), filename)
+	return nil
+}
+
+func (s *shortcuts) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameShortcut, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, s.shortcuts); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d shortcuts, filename is %s \n", filemgmt.Prefix, len(s.shortcuts), filename)
+	return nil
+}
+
+func (s *shortcuts) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameShortcut, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, s.shortcuts); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d shortcuts, filename is %s \n", filemgmt.Prefix, len(s.shortcuts), filename)
+	return nil
+}
+
+func (s *shortcuts) outPutConsole() {
+	for _, v := range s.shortcuts {
+		fmt.Printf("%+v\n", v)
+	}
+}
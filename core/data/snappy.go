@@ -0,0 +1,89 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// snappyMaxSize bounds the announced decompressed size of a block
+var snappyMaxSize = 1 << 30
+
+var errSnappyCorrupt = errors.New("snappy: corrupt block")
+
+// decodeSnappy decompress a raw snappy block (not the framed stream format), the block
+// starts with the decompressed size as a varint followed by literal and copy elements
+func decodeSnappy(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, errSnappyCorrupt
+	}
+	if size > uint64(snappyMaxSize) {
+		return nil, fmt.Errorf("snappy: decompressed size %d is too large", size)
+	}
+	dst := make([]byte, 0, size)
+	for i := n; i < len(src); {
+		tag := src[i]
+		i++
+		var length, offset int
+		switch tag & 0x03 {
+		case 0x00:
+			// literal, lengths over 60 are stored in the 1 to 4 next bytes
+			length = int(tag >> 2)
+			if length >= 60 {
+				extra := length - 59
+				if i+extra > len(src) {
+					return nil, errSnappyCorrupt
+				}
+				length = 0
+				for k := extra - 1; k >= 0; k-- {
+					length = length<<8 | int(src[i+k])
+				}
+				i += extra
+			}
+			length++
+			if length <= 0 || i+length > len(src) || len(dst)+length > int(size) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[i:i+length]...)
+			i += length
+			continue
+		case 0x01:
+			if i >= len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xE0)<<3 | int(src[i])
+			i++
+		case 0x02:
+			if i+2 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[i:]))
+			i += 2
+		case 0x03:
+			if i+4 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[i:]))
+			i += 4
+		}
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(size) {
+			return nil, errSnappyCorrupt
+		}
+		// the copy can overlap the bytes it produces, copy one by one
+		start := len(dst) - offset
+		for k := 0; k < length; k++ {
+			dst = append(dst, dst[start+k])
+		}
+	}
+	if len(dst) != int(size) {
+		return nil, fmt.Errorf("snappy: got %d bytes, want %d", len(dst), size)
+	}
+	return dst, nil
+}
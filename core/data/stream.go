@@ -0,0 +1,87 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/jszwec/csvutil"
+)
+
+// HistoryStreamer is implemented by the history item, records are yielded while
+// the rows are scanned instead of being collected first
+type HistoryStreamer interface {
+	ChromeStream(ctx context.Context, fn func(HistoryEntry) error) error
+	FirefoxStream(ctx context.Context, fn func(HistoryEntry) error) error
+}
+
+// CookieStreamer is implemented by the cookie item, chromium values are decrypted with key
+type CookieStreamer interface {
+	ChromeStream(ctx context.Context, key []byte, fn func(Cookie) error) error
+	FirefoxStream(ctx context.Context, fn func(Cookie) error) error
+}
+
+// RecordWriter consume records one at a time, Flush must be called once the stream ends
+type RecordWriter interface {
+	Write(record interface{}) error
+	Flush() error
+}
+
+type jsonLinesWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJsonLinesWriter return a writer that encodes every record as a single JSON line
+func NewJsonLinesWriter(w io.Writer) RecordWriter {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &jsonLinesWriter{w: bw, enc: enc}
+}
+
+func (j *jsonLinesWriter) Write(record interface{}) error {
+	return j.enc.Encode(record)
+}
+
+func (j *jsonLinesWriter) Flush() error {
+	return j.w.Flush()
+}
+
+type csvWriter struct {
+	w       *bufio.Writer
+	cw      *csv.Writer
+	enc     *csvutil.Encoder
+	started bool
+}
+
+// NewCsvWriter return a writer that encodes every record as a CSV row, the header
+// is taken from the first record and the output starts with the same BOM as WriteToCsv
+func NewCsvWriter(w io.Writer) RecordWriter {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	return &csvWriter{w: bw, cw: cw, enc: csvutil.NewEncoder(cw)}
+}
+
+func (c *csvWriter) Write(record interface{}) error {
+	if !c.started {
+		if _, err := c.w.Write(utf8Bom); err != nil {
+			return err
+		}
+		c.started = true
+	}
+	return c.enc.Encode(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.cw.Flush()
+	if err := c.cw.Error(); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
@@ -0,0 +1,165 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const syntheticHistoryRows = 200000
+
+var (
+	syntheticOnce sync.Once
+	syntheticDir  string
+	syntheticErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if syntheticDir != "" {
+		_ = os.RemoveAll(syntheticDir)
+	}
+	os.Exit(code)
+}
+
+// syntheticHistory build a chromium History database once and return its directory
+func syntheticHistory(tb testing.TB) string {
+	syntheticOnce.Do(func() {
+		syntheticDir, syntheticErr = os.MkdirTemp("", "go-cc-history")
+		if syntheticErr != nil {
+			return
+		}
+		syntheticErr = createSyntheticHistory(filepath.Join(syntheticDir, ChromeHistoryFile), syntheticHistoryRows)
+	})
+	if syntheticErr != nil {
+		tb.Fatal(syntheticErr)
+	}
+	return syntheticDir
+}
+
+func createSyntheticHistory(path string, n int) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE urls(id INTEGER PRIMARY KEY AUTOINCREMENT, url LONGVARCHAR, title LONGVARCHAR,
+		visit_count INTEGER DEFAULT 0 NOT NULL, typed_count INTEGER DEFAULT 0 NOT NULL,
+		last_visit_time INTEGER NOT NULL, hidden INTEGER DEFAULT 0 NOT NULL)`)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO urls (url, title, visit_count, last_visit_time) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		_, err = stmt.Exec(fmt.Sprintf("https://example.com/page/%d?q=%d", i, i*7), fmt.Sprintf("Page %d", i), i%97, 13300000000000000+int64(i)*1000000)
+		if err != nil {
+			return err
+		}
+	}
+	if err = stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func TestHistoryChromeStreamCancel(t *testing.T) {
+	dir := syntheticHistory(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &historyData{tempDir: dir}
+	var count int
+	err := h.ChromeStream(ctx, func(entry HistoryEntry) error {
+		count++
+		if count == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ChromeStream() error = %v, want %v", err, context.Canceled)
+	}
+	if count >= syntheticHistoryRows {
+		t.Fatalf("ChromeStream() yielded %d rows after cancel", count)
+	}
+}
+
+func TestHistoryChromeParseSkipsBadRows(t *testing.T) {
+	dir := t.TempDir()
+	createDB(t, filepath.Join(dir, ChromeHistoryFile), `CREATE TABLE urls(id INTEGER PRIMARY KEY, url LONGVARCHAR,
+		title LONGVARCHAR, visit_count INTEGER DEFAULT 0 NOT NULL, last_visit_time INTEGER NOT NULL);
+		INSERT INTO urls VALUES (1, 'https://a.test/', 'A', 2, 13300000000000000);
+		INSERT INTO urls VALUES (2, NULL, 'B', 1, 13300000000000000);`)
+	h := &historyData{tempDir: dir}
+	// a second parse must not keep the entries of the first one
+	for i := 0; i < 2; i++ {
+		if err := h.ChromeParse(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r := h.Records(); len(r) != 1 || r[0].Url != "https://a.test/" {
+		t.Errorf("got %+v, want only the https://a.test/ entry", r)
+	}
+}
+
+func BenchmarkHistoryChromeParse(b *testing.B) {
+	dir := syntheticHistory(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h := &historyData{tempDir: dir}
+		if err := h.ChromeParse(nil); err != nil {
+			b.Fatal(err)
+		}
+		w := NewJsonLinesWriter(io.Discard)
+		for _, v := range h.Records() {
+			if err := w.Write(v); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHistoryChromeStreamJsonLines(b *testing.B) {
+	benchmarkHistoryStream(b, NewJsonLinesWriter(io.Discard))
+}
+
+func BenchmarkHistoryChromeStreamCsv(b *testing.B) {
+	benchmarkHistoryStream(b, NewCsvWriter(io.Discard))
+}
+
+func benchmarkHistoryStream(b *testing.B, w RecordWriter) {
+	dir := syntheticHistory(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h := &historyData{tempDir: dir}
+		err := h.ChromeStream(context.Background(), func(entry HistoryEntry) error {
+			return w.Write(entry)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = w.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+)
+
+// spidermonkey structured clone tags, firefox writes the IndexedDB values with it.
+// Every value starts with a pair of a 32 bits tag and 32 bits of data, a pair whose tag
+// is below scFloatMax is a double.
+const (
+	scFloatMax        = 0xFFF00000
+	scHeader          = 0xFFF10000
+	scNull            = 0xFFFF0000
+	scUndefined       = 0xFFFF0001
+	scBoolean         = 0xFFFF0002
+	scInt32           = 0xFFFF0003
+	scString          = 0xFFFF0004
+	scDateObject      = 0xFFFF0005
+	scRegExpObject    = 0xFFFF0006
+	scArrayObject     = 0xFFFF0007
+	scObjectObject    = 0xFFFF0008
+	scArrayBufferV2   = 0xFFFF0009
+	scBooleanObject   = 0xFFFF000A
+	scStringObject    = 0xFFFF000B
+	scNumberObject    = 0xFFFF000C
+	scBackReference   = 0xFFFF000D
+	scTypedArrayV2    = 0xFFFF0010
+	scMapObject       = 0xFFFF0011
+	scSetObject       = 0xFFFF0012
+	scEndOfKeys       = 0xFFFF0013
+	scDataViewV2      = 0xFFFF0015
+	scBigInt          = 0xFFFF001D
+	scBigIntObject    = 0xFFFF001E
+	scArrayBuffer     = 0xFFFF001F
+	scTypedArray      = 0xFFFF0020
+	scDataView        = 0xFFFF0021
+	scLatin1Flag      = 0x80000000
+	scBigIntSignFlag  = 0x80000000
+	scTypedArrayV1Min = 0xFFFF0100
+	scTypedArrayV1Max = 0xFFFF01FF
+)
+
+var errCloneCorrupt = errors.New("structured clone: corrupt value")
+
+// cloneReader decode a structured clone buffer into json friendly go values, objects
+// are kept in the order they are read to resolve the back references
+type cloneReader struct {
+	b       []byte
+	pos     int
+	objects []interface{}
+}
+
+// decodeStructuredClone decode a value written by the spidermonkey structured clone, the
+// dom objects like blobs and files are not understood
+func decodeStructuredClone(b []byte) (interface{}, error) {
+	r := &cloneReader{b: b}
+	tag, _, err := r.peekPair()
+	if err != nil {
+		return nil, err
+	}
+	if tag == scHeader {
+		r.pos += 8
+	}
+	return r.readValue()
+}
+
+func (r *cloneReader) peekPair() (uint32, uint32, error) {
+	if r.pos+8 > len(r.b) {
+		return 0, 0, errCloneCorrupt
+	}
+	pair := binary.LittleEndian.Uint64(r.b[r.pos:])
+	return uint32(pair >> 32), uint32(pair), nil
+}
+
+func (r *cloneReader) readPair() (uint32, uint32, error) {
+	tag, data, err := r.peekPair()
+	if err == nil {
+		r.pos += 8
+	}
+	return tag, data, err
+}
+
+func (r *cloneReader) readUint64() (uint64, error) {
+	if r.pos+8 > len(r.b) {
+		return 0, errCloneCorrupt
+	}
+	v := binary.LittleEndian.Uint64(r.b[r.pos:])
+	r.pos += 8
+	return v, nil
+}
+
+func (r *cloneReader) readDouble() (float64, error) {
+	v, err := r.readUint64()
+	return math.Float64frombits(v), err
+}
+
+// readBytes read n bytes, the buffer is padded to the next 8 bytes
+func (r *cloneReader) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.b)-r.pos) {
+		return nil, errCloneCorrupt
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int((n + 7) &^ 7)
+	if r.pos > len(r.b) {
+		r.pos = len(r.b)
+	}
+	return b, nil
+}
+
+// readString read the chars of a string, data holds the length and the latin1 flag
+func (r *cloneReader) readString(data uint32) (string, error) {
+	length := uint64(data &^ scLatin1Flag)
+	if data&scLatin1Flag != 0 {
+		b, err := r.readBytes(length)
+		return decodeLatin1(b), err
+	}
+	b, err := r.readBytes(2 * length)
+	return decodeUTF16(b), err
+}
+
+func (r *cloneReader) addObject(v interface{}) int {
+	r.objects = append(r.objects, v)
+	return len(r.objects) - 1
+}
+
+func (r *cloneReader) readValue() (interface{}, error) {
+	tag, data, err := r.readPair()
+	if err != nil {
+		return nil, err
+	}
+	if tag < scFloatMax {
+		return jsNumber(math.Float64frombits(uint64(tag)<<32 | uint64(data))), nil
+	}
+	switch tag {
+	case scNull, scUndefined:
+		return nil, nil
+	case scBoolean:
+		return data != 0, nil
+	case scInt32:
+		return int32(data), nil
+	case scString:
+		return r.readString(data)
+	case scBigInt:
+		return r.readBigInt(data)
+	case scDateObject:
+		v, err := r.readDouble()
+		date := filemgmt.UnixMilliTime(int64(v))
+		r.addObject(date)
+		return date, err
+	case scRegExpObject:
+		stringTag, stringData, err := r.readPair()
+		if err != nil {
+			return nil, err
+		}
+		if stringTag != scString {
+			return nil, errCloneCorrupt
+		}
+		source, err := r.readString(stringData)
+		v := "/" + source + "/" + regExpFlags(uint64(data), "igmyusdv")
+		r.addObject(v)
+		return v, err
+	case scBooleanObject:
+		r.addObject(data != 0)
+		return data != 0, nil
+	case scNumberObject:
+		v, err := r.readDouble()
+		r.addObject(jsNumber(v))
+		return jsNumber(v), err
+	case scStringObject:
+		v, err := r.readString(data)
+		r.addObject(v)
+		return v, err
+	case scBigIntObject:
+		v, err := r.readBigInt(data)
+		r.addObject(v)
+		return v, err
+	case scBackReference:
+		if uint64(data) >= uint64(len(r.objects)) {
+			return nil, errCloneCorrupt
+		}
+		return r.objects[data], nil
+	case scObjectObject:
+		object := make(map[string]interface{})
+		r.addObject(object)
+		return object, r.readProperties(object)
+	case scArrayObject:
+		return r.readArray(data)
+	case scMapObject, scSetObject:
+		return r.readCollection(tag)
+	case scArrayBufferV2:
+		return r.readArrayBuffer(uint64(data))
+	case scArrayBuffer:
+		length, err := r.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return r.readArrayBuffer(length)
+	case scTypedArray, scTypedArrayV2, scDataView, scDataViewV2:
+		return r.readView()
+	}
+	if tag >= scTypedArrayV1Min && tag <= scTypedArrayV1Max {
+		// the oldest typed arrays hold their elements inline, data is the element count
+		size := map[uint32]uint64{0: 1, 1: 1, 2: 2, 3: 2, 4: 4, 5: 4, 6: 4, 7: 8, 8: 1}[tag-scTypedArrayV1Min]
+		v, err := r.readBytes(uint64(data) * size)
+		r.addObject(v)
+		return v, err
+	}
+	return nil, fmt.Errorf("structured clone: unsupported tag 0x%08x", tag)
+}
+
+// readProperties read key value pairs into object until the end of keys
+func (r *cloneReader) readProperties(object map[string]interface{}) error {
+	for {
+		tag, _, err := r.peekPair()
+		if err != nil {
+			return err
+		}
+		if tag == scEndOfKeys {
+			r.pos += 8
+			return nil
+		}
+		key, err := r.readValue()
+		if err != nil {
+			return err
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return err
+		}
+		object[jsKey(key)] = value
+	}
+}
+
+// readArray read an array, its elements are written as index value pairs
+func (r *cloneReader) readArray(length uint32) (interface{}, error) {
+	if uint64(length) > uint64(len(r.b)) {
+		return nil, errCloneCorrupt
+	}
+	array := make([]interface{}, length)
+	r.addObject(array)
+	for {
+		tag, _, err := r.peekPair()
+		if err != nil {
+			return nil, err
+		}
+		if tag == scEndOfKeys {
+			r.pos += 8
+			return array, nil
+		}
+		key, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		// named properties of the array are dropped
+		if i, ok := key.(int32); ok && i >= 0 && int(i) < len(array) {
+			array[i] = value
+		}
+	}
+}
+
+func (r *cloneReader) readCollection(tag uint32) (interface{}, error) {
+	// maps are read as a list of [key, value] pairs, sets as a list
+	var list []interface{}
+	id := r.addObject(list)
+	for {
+		next, _, err := r.peekPair()
+		if err != nil {
+			return nil, err
+		}
+		if next == scEndOfKeys {
+			r.pos += 8
+			r.objects[id] = list
+			return list, nil
+		}
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if tag == scMapObject {
+			value, err := r.readValue()
+			if err != nil {
+				return nil, err
+			}
+			v = []interface{}{v, value}
+		}
+		list = append(list, v)
+	}
+}
+
+func (r *cloneReader) readBigInt(data uint32) (interface{}, error) {
+	length := uint64(data &^ scBigIntSignFlag)
+	if length > uint64(len(r.b)-r.pos)/8 {
+		return nil, errCloneCorrupt
+	}
+	// little endian 64 bits digits
+	v := new(big.Int)
+	for i := uint64(0); i < length; i++ {
+		digit, err := r.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		v.Or(v, new(big.Int).Lsh(new(big.Int).SetUint64(digit), uint(64*i)))
+	}
+	if data&scBigIntSignFlag != 0 {
+		v.Neg(v)
+	}
+	return v.String(), nil
+}
+
+func (r *cloneReader) readArrayBuffer(length uint64) (interface{}, error) {
+	b, err := r.readBytes(length)
+	if err != nil {
+		return nil, err
+	}
+	r.addObject(b)
+	return b, nil
+}
+
+// readView read a typed array or data view, its length and byte offset come before the
+// buffer it looks into
+func (r *cloneReader) readView() (interface{}, error) {
+	id := r.addObject(nil)
+	if _, err := r.readUint64(); err != nil {
+		return nil, err
+	}
+	offset, err := r.readUint64()
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := r.readValue()
+	if err != nil {
+		return nil, err
+	}
+	b, ok := buffer.([]byte)
+	if !ok || offset > uint64(len(b)) {
+		return nil, errCloneCorrupt
+	}
+	r.objects[id] = b[offset:]
+	return b[offset:], nil
+}
@@ -0,0 +1,238 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+const (
+	topSiteRedirectSep = " -> "
+
+	// firefoxTopSites is how many of the most frecent places are kept, about as many
+	// as the new tab page of both browsers can show
+	firefoxTopSites = 20
+)
+
+// TopSite is a site of the new tab page, Rank starts at 0. Old chromium versions saved
+// a Thumbnail of the site, it is the file name once written by WriteImages.
+type TopSite struct {
+	Rank      int
+	Url       string
+	Title     string
+	Redirects string
+	Thumbnail string
+	image     []byte
+}
+
+type topSites struct {
+	mainPath string
+	tempDir  string
+	topSites []TopSite
+}
+
+func NewTopSites(main, sub string) Item {
+	return &topSites{mainPath: main}
+}
+
+func (t *topSites) ChromeParse(key []byte) error {
+	return t.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the top_sites table, the thumbnails table of the versions
+// before 2019 has the same columns plus the thumbnail
+func (t *topSites) ChromeParseContext(ctx context.Context, key []byte) error {
+	topDB, err := sql.Open("sqlite3", filepath.Join(t.tempDir, ChromeTopSitesFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := topDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	table := "top_sites"
+	columns, err := tableColumns(ctx, topDB, table)
+	if err != nil {
+		table = "thumbnails"
+		if columns, err = tableColumns(ctx, topDB, table); err != nil {
+			return err
+		}
+	}
+	query := fmt.Sprintf(QueryChromiumTopSites, selectColumns(columns, chromiumTopSiteColumns), table)
+	rows, err := topDB.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	t.topSites = nil
+	for rows.Next() {
+		var (
+			site      TopSite
+			redirects string
+		)
+		if err = rows.Scan(&site.Url, &site.Rank, &site.Title, &redirects, &site.image); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		site.Redirects = strings.Join(strings.Fields(redirects), topSiteRedirectSep)
+		t.topSites = append(t.topSites, site)
+	}
+	return rows.Err()
+}
+
+var chromiumTopSiteColumns = []columnDefault{
+	{"url", "''"},
+	{"url_rank", "0"},
+	{"title", "''"},
+	{"redirects", "''"},
+	{"thumbnail", "X''"},
+}
+
+func (t *topSites) FirefoxParse() error {
+	return t.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext rank the places by frecency, the new tab page of firefox has no
+// database of its own and builds its top sites the same way
+func (t *topSites) FirefoxParseContext(ctx context.Context) error {
+	placesDB, err := sql.Open("sqlite3", filepath.Join(t.tempDir, FirefoxDataFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := placesDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	rows, err := placesDB.QueryContext(ctx, QueryFirefoxTopSites, firefoxTopSites)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	t.topSites = nil
+	for rows.Next() {
+		site := TopSite{Rank: len(t.topSites)}
+		if err = rows.Scan(&site.Url, &site.Title); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		t.topSites = append(t.topSites, site)
+	}
+	return rows.Err()
+}
+
+// WriteImages save the thumbnails of the top sites
+func (t *topSites) WriteImages(browser, dir string) error {
+	var count int
+	for _, v := range t.topSites {
+		if len(v.image) > 0 {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	imageDir, err := makeItemDir(browser, ItemNameTopSite, dir)
+	if err != nil {
+		return err
+	}
+	for i, v := range t.topSites {
+		if len(v.image) == 0 {
+			continue
+		}
+		if t.topSites[i].Thumbnail, err = writeImage(imageDir, v.image); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s Get %d top site thumbnails, directory is %s \n", filemgmt.Prefix, count, imageDir)
+	return nil
+}
+
+// Records return all the parsed top sites
+func (t *topSites) Records() []TopSite {
+	return t.topSites
+}
+
+func (t *topSites) CopyDB() error {
+	dir, err := copyToTempDir(t.mainPath)
+	if err != nil {
+		return err
+	}
+	t.tempDir = dir
+	return nil
+}
+
+func (t *topSites) Release() error {
+	return releaseTempDir(t.tempDir)
+}
+
+func (t *topSites) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(t.topSites, func(i, j int) bool {
+		return t.topSites[i].Rank < t.topSites[j].Rank
+	})
+	switch format {
+	case formatCSV:
+		err := t.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		t.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return t.outPutJsonLines(browser, dir)
+	default:
+		err := t.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (t *topSites) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameTopSite, GetFormatName(formatJson))
+	err := WriteToJson(filename, t.topSites)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d top sites, filename is %s \n", filemgmt.Prefix, len(t.topSites), filename)
+	return nil
+}
+
+func (t *topSites) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameTopSite, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, t.topSites); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d top sites, filename is %s \n", filemgmt.Prefix, len(t.topSites), filename)
+	return nil
+}
+
+func (t *topSites) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameTopSite, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, t.topSites); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d top sites, filename is %s \n", filemgmt.Prefix, len(t.topSites), filename)
+	return nil
+}
+
+func (t *topSites) outPutConsole() {
+	for _, v := range t.topSites {
+		fmt.Printf("%+v\n", v)
+	}
+}
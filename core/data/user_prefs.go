@@ -0,0 +1,316 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// jsPref is a pref() call of a firefox prefs file, Value is the string, number or bool
+// as text
+type jsPref struct {
+	Function string
+	Name     string
+	Value    string
+}
+
+// prefsJsFunctions are the calls firefox accepts, the first ones are found in the
+// default prefs of the install, profiles use user_pref
+var prefsJsFunctions = map[string]bool{
+	"pref":        true,
+	"sticky_pref": true,
+	"lock_pref":   true,
+	"user_pref":   true,
+}
+
+const (
+	prefsTokenEOF = iota
+	prefsTokenIdent
+	prefsTokenString
+	prefsTokenNumber
+	prefsTokenPunct
+)
+
+type prefsToken struct {
+	kind int
+	text string
+	line int
+}
+
+type prefsLexer struct {
+	src  []byte
+	pos  int
+	line int
+	last prefsToken
+}
+
+// parsePrefsJs tokenize a prefs.js or user.js file the way firefox does, so strings can
+// hold any escape, comments are //, # and /* */ ones, and a bad statement is skipped up
+// to its ; like firefox does. The first syntax error is returned with the parsed prefs.
+func parsePrefsJs(src []byte) ([]jsPref, error) {
+	l := &prefsLexer{src: src, line: 1}
+	var (
+		prefs []jsPref
+		first error
+	)
+	for {
+		p, err := l.pref()
+		if err == io.EOF {
+			return prefs, first
+		}
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			l.skipStatement()
+			continue
+		}
+		prefs = append(prefs, p)
+	}
+}
+
+func (l *prefsLexer) pref() (jsPref, error) {
+	var p jsPref
+	l.last = prefsToken{}
+	t, err := l.next()
+	if err != nil {
+		return p, err
+	}
+	if t.kind == prefsTokenEOF {
+		return p, io.EOF
+	}
+	if t.kind != prefsTokenIdent || !prefsJsFunctions[t.text] {
+		return p, l.errorf(t, "expected pref function, got %q", t.text)
+	}
+	p.Function = t.text
+	if err = l.expect("("); err != nil {
+		return p, err
+	}
+	if t, err = l.next(); err != nil {
+		return p, err
+	}
+	if t.kind != prefsTokenString {
+		return p, l.errorf(t, "expected pref name, got %q", t.text)
+	}
+	p.Name = t.text
+	if err = l.expect(","); err != nil {
+		return p, err
+	}
+	if t, err = l.next(); err != nil {
+		return p, err
+	}
+	switch {
+	case t.kind == prefsTokenString, t.kind == prefsTokenNumber:
+	case t.kind == prefsTokenIdent && (t.text == "true" || t.text == "false"):
+	default:
+		return p, l.errorf(t, "expected pref value, got %q", t.text)
+	}
+	p.Value = t.text
+	// pref() of the default files can end with the sticky and locked attributes
+	for {
+		if t, err = l.next(); err != nil {
+			return p, err
+		}
+		if t.kind != prefsTokenPunct || t.text != "," {
+			break
+		}
+		if t, err = l.next(); err != nil {
+			return p, err
+		}
+		if t.kind != prefsTokenIdent {
+			return p, l.errorf(t, "expected pref attribute, got %q", t.text)
+		}
+	}
+	if t.kind != prefsTokenPunct || t.text != ")" {
+		return p, l.errorf(t, "expected ), got %q", t.text)
+	}
+	return p, l.expect(";")
+}
+
+func (l *prefsLexer) expect(punct string) error {
+	t, err := l.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != prefsTokenPunct || t.text != punct {
+		return l.errorf(t, "expected %s, got %q", punct, t.text)
+	}
+	return nil
+}
+
+func (l *prefsLexer) errorf(t prefsToken, format string, args ...interface{}) error {
+	if t.kind == prefsTokenEOF {
+		return fmt.Errorf("line %d: unexpected end of file", t.line)
+	}
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+// skipStatement drop the tokens up to the next ; bytes the lexer can't read are skipped
+func (l *prefsLexer) skipStatement() {
+	if l.last.kind == prefsTokenPunct && l.last.text == ";" {
+		return
+	}
+	for l.pos < len(l.src) {
+		t, err := l.next()
+		if err != nil {
+			l.pos++
+			continue
+		}
+		if t.kind == prefsTokenEOF || t.kind == prefsTokenPunct && t.text == ";" {
+			return
+		}
+	}
+}
+
+func (l *prefsLexer) next() (prefsToken, error) {
+	if err := l.skipSpace(); err != nil {
+		return prefsToken{}, err
+	}
+	t := prefsToken{line: l.line}
+	if l.pos >= len(l.src) {
+		return t, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		text, err := l.readString(c)
+		if err != nil {
+			return t, err
+		}
+		t.kind, t.text = prefsTokenString, text
+	case c == '-' || c == '+' || c >= '0' && c <= '9':
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+			l.pos++
+		}
+		t.kind, t.text = prefsTokenNumber, strings.TrimPrefix(string(l.src[start:l.pos]), "+")
+		if t.text == "" || t.text == "-" {
+			return t, fmt.Errorf("line %d: bad number", t.line)
+		}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || l.src[l.pos] >= 'a' && l.src[l.pos] <= 'z' ||
+			l.src[l.pos] >= 'A' && l.src[l.pos] <= 'Z' || l.src[l.pos] >= '0' && l.src[l.pos] <= '9') {
+			l.pos++
+		}
+		t.kind, t.text = prefsTokenIdent, string(l.src[start:l.pos])
+	case strings.IndexByte("(),;", c) >= 0:
+		l.pos++
+		t.kind, t.text = prefsTokenPunct, string(c)
+	default:
+		return t, fmt.Errorf("line %d: unexpected character %q", t.line, c)
+	}
+	l.last = t
+	return t, nil
+}
+
+func (l *prefsLexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#' || c == '/' && l.peek(1) == '/':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '/' && l.peek(1) == '*':
+			end := strings.Index(string(l.src[l.pos+2:]), "*/")
+			if end < 0 {
+				l.pos = len(l.src)
+				return fmt.Errorf("line %d: unterminated comment", l.line)
+			}
+			comment := l.src[l.pos : l.pos+2+end+2]
+			l.line += strings.Count(string(comment), "\n")
+			l.pos += len(comment)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *prefsLexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+// readString read a quoted string, \uXXXX escapes can be utf-16 surrogate pairs
+func (l *prefsLexer) readString(quote byte) (string, error) {
+	line := l.line
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\n':
+			l.line++
+			b.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.src) {
+				break
+			}
+			e := l.src[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'x':
+				v, err := l.readHex(2)
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(rune(v))
+			case 'u':
+				v, err := l.readHex(4)
+				if err != nil {
+					return "", err
+				}
+				r := rune(v)
+				if utf16.IsSurrogate(r) && l.peek(0) == '\\' && l.peek(1) == 'u' {
+					l.pos += 2
+					low, err := l.readHex(4)
+					if err != nil {
+						return "", err
+					}
+					r = utf16.DecodeRune(r, rune(low))
+				}
+				b.WriteRune(r)
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated string", line)
+}
+
+func (l *prefsLexer) readHex(n int) (uint64, error) {
+	if l.pos+n > len(l.src) {
+		return 0, fmt.Errorf("line %d: short escape", l.line)
+	}
+	v, err := strconv.ParseUint(string(l.src[l.pos:l.pos+n]), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("line %d: bad escape", l.line)
+	}
+	l.pos += n
+	return v, nil
+}
@@ -0,0 +1,41 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrefsJs(t *testing.T) {
+	src := []byte(`// Mozilla User Preferences
+/* a block
+   comment */
+# hash comment
+user_pref("browser.startup.homepage", "https://a.test/|https://b.test/");
+user_pref("browser.download.folderList", 2);
+user_pref("network.proxy.type", -1);
+user_pref('single', true);
+user_pref("escaped", "q\"\\\n\x41é😀");
+user_pref("broken", );
+pref("default.pref", false, sticky, locked);
+user_pref("after.broken", "ok");
+`)
+	prefs, err := parsePrefsJs(src)
+	if err == nil || err.Error() != `line 10: expected pref value, got ")"` {
+		t.Errorf("got error %v", err)
+	}
+	want := []jsPref{
+		{"user_pref", "browser.startup.homepage", "https://a.test/|https://b.test/"},
+		{"user_pref", "browser.download.folderList", "2"},
+		{"user_pref", "network.proxy.type", "-1"},
+		{"user_pref", "single", "true"},
+		{"user_pref", "escaped", "q\"\\\nAé😀"},
+		{"pref", "default.pref", "false"},
+		{"user_pref", "after.broken", "ok"},
+	}
+	if !reflect.DeepEqual(prefs, want) {
+		t.Errorf("got %+v, want %+v", prefs, want)
+	}
+}
@@ -0,0 +1,499 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+)
+
+// v8 ValueSerializer tags, chromium writes the IndexedDB values with it
+const (
+	v8Version         = 0xFF
+	v8Padding         = 0x00
+	v8VerifyCount     = '?'
+	v8Hole            = '-'
+	v8Undefined       = '_'
+	v8Null            = '0'
+	v8True            = 'T'
+	v8False           = 'F'
+	v8Int32           = 'I'
+	v8Uint32          = 'U'
+	v8Double          = 'N'
+	v8BigInt          = 'Z'
+	v8Utf8String      = 'S'
+	v8OneByteString   = '"'
+	v8TwoByteString   = 'c'
+	v8ObjectReference = '^'
+	v8BeginObject     = 'o'
+	v8EndObject       = '{'
+	v8BeginSparse     = 'a'
+	v8EndSparse       = '@'
+	v8BeginDense      = 'A'
+	v8EndDense        = '$'
+	v8Date            = 'D'
+	v8TrueObject      = 'y'
+	v8FalseObject     = 'x'
+	v8NumberObject    = 'n'
+	v8BigIntObject    = 'z'
+	v8StringObject    = 's'
+	v8RegExp          = 'R'
+	v8BeginMap        = ';'
+	v8EndMap          = ':'
+	v8BeginSet        = '\''
+	v8EndSet          = ','
+	v8ArrayBuffer     = 'B'
+	v8ArrayBufferView = 'V'
+	v8Error           = 'r'
+
+	// blink wraps the v8 value, the trailer offset tag is followed by an offset and a size
+	blinkTrailerOffset     = 0xFE
+	blinkTrailerOffsetSize = 12
+)
+
+var errV8Corrupt = errors.New("v8: corrupt value")
+
+// v8Reader decode a v8 serialized value into json friendly go values, objects are kept
+// in the order they are read to resolve the object references
+type v8Reader struct {
+	b       []byte
+	pos     int
+	version uint64
+	objects []interface{}
+}
+
+// decodeV8Value decode a value written by the v8 ValueSerializer, with or without the
+// blink envelope. Host objects like blobs and files are not understood.
+func decodeV8Value(b []byte) (interface{}, error) {
+	r := &v8Reader{b: b}
+	if err := r.readHeader(); err != nil {
+		return nil, err
+	}
+	return r.readValue()
+}
+
+// readHeader skip the blink and v8 version headers and the blink trailer offset
+func (r *v8Reader) readHeader() error {
+	for r.pos < len(r.b) {
+		switch r.b[r.pos] {
+		case v8Version:
+			r.pos++
+			version, err := r.readVarint()
+			if err != nil {
+				return err
+			}
+			r.version = version
+		case blinkTrailerOffset:
+			r.pos += 1 + blinkTrailerOffsetSize
+		default:
+			return nil
+		}
+	}
+	return errV8Corrupt
+}
+
+func (r *v8Reader) readTag() (byte, error) {
+	for r.pos < len(r.b) {
+		tag := r.b[r.pos]
+		r.pos++
+		if tag != v8Padding {
+			return tag, nil
+		}
+	}
+	return 0, errV8Corrupt
+}
+
+func (r *v8Reader) peekTag() byte {
+	for i := r.pos; i < len(r.b); i++ {
+		if r.b[i] != v8Padding {
+			return r.b[i]
+		}
+	}
+	return 0
+}
+
+func (r *v8Reader) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		return 0, errV8Corrupt
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *v8Reader) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.b)-r.pos) {
+		return nil, errV8Corrupt
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *v8Reader) readDouble() (float64, error) {
+	b, err := r.readBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (r *v8Reader) readString(tag byte) (string, error) {
+	length, err := r.readVarint()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.readBytes(length)
+	if err != nil {
+		return "", err
+	}
+	switch tag {
+	case v8OneByteString:
+		return decodeLatin1(b), nil
+	case v8TwoByteString:
+		return decodeUTF16(b), nil
+	}
+	return string(b), nil
+}
+
+// addObject register an object for the object references and return its id
+func (r *v8Reader) addObject(v interface{}) int {
+	r.objects = append(r.objects, v)
+	return len(r.objects) - 1
+}
+
+func (r *v8Reader) readValue() (interface{}, error) {
+	tag, err := r.readTag()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case v8VerifyCount:
+		if _, err := r.readVarint(); err != nil {
+			return nil, err
+		}
+		return r.readValue()
+	case v8Undefined, v8Null, v8Hole:
+		return nil, nil
+	case v8True:
+		return true, nil
+	case v8False:
+		return false, nil
+	case v8Int32:
+		v, err := r.readVarint()
+		// zigzag encoded
+		return int32(v>>1) ^ -int32(v&1), err
+	case v8Uint32:
+		v, err := r.readVarint()
+		return uint32(v), err
+	case v8Double:
+		v, err := r.readDouble()
+		return jsNumber(v), err
+	case v8BigInt:
+		return r.readBigInt()
+	case v8Utf8String, v8OneByteString, v8TwoByteString:
+		return r.readString(tag)
+	case v8ObjectReference:
+		id, err := r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if id >= uint64(len(r.objects)) {
+			return nil, errV8Corrupt
+		}
+		return r.objects[id], nil
+	case v8BeginObject:
+		object := make(map[string]interface{})
+		r.addObject(object)
+		return object, r.readProperties(object, v8EndObject)
+	case v8BeginDense:
+		return r.readDenseArray()
+	case v8BeginSparse:
+		length, err := r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		// a sparse array is read as an object of its set indexes
+		object := map[string]interface{}{"length": length}
+		r.addObject(object)
+		if err := r.readProperties(object, v8EndSparse); err != nil {
+			return nil, err
+		}
+		_, err = r.readVarint()
+		return object, err
+	case v8Date:
+		v, err := r.readDouble()
+		date := filemgmt.UnixMilliTime(int64(v))
+		r.addObject(date)
+		return date, err
+	case v8TrueObject, v8FalseObject:
+		r.addObject(tag == v8TrueObject)
+		return tag == v8TrueObject, nil
+	case v8NumberObject:
+		v, err := r.readDouble()
+		r.addObject(jsNumber(v))
+		return jsNumber(v), err
+	case v8BigIntObject:
+		v, err := r.readBigInt()
+		r.addObject(v)
+		return v, err
+	case v8StringObject:
+		stringTag, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.readString(stringTag)
+		r.addObject(v)
+		return v, err
+	case v8RegExp:
+		return r.readRegExp()
+	case v8BeginMap, v8BeginSet:
+		return r.readCollection(tag)
+	case v8ArrayBuffer:
+		length, err := r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		buffer, err := r.readBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		r.addObject(buffer)
+		if r.peekTag() == v8ArrayBufferView {
+			return r.readView(buffer)
+		}
+		return buffer, nil
+	case v8Error:
+		return r.readError()
+	}
+	return nil, fmt.Errorf("v8: unsupported tag 0x%02x", tag)
+}
+
+// readProperties read key value pairs into object until the end tag and its property count
+func (r *v8Reader) readProperties(object map[string]interface{}, end byte) error {
+	for {
+		if r.peekTag() == end {
+			if _, err := r.readTag(); err != nil {
+				return err
+			}
+			_, err := r.readVarint()
+			return err
+		}
+		key, err := r.readValue()
+		if err != nil {
+			return err
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return err
+		}
+		object[jsKey(key)] = value
+	}
+}
+
+func (r *v8Reader) readDenseArray() (interface{}, error) {
+	length, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.b)-r.pos) {
+		return nil, errV8Corrupt
+	}
+	array := make([]interface{}, length)
+	id := r.addObject(array)
+	for i := range array {
+		if array[i], err = r.readValue(); err != nil {
+			return nil, err
+		}
+	}
+	r.objects[id] = array
+	// named properties of the array are dropped
+	extra := make(map[string]interface{})
+	if err := r.readProperties(extra, v8EndDense); err != nil {
+		return nil, err
+	}
+	_, err = r.readVarint()
+	return array, err
+}
+
+func (r *v8Reader) readCollection(tag byte) (interface{}, error) {
+	end := byte(v8EndMap)
+	if tag == v8BeginSet {
+		end = v8EndSet
+	}
+	// maps are read as a list of [key, value] pairs, sets as a list
+	var list []interface{}
+	id := r.addObject(list)
+	for r.peekTag() != end {
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if tag == v8BeginMap {
+			value, err := r.readValue()
+			if err != nil {
+				return nil, err
+			}
+			v = []interface{}{v, value}
+		}
+		list = append(list, v)
+	}
+	r.objects[id] = list
+	if _, err := r.readTag(); err != nil {
+		return nil, err
+	}
+	_, err := r.readVarint()
+	return list, err
+}
+
+func (r *v8Reader) readBigInt() (interface{}, error) {
+	bitfield, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	digits, err := r.readBytes(bitfield >> 1)
+	if err != nil {
+		return nil, err
+	}
+	// little endian digits, the bigint is written as a string to keep its precision
+	be := make([]byte, len(digits))
+	for i, d := range digits {
+		be[len(digits)-1-i] = d
+	}
+	v := new(big.Int).SetBytes(be)
+	if bitfield&1 == 1 {
+		v.Neg(v)
+	}
+	return v.String(), nil
+}
+
+func (r *v8Reader) readRegExp() (interface{}, error) {
+	tag, err := r.readTag()
+	if err != nil {
+		return nil, err
+	}
+	source, err := r.readString(tag)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	v := "/" + source + "/" + regExpFlags(flags, "gimyusldv")
+	r.addObject(v)
+	return v, nil
+}
+
+// regExpFlags turn the flag bits into their letters, letters are in bit order
+func regExpFlags(bits uint64, letters string) string {
+	var flags []byte
+	for i := 0; i < len(letters); i++ {
+		if bits&(1<<i) != 0 {
+			flags = append(flags, letters[i])
+		}
+	}
+	return string(flags)
+}
+
+// readView read a typed array or data view of the buffer read just before it
+func (r *v8Reader) readView(buffer []byte) (interface{}, error) {
+	if _, err := r.readTag(); err != nil {
+		return nil, err
+	}
+	if _, err := r.readBytes(1); err != nil {
+		return nil, err
+	}
+	offset, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	length, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	// the view flags were added in version 14
+	if r.version >= 14 {
+		if _, err := r.readVarint(); err != nil {
+			return nil, err
+		}
+	}
+	if offset > uint64(len(buffer)) || length > uint64(len(buffer))-offset {
+		return nil, errV8Corrupt
+	}
+	view := buffer[offset : offset+length]
+	r.addObject(view)
+	return view, nil
+}
+
+// readError read an error object, the prototype tags name the error type
+func (r *v8Reader) readError() (interface{}, error) {
+	names := map[byte]string{
+		'E': "EvalError", 'R': "RangeError", 'F': "ReferenceError",
+		'S': "SyntaxError", 'T': "TypeError", 'U': "URIError",
+	}
+	object := map[string]interface{}{"name": "Error"}
+	r.addObject(object)
+	for {
+		tag, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case '.':
+			return object, nil
+		case 'm', 's':
+			stringTag, err := r.readTag()
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.readString(stringTag)
+			if err != nil {
+				return nil, err
+			}
+			if tag == 'm' {
+				object["message"] = v
+			} else {
+				object["stack"] = v
+			}
+		case 'c':
+			v, err := r.readValue()
+			if err != nil {
+				return nil, err
+			}
+			object["cause"] = v
+		default:
+			if name, ok := names[tag]; ok {
+				object["name"] = name
+				continue
+			}
+			return nil, errV8Corrupt
+		}
+	}
+}
+
+// jsNumber keep a double encodable as json, NaN and the infinities become strings
+func jsNumber(v float64) interface{} {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return v
+}
+
+// jsKey turn a property key into a string like javascript does
+func jsKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'g', -1, 64)
+	}
+	return fmt.Sprint(key)
+}
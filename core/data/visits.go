@@ -0,0 +1,339 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// chromium page_transition, the low byte is the core type and the high bits are qualifiers
+const (
+	chromiumTransitionCoreMask = 0xFF
+
+	chromiumTransitionBlocked        = 0x00800000
+	chromiumTransitionForwardBack    = 0x01000000
+	chromiumTransitionFromAddressBar = 0x02000000
+	chromiumTransitionHomePage       = 0x04000000
+	chromiumTransitionFromAPI        = 0x08000000
+	chromiumTransitionChainStart     = 0x10000000
+	chromiumTransitionChainEnd       = 0x20000000
+	chromiumTransitionClientRedirect = 0x40000000
+	chromiumTransitionServerRedirect = 0x80000000
+
+	chromiumTransitionRedirectMask = chromiumTransitionClientRedirect | chromiumTransitionServerRedirect
+
+	// visitQualifierSep joins the transition qualifiers, csv can't hold a list
+	visitQualifierSep = "|"
+)
+
+var chromiumTransitions = []string{
+	"link",
+	"typed",
+	"auto_bookmark",
+	"auto_subframe",
+	"manual_subframe",
+	"generated",
+	"auto_toplevel",
+	"form_submit",
+	"reload",
+	"keyword",
+	"keyword_generated",
+}
+
+var chromiumTransitionQualifiers = []struct {
+	mask int64
+	name string
+}{
+	{chromiumTransitionBlocked, "blocked"},
+	{chromiumTransitionForwardBack, "forward_back"},
+	{chromiumTransitionFromAddressBar, "from_address_bar"},
+	{chromiumTransitionHomePage, "home_page"},
+	{chromiumTransitionFromAPI, "from_api"},
+	{chromiumTransitionChainStart, "chain_start"},
+	{chromiumTransitionChainEnd, "chain_end"},
+	{chromiumTransitionClientRedirect, "client_redirect"},
+	{chromiumTransitionServerRedirect, "server_redirect"},
+}
+
+// chromiumVisitSources is the visit_source table, visits without a row are browsed
+var chromiumVisitSources = map[int64]string{
+	0: "synced",
+	1: "browsed",
+	2: "extension",
+	3: "firefox_imported",
+	4: "ie_imported",
+	5: "safari_imported",
+}
+
+// firefoxVisitTypes is moz_historyvisits.visit_type, see nsINavHistoryService
+var firefoxVisitTypes = map[int64]string{
+	1: "link",
+	2: "typed",
+	3: "bookmark",
+	4: "embed",
+	5: "redirect_permanent",
+	6: "redirect_temporary",
+	7: "download",
+	8: "framed_link",
+	9: "reload",
+}
+
+// firefoxVisitSources is moz_historyvisits.source, only set by recent firefox
+var firefoxVisitSources = map[int64]string{
+	0: "organic",
+	1: "sponsored",
+	2: "bookmarked",
+	3: "searched",
+	4: "synced",
+}
+
+// Visit is a single visit of an url, FromVisit is the ID of the referring visit
+// and SyncDevice the sync cache guid of the device the visit comes from
+type Visit struct {
+	ID         int64
+	Url        string
+	Title      string
+	VisitTime  time.Time
+	FromVisit  int64
+	Transition string
+	Qualifiers string
+	IsRedirect bool
+	Duration   time.Duration
+	Source     string
+	SyncDevice string
+}
+
+type visits struct {
+	mainPath string
+	tempDir  string
+	visits   []Visit
+}
+
+func NewVisits(main, sub string) Item {
+	return &visits{mainPath: main}
+}
+
+func (v *visits) ChromeParse(key []byte) error {
+	return v.ChromeParseContext(context.Background(), key)
+}
+
+func (v *visits) ChromeParseContext(ctx context.Context, key []byte) error {
+	historyDB, err := sql.Open("sqlite3", filepath.Join(v.tempDir, ChromeHistoryFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := historyDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	columns, err := tableColumns(ctx, historyDB, "visits")
+	if err != nil {
+		return err
+	}
+	// the originator columns came with history sync, older profiles don't have them
+	originator := "''"
+	if hasColumn(columns, "originator_cache_guid") {
+		originator = "v.originator_cache_guid"
+	}
+	rows, err := historyDB.QueryContext(ctx, fmt.Sprintf(QueryChromiumVisits, originator))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	v.visits = nil
+	for rows.Next() {
+		var (
+			id, visitTime, fromVisit, transition, duration, source int64
+			url, title, device                                     string
+		)
+		if err = rows.Scan(&id, &url, &title, &visitTime, &fromVisit, &transition, &duration, &device, &source); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		core, qualifiers := ChromiumTransition(transition)
+		v.visits = append(v.visits, Visit{
+			ID:         id,
+			Url:        url,
+			Title:      title,
+			VisitTime:  filemgmt.WebKitTime(visitTime),
+			FromVisit:  fromVisit,
+			Transition: core,
+			Qualifiers: strings.Join(qualifiers, visitQualifierSep),
+			IsRedirect: transition&chromiumTransitionRedirectMask != 0,
+			Duration:   time.Duration(duration) * time.Microsecond,
+			Source:     chromiumVisitSources[source],
+			SyncDevice: device,
+		})
+	}
+	return rows.Err()
+}
+
+func (v *visits) FirefoxParse() error {
+	return v.FirefoxParseContext(context.Background())
+}
+
+func (v *visits) FirefoxParseContext(ctx context.Context) error {
+	placesDB, err := sql.Open("sqlite3", filepath.Join(v.tempDir, FirefoxDataFile))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := placesDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	_, err = placesDB.ExecContext(ctx, CloseJournalMode)
+	if err != nil {
+		logger.Error(err)
+	}
+	columns, err := tableColumns(ctx, placesDB, "moz_historyvisits")
+	if err != nil {
+		return err
+	}
+	// no source column means unknown, -1 is not in firefoxVisitSources
+	source := "-1"
+	if hasColumn(columns, "source") {
+		source = "v.source"
+	}
+	rows, err := placesDB.QueryContext(ctx, fmt.Sprintf(QueryFirefoxVisits, source))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	v.visits = nil
+	for rows.Next() {
+		var (
+			id, visitDate, fromVisit, visitType, visitSource int64
+			url, title                                       string
+		)
+		if err = rows.Scan(&id, &url, &title, &visitDate, &fromVisit, &visitType, &visitSource); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		transition := FirefoxVisitType(visitType)
+		v.visits = append(v.visits, Visit{
+			ID:         id,
+			Url:        url,
+			Title:      title,
+			VisitTime:  filemgmt.PRTime(visitDate),
+			FromVisit:  fromVisit,
+			Transition: transition,
+			IsRedirect: strings.HasPrefix(transition, "redirect"),
+			Source:     firefoxVisitSources[visitSource],
+		})
+	}
+	return rows.Err()
+}
+
+// ChromiumTransition decode a chromium page_transition into its core type and qualifiers
+func ChromiumTransition(transition int64) (string, []string) {
+	core := "unknown"
+	if c := transition & chromiumTransitionCoreMask; c < int64(len(chromiumTransitions)) {
+		core = chromiumTransitions[c]
+	}
+	var qualifiers []string
+	for _, q := range chromiumTransitionQualifiers {
+		if transition&q.mask != 0 {
+			qualifiers = append(qualifiers, q.name)
+		}
+	}
+	return core, qualifiers
+}
+
+// FirefoxVisitType return the name of a firefox visit_type
+func FirefoxVisitType(visitType int64) string {
+	if name, ok := firefoxVisitTypes[visitType]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Records return all the parsed visits ordered by visit time
+func (v *visits) Records() []Visit {
+	return v.visits
+}
+
+func (v *visits) CopyDB() error {
+	dir, err := copyToTempDir(v.mainPath)
+	if err != nil {
+		return err
+	}
+	v.tempDir = dir
+	return nil
+}
+
+func (v *visits) Release() error {
+	return releaseTempDir(v.tempDir)
+}
+
+func (v *visits) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(v.visits, func(i, j int) bool {
+		return v.visits[i].VisitTime.Before(v.visits[j].VisitTime)
+	})
+	switch format {
+	case formatCSV:
+		err := v.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		v.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return v.outPutJsonLines(browser, dir)
+	default:
+		err := v.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (v *visits) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameVisit, GetFormatName(formatJson))
+	err := WriteToJson(filename, v.visits)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d visits, filename is %s \n", filemgmt.Prefix, len(v.visits), filename)
+	return nil
+}
+
+func (v *visits) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameVisit, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, v.visits); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d visits, filename is %s \n", filemgmt.Prefix, len(v.visits), filename)
+	return nil
+}
+
+func (v *visits) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameVisit, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, v.visits); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d visits, filename is %s \n", filemgmt.Prefix, len(v.visits), filename)
+	return nil
+}
+
+func (v *visits) outPutConsole() {
+	for _, visit := range v.visits {
+		fmt.Printf("%+v\n", visit)
+	}
+}
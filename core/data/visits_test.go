@@ -0,0 +1,34 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChromiumTransition(t *testing.T) {
+	tests := []struct {
+		transition int64
+		core       string
+		qualifiers []string
+	}{
+		{0, "link", nil},
+		{1, "typed", nil},
+		{10, "keyword_generated", nil},
+		{0x0b, "unknown", nil},
+		{1 | chromiumTransitionFromAddressBar | chromiumTransitionChainStart | chromiumTransitionChainEnd, "typed",
+			[]string{"from_address_bar", "chain_start", "chain_end"}},
+		{7 | chromiumTransitionServerRedirect, "form_submit", []string{"server_redirect"}},
+		// chromium stores the transition as a signed int32, this is a server redirect ending a chain
+		{-1610612736, "link", []string{"chain_end", "server_redirect"}},
+		{8 | chromiumTransitionForwardBack | chromiumTransitionBlocked, "reload", []string{"blocked", "forward_back"}},
+	}
+	for _, tt := range tests {
+		core, qualifiers := ChromiumTransition(tt.transition)
+		if core != tt.core || !reflect.DeepEqual(qualifiers, tt.qualifiers) {
+			t.Errorf("ChromiumTransition(%#x) = %s %v, want %s %v", tt.transition, core, qualifiers, tt.core, tt.qualifiers)
+		}
+	}
+}
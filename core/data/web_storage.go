@@ -0,0 +1,532 @@
+// Package data
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+const (
+	StorageLocal   = "local"
+	StorageSession = "session"
+
+	// chromium local storage keys, META: holds the origin metadata and _ the values as
+	// _<origin>\x00<key>
+	chromiumStorageMetaPrefix = "META:"
+	chromiumStorageDataPrefix = "_"
+	// chromium session storage keys, namespace-<guid>-<origin> points to a map number and
+	// map-<number>-<key> holds the values of that map
+	chromiumSessionNamespacePrefix = "namespace-"
+	chromiumSessionMapPrefix       = "map-"
+	chromiumSessionGuidLength      = 36
+
+	// the first byte of the local storage strings tells how the rest is encoded
+	chromiumStorageUTF16  = 0
+	chromiumStorageLatin1 = 1
+
+	// firefox copies the ls/data.sqlite of each origin to ls/<origin dir> of the temp dir
+	firefoxLsDir  = "ls"
+	firefoxLsFile = "data.sqlite"
+	// LSValue::ConversionType and LSValue::CompressionType of the firefox ls values
+	firefoxLsConversionNone    = 0
+	firefoxLsCompressionSnappy = 1
+)
+
+// StorageEntry is a key of the web storage of an origin, Storage is local or session.
+// LastModified is per origin and only known for local storage.
+type StorageEntry struct {
+	Origin       string
+	Key          string
+	Value        string
+	Storage      string
+	LastModified time.Time
+}
+
+type webStorage struct {
+	mainPath string
+	tempDir  string
+	entries  []StorageEntry
+}
+
+func NewWebStorage(main, sub string) Item {
+	return &webStorage{mainPath: main}
+}
+
+func (w *webStorage) ChromeParse(key []byte) error {
+	return w.ChromeParseContext(context.Background(), key)
+}
+
+// ChromeParseContext read the Local Storage and the Session Storage leveldb of the profile
+func (w *webStorage) ChromeParseContext(ctx context.Context, key []byte) error {
+	local, err := readLevelDB(filepath.Join(w.tempDir, filepath.Base(w.mainPath)))
+	if err != nil {
+		return err
+	}
+	w.entries = chromiumLocalStorage(local)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	session, err := readLevelDB(filepath.Join(w.tempDir, ChromeSessionDir))
+	if err != nil {
+		logger.Debug(err)
+		return nil
+	}
+	w.entries = append(w.entries, chromiumSessionStorage(session)...)
+	return ctx.Err()
+}
+
+func chromiumLocalStorage(records []levelDBRecord) []StorageEntry {
+	var entries []StorageEntry
+	modified := make(map[string]time.Time)
+	for _, r := range records {
+		key := string(r.Key)
+		switch {
+		case strings.HasPrefix(key, chromiumStorageMetaPrefix):
+			// LocalStorageOriginMetaData, field 1 is the last modified base::Time
+			origin := strings.TrimPrefix(key, chromiumStorageMetaPrefix)
+			modified[origin] = filemgmt.WebKitTime(protobufVarint(r.Value, 1))
+		case strings.HasPrefix(key, chromiumStorageDataPrefix):
+			origin, scriptKey, ok := strings.Cut(strings.TrimPrefix(key, chromiumStorageDataPrefix), "\x00")
+			if !ok {
+				continue
+			}
+			entries = append(entries, StorageEntry{
+				Origin:  origin,
+				Key:     decodeChromiumStorageString([]byte(scriptKey)),
+				Value:   decodeChromiumStorageString(r.Value),
+				Storage: StorageLocal,
+			})
+		}
+	}
+	for i := range entries {
+		entries[i].LastModified = modified[entries[i].Origin]
+	}
+	return entries
+}
+
+func chromiumSessionStorage(records []levelDBRecord) []StorageEntry {
+	origins := make(map[string]string)
+	for _, r := range records {
+		key := string(r.Key)
+		if !strings.HasPrefix(key, chromiumSessionNamespacePrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, chromiumSessionNamespacePrefix)
+		if len(rest) <= chromiumSessionGuidLength || rest[chromiumSessionGuidLength] != '-' {
+			continue
+		}
+		// several namespaces share a map until one of them writes to it
+		origins[string(r.Value)] = rest[chromiumSessionGuidLength+1:]
+	}
+	var entries []StorageEntry
+	for _, r := range records {
+		key := string(r.Key)
+		if !strings.HasPrefix(key, chromiumSessionMapPrefix) {
+			continue
+		}
+		number, scriptKey, ok := strings.Cut(strings.TrimPrefix(key, chromiumSessionMapPrefix), "-")
+		if !ok {
+			continue
+		}
+		origin, ok := origins[number]
+		if !ok {
+			// the namespace of the map was deleted
+			continue
+		}
+		// session storage strings are utf-16 without the encoding byte
+		entries = append(entries, StorageEntry{
+			Origin:  origin,
+			Key:     decodeUTF16([]byte(scriptKey)),
+			Value:   decodeUTF16(r.Value),
+			Storage: StorageSession,
+		})
+	}
+	return entries
+}
+
+// decodeChromiumStorageString decode a local storage string by its first byte
+func decodeChromiumStorageString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	switch b[0] {
+	case chromiumStorageUTF16:
+		return decodeUTF16(b[1:])
+	case chromiumStorageLatin1:
+		return decodeLatin1(b[1:])
+	}
+	return string(b)
+}
+
+// decodeUTF16 decode little endian utf-16, an odd last byte is dropped
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+func decodeLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// protobufVarint return the varint of a top level field of a protobuf message, 0 when
+// the field is missing or the message is broken
+func protobufVarint(message []byte, field uint64) int64 {
+	for i := 0; i < len(message); {
+		tag, n := binary.Uvarint(message[i:])
+		if n <= 0 {
+			return 0
+		}
+		i += n
+		var size uint64
+		switch tag & 0x07 {
+		case 0:
+			v, n := binary.Uvarint(message[i:])
+			if n <= 0 {
+				return 0
+			}
+			if tag>>3 == field {
+				return int64(v)
+			}
+			i += n
+			continue
+		case 1:
+			size = 8
+		case 2:
+			length, n := binary.Uvarint(message[i:])
+			if n <= 0 {
+				return 0
+			}
+			i += n
+			size = length
+		case 5:
+			size = 4
+		default:
+			return 0
+		}
+		if size > uint64(len(message)-i) {
+			return 0
+		}
+		i += int(size)
+	}
+	return 0
+}
+
+func (w *webStorage) FirefoxParse() error {
+	return w.FirefoxParseContext(context.Background())
+}
+
+// FirefoxParseContext read the per origin storage/default/<origin>/ls/data.sqlite and the
+// legacy webappsstore.sqlite, the legacy entries of an origin already read are skipped
+func (w *webStorage) FirefoxParseContext(ctx context.Context) error {
+	w.entries = nil
+	seen := make(map[string]bool)
+	origins, err := os.ReadDir(filepath.Join(w.tempDir, firefoxLsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, o := range origins {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		entries, err := firefoxLsData(ctx, filepath.Join(w.tempDir, firefoxLsDir, o.Name(), firefoxLsFile), o.Name())
+		if err != nil {
+			logger.Debugf("%s: %s", o.Name(), err)
+			continue
+		}
+		for _, e := range entries {
+			seen[e.Origin] = true
+		}
+		w.entries = append(w.entries, entries...)
+	}
+	webapps := filepath.Join(w.tempDir, FirefoxWebappsFile)
+	if _, err := os.Stat(webapps); err != nil {
+		return nil
+	}
+	entries, err := firefoxWebapps(ctx, webapps)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !seen[e.Origin] {
+			w.entries = append(w.entries, e)
+		}
+	}
+	return nil
+}
+
+// firefoxLsData read the localStorage of an origin, values are utf-8 or raw utf-16 and
+// can be snappy compressed. Old databases have no conversion column and a compressed flag.
+func firefoxLsData(ctx context.Context, path, dirName string) ([]StorageEntry, error) {
+	lsDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := lsDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	origin := firefoxOriginDir(dirName)
+	var saved string
+	if err := lsDB.QueryRowContext(ctx, QueryFirefoxLsOrigin).Scan(&saved); err == nil && saved != "" {
+		origin = saved
+	}
+	columns, err := tableColumns(ctx, lsDB, "data")
+	if err != nil {
+		return nil, err
+	}
+	conversion, compression := "1", "0"
+	if hasColumn(columns, "conversion_type") {
+		conversion = "conversion_type"
+	}
+	if hasColumn(columns, "compression_type") {
+		compression = "compression_type"
+	} else if hasColumn(columns, "compressed") {
+		compression = "compressed"
+	}
+	rows, err := lsDB.QueryContext(ctx, fmt.Sprintf(QueryFirefoxLsData, conversion, compression))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var entries []StorageEntry
+	for rows.Next() {
+		var (
+			key                    string
+			value                  []byte
+			conversion, compressed int
+		)
+		if err = rows.Scan(&key, &value, &conversion, &compressed); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		if compressed == firefoxLsCompressionSnappy {
+			if value, err = decodeSnappy(value); err != nil {
+				logger.Warn(err)
+				continue
+			}
+		}
+		entry := StorageEntry{Origin: origin, Key: key, Value: string(value), Storage: StorageLocal}
+		if conversion == firefoxLsConversionNone {
+			entry.Value = decodeUTF16(value)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// firefoxWebapps read webappsstore2, the origin is saved as a reversed scope key and
+// the origin attributes, like ^userContextId=1 for containers, are kept as firefox does
+func firefoxWebapps(ctx context.Context, path string) ([]StorageEntry, error) {
+	webappsDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := webappsDB.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	columns, err := tableColumns(ctx, webappsDB, "webappsstore2")
+	if err != nil {
+		return nil, err
+	}
+	attributes, scope := "''", "scope"
+	if hasColumn(columns, "originAttributes") {
+		attributes = "IFNULL(originAttributes, '')"
+	}
+	if hasColumn(columns, "originKey") {
+		scope = "originKey"
+	}
+	rows, err := webappsDB.QueryContext(ctx, fmt.Sprintf(QueryFirefoxWebapps, attributes, scope))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	var entries []StorageEntry
+	for rows.Next() {
+		var attrs, key, scopeKey, value string
+		if err = rows.Scan(&attrs, &scopeKey, &key, &value); err != nil {
+			logger.Warn(err)
+			continue
+		}
+		entries = append(entries, StorageEntry{
+			Origin:  firefoxScopeOrigin(scopeKey) + attrs,
+			Key:     key,
+			Value:   value,
+			Storage: StorageLocal,
+		})
+	}
+	return entries, rows.Err()
+}
+
+// firefoxScopeOrigin turn a scope key like moc.elpmaxe.:https:443 into https://example.com,
+// the oldest keys start with an app id that is dropped
+func firefoxScopeOrigin(scope string) string {
+	parts := strings.Split(scope, ":")
+	if len(parts) < 3 {
+		return scope
+	}
+	host, scheme, port := []rune(parts[len(parts)-3]), parts[len(parts)-2], parts[len(parts)-1]
+	for i, j := 0, len(host)-1; i < j; i, j = i+1, j-1 {
+		host[i], host[j] = host[j], host[i]
+	}
+	origin := scheme + "://" + strings.TrimPrefix(string(host), ".")
+	if port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		origin += ":" + port
+	}
+	return origin
+}
+
+// firefoxOriginDir turn a storage directory name back into an origin, the directory of
+// https://example.com:8443^userContextId=1 is https+++example.com+8443^userContextId=1
+func firefoxOriginDir(name string) string {
+	origin, attrs, _ := strings.Cut(name, "^")
+	origin = strings.Replace(origin, "+++", "://", 1)
+	if i := strings.LastIndex(origin, "+"); i > 0 {
+		if _, err := strconv.Atoi(origin[i+1:]); err == nil {
+			origin = origin[:i] + ":" + origin[i+1:]
+		}
+	}
+	if attrs != "" {
+		origin += "^" + attrs
+	}
+	return origin
+}
+
+// Records return all the parsed web storage entries
+func (w *webStorage) Records() []StorageEntry {
+	return w.entries
+}
+
+// CopyDB copy the Local Storage leveldb, the Session Storage of the same profile is
+// copied too when it exists. Firefox storage is sqlite and copied by copyFirefox.
+func (w *webStorage) CopyDB() error {
+	if filepath.Base(w.mainPath) == filepath.Base(FirefoxStorageDir) {
+		return w.copyFirefox()
+	}
+	session := filepath.Join(filepath.Dir(filepath.Dir(w.mainPath)), ChromeSessionDir)
+	dir, err := copyDirsToTempDir(w.mainPath, session)
+	if err != nil {
+		return err
+	}
+	w.tempDir = dir
+	return nil
+}
+
+// copyFirefox copy the ls/data.sqlite of every origin of storage/default and the
+// webappsstore.sqlite of the profile when it exists
+func (w *webStorage) copyFirefox() error {
+	matches, err := filepath.Glob(filepath.Join(w.mainPath, "*", firefoxLsDir, firefoxLsFile))
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "go-cc-")
+	if err != nil {
+		return err
+	}
+	w.tempDir = dir
+	for _, m := range matches {
+		dst := filepath.Join(dir, firefoxLsDir, filepath.Base(filepath.Dir(filepath.Dir(m))))
+		if err := os.MkdirAll(dst, 0700); err != nil {
+			return err
+		}
+		if err := copyWithWal(m, filepath.Join(dst, firefoxLsFile)); err != nil {
+			return err
+		}
+	}
+	webapps := filepath.Join(filepath.Dir(filepath.Dir(w.mainPath)), FirefoxWebappsFile)
+	if _, err := os.Stat(webapps); err != nil {
+		return nil
+	}
+	return copyWithWal(webapps, filepath.Join(dir, FirefoxWebappsFile))
+}
+
+func (w *webStorage) Release() error {
+	return releaseTempDir(w.tempDir)
+}
+
+func (w *webStorage) OutPut(format OutputFormat, browser, dir string) error {
+	sort.SliceStable(w.entries, func(i, j int) bool {
+		if w.entries[i].Origin != w.entries[j].Origin {
+			return w.entries[i].Origin < w.entries[j].Origin
+		}
+		return w.entries[i].Key < w.entries[j].Key
+	})
+	switch format {
+	case formatCSV:
+		err := w.outPutCsv(browser, dir)
+		return err
+	case formatConsole:
+		w.outPutConsole()
+		return nil
+	case formatJsonLines:
+		return w.outPutJsonLines(browser, dir)
+	default:
+		err := w.outPutJson(browser, dir)
+		return err
+	}
+}
+
+func (w *webStorage) outPutJson(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameStorage, GetFormatName(formatJson))
+	err := WriteToJson(filename, w.entries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d storage entries, filename is %s \n", filemgmt.Prefix, len(w.entries), filename)
+	return nil
+}
+
+func (w *webStorage) outPutCsv(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameStorage, GetFormatName(formatCSV))
+	if err := WriteToCsv(filename, w.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d storage entries, filename is %s \n", filemgmt.Prefix, len(w.entries), filename)
+	return nil
+}
+
+func (w *webStorage) outPutJsonLines(browser, dir string) error {
+	filename := filemgmt.FormatFileName(dir, browser, ItemNameStorage, GetFormatName(formatJsonLines))
+	if err := WriteToJsonLines(filename, w.entries); err != nil {
+		return err
+	}
+	fmt.Printf("%s Get %d storage entries, filename is %s \n", filemgmt.Prefix, len(w.entries), filename)
+	return nil
+}
+
+func (w *webStorage) outPutConsole() {
+	for _, v := range w.entries {
+		fmt.Printf("%+v\n", v)
+	}
+}
@@ -5,10 +5,12 @@
 
 package decrypt
 
+import "github.com/teocci/go-chrome-cookies/core/throw"
+
 func ChromePass(key, encryptPass []byte) ([]byte, error) {
 	if len(encryptPass) > 3 {
 		if len(key) == 0 {
-			return nil, errSecurityKeyIsEmpty
+			return nil, throw.ErrorSecurityKeyIsEmpty()
 		}
 		var chromeIV = []byte{32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32}
 		return aes128CBCDecrypt(key, chromeIV, encryptPass[3:])
@@ -19,4 +21,23 @@ func ChromePass(key, encryptPass []byte) ([]byte, error) {
 
 func DPApi(data []byte) ([]byte, error) {
 	return nil, nil
-}
\ No newline at end of file
+}
+
+// EncryptChromePass is the inverse of ChromePass, the secret key comes from the
+// keychain so the value is written with the 'v10' prefix
+func EncryptChromePass(key, plainText []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, throw.ErrorSecurityKeyIsEmpty()
+	}
+	var chromeIV = []byte{32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32}
+	encrypted, err := aes128CBCEncrypt(key, chromeIV, plainText)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("v10"), encrypted...), nil
+}
+
+// DPApiEncrypt is only available on Windows
+func DPApiEncrypt(data []byte) ([]byte, error) {
+	return nil, throw.ErrorSecurityKeyIsEmpty()
+}
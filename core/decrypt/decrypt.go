@@ -4,6 +4,7 @@
 package decrypt
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/des"
@@ -166,6 +167,25 @@ func aes128CBCDecrypt(key, iv, encryptPass []byte) ([]byte, error) {
 	return dst, nil
 }
 
+func aes128CBCEncrypt(key, iv, plainText []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	src := PKCS5Padding(plainText, block.BlockSize())
+	dst := make([]byte, len(src))
+	mode := cipher.NewCBCEncrypter(block, iv)
+	mode.CryptBlocks(dst, src)
+	return dst, nil
+}
+
+func PKCS5Padding(src []byte, blockSize int) []byte {
+	padding := blockSize - len(src)%blockSize
+	dst := make([]byte, len(src), len(src)+padding)
+	copy(dst, src)
+	return append(dst, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
 func PKCS5UnPadding(src []byte) []byte {
 	length := len(src)
 	unpad := int(src[length-1])
@@ -5,11 +5,13 @@
 
 package decrypt
 
+import "github.com/teocci/go-chrome-cookies/core/throw"
+
 func ChromePass(key, encryptPass []byte) ([]byte, error) {
 	var chromeIV = []byte{32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32}
 	if len(encryptPass) > 3 {
 		if len(key) == 0 {
-			return nil, errSecurityKeyIsEmpty
+			return nil, throw.ErrorSecurityKeyIsEmpty()
 		}
 		return aes128CBCDecrypt(key, chromeIV, encryptPass[3:])
 	} else {
@@ -20,3 +22,22 @@ func ChromePass(key, encryptPass []byte) ([]byte, error) {
 func DPApi(data []byte) ([]byte, error) {
 	return nil, nil
 }
+
+// EncryptChromePass is the inverse of ChromePass, the secret key comes from the
+// keyring so the value is written with the 'v11' prefix
+func EncryptChromePass(key, plainText []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, throw.ErrorSecurityKeyIsEmpty()
+	}
+	var chromeIV = []byte{32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32, 32}
+	encrypted, err := aes128CBCEncrypt(key, chromeIV, plainText)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("v11"), encrypted...), nil
+}
+
+// DPApiEncrypt is only available on Windows
+func DPApiEncrypt(data []byte) ([]byte, error) {
+	return nil, throw.ErrorSecurityKeyIsEmpty()
+}
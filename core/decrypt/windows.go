@@ -8,6 +8,7 @@ package decrypt
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"github.com/teocci/go-chrome-cookies/core/throw"
 	"syscall"
 	"unsafe"
@@ -41,6 +42,33 @@ func aesGCMDecrypt(encrypted, key, nonce []byte) ([]byte, error) {
 	return origData, nil
 }
 
+// EncryptChromePass is the inverse of ChromePass, it seals the value with a
+// random nonce and the 'v10' prefix
+func EncryptChromePass(key, plainText []byte) ([]byte, error) {
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encrypted, err := aesGCMEncrypt(plainText, key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	dst := append([]byte("v10"), nonce...)
+	return append(dst, encrypted...), nil
+}
+
+func aesGCMEncrypt(plainText, key, nonce []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	blockMode, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return blockMode.Seal(nil, nonce, plainText, nil), nil
+}
+
 type dataBlob struct {
 	cbData uint32
 	pbData *byte
@@ -77,4 +105,20 @@ func DPApi(data []byte) ([]byte, error) {
 	}
 	defer procLocalFree.Call(uintptr(unsafe.Pointer(outBlob.pbData)))
 	return outBlob.ToByteArray(), nil
-}
\ No newline at end of file
+}
+
+// DPApiEncrypt
+// chrome < 80, inverse of DPApi
+func DPApiEncrypt(data []byte) ([]byte, error) {
+	dllCrypt := syscall.NewLazyDLL("Crypt32.dll")
+	dllKernel := syscall.NewLazyDLL("Kernel32.dll")
+	procEncryptData := dllCrypt.NewProc("CryptProtectData")
+	procLocalFree := dllKernel.NewProc("LocalFree")
+	var outBlob dataBlob
+	r, _, err := procEncryptData.Call(uintptr(unsafe.Pointer(NewBlob(data))), 0, 0, 0, 0, 0, uintptr(unsafe.Pointer(&outBlob)))
+	if r == 0 {
+		return nil, err
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(outBlob.pbData)))
+	return outBlob.ToByteArray(), nil
+}
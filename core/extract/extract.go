@@ -0,0 +1,243 @@
+// Package extract
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/browser"
+	"github.com/teocci/go-chrome-cookies/core/data"
+	"github.com/teocci/go-chrome-cookies/filemgmt"
+	"github.com/teocci/go-chrome-cookies/logger"
+)
+
+// Plan describes what Run extracts and where the output goes
+type Plan struct {
+	// Browsers to extract, see browser.PickBrowser and browser.PickProfiles
+	Browsers []browser.Browser
+
+	// Items to extract from every browser, empty means browser.DefaultItems, plus the
+	// cache when BodyDir is set
+	Items []string
+
+	// Format and OutputDir are passed to Item.OutPut
+	Format    data.OutputFormat
+	OutputDir string
+
+	// ImageDir when set receives the images of the items that hold some, like the
+	// favicon bitmaps and the top site thumbnails, see data.ImageWriter
+	ImageDir string
+
+	// BodyDir when set receives the cached response bodies, see data.BodyWriter
+	BodyDir string
+
+	// PasswordProfile when set writes the logins in the import layout of a password
+	// manager instead of Format, see data.PasswordProfileNames
+	PasswordProfile string
+
+	// Workers bounds how many items are extracted at the same time, default is runtime.NumCPU
+	Workers int
+
+	// Collect when set receives every parsed item instead of Item.OutPut, it is called
+	// from the workers and must be safe for concurrent use
+	Collect func(b browser.Browser, itemName string, item data.Item) error
+}
+
+// ItemReport is the result of a single item of a single browser
+type ItemReport struct {
+	Browser  string
+	Item     string
+	Skipped  bool // the item file does not exist in the profile
+	Duration time.Duration
+	Err      error
+}
+
+// BrowserReport is the result of the secret key initialization of a browser
+type BrowserReport struct {
+	Browser string
+	KeyErr  error
+}
+
+// Report is returned by Run once every job is done
+type Report struct {
+	Browsers []BrowserReport
+	Items    []ItemReport
+	Started  time.Time
+	Finished time.Time
+}
+
+// Err join the key and item errors of the report, skipped items are not errors
+func (r *Report) Err() error {
+	var errs []error
+	for _, b := range r.Browsers {
+		if b.KeyErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Browser, b.KeyErr))
+		}
+	}
+	for _, i := range r.Items {
+		if i.Err != nil && !i.Skipped {
+			errs = append(errs, fmt.Errorf("%s %s: %w", i.Browser, i.Item, i.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type job struct {
+	browser browser.Browser
+	item    string
+	key     []byte
+}
+
+// Run initialize the secret key once per browser and extracts the items with a bounded
+// worker pool. Per item errors are collected in the report, the returned error is only
+// set when the run itself could not complete (output dir, ctx done).
+func Run(ctx context.Context, plan Plan) (*Report, error) {
+	report := &Report{Started: time.Now()}
+	if plan.OutputDir != "" {
+		if err := filemgmt.MakeDir(plan.OutputDir); err != nil {
+			return nil, err
+		}
+	}
+	if plan.ImageDir != "" {
+		if err := filemgmt.MakeDir(plan.ImageDir); err != nil {
+			return nil, err
+		}
+	}
+	if plan.BodyDir != "" {
+		if err := filemgmt.MakeDir(plan.BodyDir); err != nil {
+			return nil, err
+		}
+	}
+	if plan.PasswordProfile != "" {
+		if _, err := data.GetPasswordProfile(plan.PasswordProfile); err != nil {
+			return nil, err
+		}
+	}
+	workers := plan.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan job)
+	results := make(chan ItemReport)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- runJob(ctx, j, plan)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, b := range plan.Browsers {
+			if ctx.Err() != nil {
+				return
+			}
+			err := b.InitSecretKeyContext(ctx)
+			if err != nil {
+				logger.Error(err)
+			}
+			report.Browsers = append(report.Browsers, BrowserReport{Browser: b.GetName(), KeyErr: err})
+			items := plan.Items
+			if len(items) == 0 {
+				items = browser.DefaultItems(b)
+				if plan.BodyDir != "" && hasItem(b, data.ItemNameCache) {
+					items = append(items, data.ItemNameCache)
+				}
+			}
+			for _, name := range items {
+				select {
+				case jobs <- job{browser: b, item: name, key: b.GetSecretKey()}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		report.Items = append(report.Items, r)
+	}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].Browser != report.Items[j].Browser {
+			return report.Items[i].Browser < report.Items[j].Browser
+		}
+		return report.Items[i].Item < report.Items[j].Item
+	})
+	report.Finished = time.Now()
+	return report, ctx.Err()
+}
+
+func hasItem(b browser.Browser, name string) bool {
+	for _, v := range b.ListItems() {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func runJob(ctx context.Context, j job, plan Plan) ItemReport {
+	start := time.Now()
+	r := ItemReport{Browser: j.browser.GetName(), Item: j.item}
+	r.Skipped, r.Err = extractItem(ctx, j, plan)
+	r.Duration = time.Since(start)
+	return r
+}
+
+func extractItem(ctx context.Context, j job, plan Plan) (skipped bool, err error) {
+	item, err := j.browser.GetItem(j.item)
+	if err != nil {
+		return false, err
+	}
+	if err = item.CopyDB(); err != nil {
+		return errors.Is(err, os.ErrNotExist), err
+	}
+	defer func() {
+		if err := item.Release(); err != nil {
+			logger.Debug(err)
+		}
+	}()
+	if _, ok := j.browser.(*browser.Firefox); ok {
+		err = item.FirefoxParseContext(ctx)
+	} else {
+		err = item.ChromeParseContext(ctx, j.key)
+	}
+	if err != nil {
+		return false, err
+	}
+	if w, ok := item.(data.ImageWriter); ok && plan.ImageDir != "" {
+		if err = w.WriteImages(j.browser.GetName(), plan.ImageDir); err != nil {
+			return false, err
+		}
+	}
+	if w, ok := item.(data.BodyWriter); ok && plan.BodyDir != "" {
+		if err = w.WriteBodies(j.browser.GetName(), plan.BodyDir); err != nil {
+			return false, err
+		}
+	}
+	if plan.Collect != nil {
+		return false, plan.Collect(j.browser, j.item, item)
+	}
+	if w, ok := item.(data.ProfileWriter); ok && plan.PasswordProfile != "" {
+		return false, w.OutPutProfile(plan.PasswordProfile, j.browser.GetName(), plan.OutputDir)
+	}
+	return false, item.OutPut(plan.Format, j.browser.GetName(), plan.OutputDir)
+}
@@ -0,0 +1,246 @@
+// Package extract
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/browser"
+	"github.com/teocci/go-chrome-cookies/core/data"
+)
+
+// fakeBrowser hands out fakeItems, the items named in missing fail CopyDB with
+// os.ErrNotExist and the ones in broken fail to parse
+type fakeBrowser struct {
+	name    string
+	items   []string
+	missing map[string]bool
+	broken  map[string]bool
+	keyErr  error
+	running *running
+
+	mu  sync.Mutex
+	got []*fakeItem
+}
+
+func (b *fakeBrowser) InitSecretKey() error { return b.keyErr }
+
+func (b *fakeBrowser) InitSecretKeyContext(ctx context.Context) error { return b.keyErr }
+
+func (b *fakeBrowser) GetName() string { return b.name }
+
+func (b *fakeBrowser) GetProfilePath() string { return "" }
+
+func (b *fakeBrowser) GetSecretKey() []byte { return []byte("key") }
+
+func (b *fakeBrowser) GetAllItems() ([]data.Item, error) { return nil, nil }
+
+func (b *fakeBrowser) GetItem(itemName string) (data.Item, error) {
+	item := &fakeItem{name: itemName, browser: b}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.got = append(b.got, item)
+	return item, nil
+}
+
+func (b *fakeBrowser) ListItems() []string { return b.items }
+
+type fakeItem struct {
+	name    string
+	browser *fakeBrowser
+	parsed  bool
+	output  bool
+}
+
+func (i *fakeItem) ChromeParse(key []byte) error {
+	return i.ChromeParseContext(context.Background(), key)
+}
+
+func (i *fakeItem) ChromeParseContext(ctx context.Context, key []byte) error {
+	if i.browser.running != nil {
+		i.browser.running.enter()
+		defer i.browser.running.leave()
+	}
+	if i.browser.broken[i.name] {
+		return errors.New("broken")
+	}
+	i.parsed = string(key) == "key"
+	return nil
+}
+
+func (i *fakeItem) FirefoxParse() error { return nil }
+
+func (i *fakeItem) FirefoxParseContext(ctx context.Context) error { return nil }
+
+func (i *fakeItem) OutPut(format data.OutputFormat, browser, dir string) error {
+	i.output = true
+	return nil
+}
+
+func (i *fakeItem) CopyDB() error {
+	if i.browser.missing[i.name] {
+		return fmt.Errorf("copy %s: %w", i.name, os.ErrNotExist)
+	}
+	return nil
+}
+
+func (i *fakeItem) Release() error { return nil }
+
+// running records the highest number of items parsed at the same time
+type running struct {
+	now, max int32
+}
+
+func (r *running) enter() {
+	n := atomic.AddInt32(&r.now, 1)
+	for {
+		m := atomic.LoadInt32(&r.max)
+		if n <= m || atomic.CompareAndSwapInt32(&r.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (r *running) leave() { atomic.AddInt32(&r.now, -1) }
+
+func TestRunWorkers(t *testing.T) {
+	r := &running{}
+	items := []string{"cookie", "history", "password", "bookmark"}
+	plan := Plan{
+		Browsers: []browser.Browser{
+			&fakeBrowser{name: "b", items: items, running: r},
+			&fakeBrowser{name: "a", items: items, running: r},
+		},
+		Workers: 2,
+	}
+	var (
+		mu        sync.Mutex
+		collected []string
+	)
+	plan.Collect = func(b browser.Browser, itemName string, item data.Item) error {
+		if !item.(*fakeItem).parsed {
+			t.Errorf("%s %s collected before parse", b.GetName(), itemName)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		collected = append(collected, b.GetName()+" "+itemName)
+		return nil
+	}
+	report, err := Run(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collected) != 8 || len(report.Items) != 8 || len(report.Browsers) != 2 {
+		t.Fatalf("got %d collected, %d item reports and %d browser reports", len(collected), len(report.Items), len(report.Browsers))
+	}
+	if max := atomic.LoadInt32(&r.max); max > 2 {
+		t.Errorf("%d items parsed at the same time, want at most 2", max)
+	}
+	if first, last := report.Items[0], report.Items[7]; first.Browser != "a" || first.Item != "bookmark" || last.Browser != "b" || last.Item != "password" {
+		t.Errorf("report not sorted, got %v first and %v last", first, last)
+	}
+	if report.Err() != nil || report.Finished.Before(report.Started) {
+		t.Errorf("got %v, started %v finished %v", report.Err(), report.Started, report.Finished)
+	}
+}
+
+func TestRunSkippedAndErrors(t *testing.T) {
+	keyErr := errors.New("no key")
+	b := &fakeBrowser{
+		name:    "a",
+		items:   []string{"cookie", "history", "password"},
+		missing: map[string]bool{"history": true},
+		broken:  map[string]bool{"password": true},
+		keyErr:  keyErr,
+	}
+	var outputs int32
+	plan := Plan{Browsers: []browser.Browser{b}, Items: []string{"cookie", "history", "password"}, Workers: 1}
+	plan.Collect = func(b browser.Browser, itemName string, item data.Item) error {
+		atomic.AddInt32(&outputs, 1)
+		return nil
+	}
+	report, err := Run(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byItem := make(map[string]ItemReport)
+	for _, r := range report.Items {
+		byItem[r.Item] = r
+	}
+	if r := byItem["history"]; !r.Skipped || !errors.Is(r.Err, os.ErrNotExist) {
+		t.Errorf("missing item got %+v, want skipped", r)
+	}
+	if r := byItem["password"]; r.Skipped || r.Err == nil {
+		t.Errorf("broken item got %+v, want an error", r)
+	}
+	if outputs != 1 {
+		t.Errorf("collected %d items, want only the parsed one", outputs)
+	}
+	err = report.Err()
+	if !errors.Is(err, keyErr) || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("report error %v, want the key and parse errors without the skipped item", err)
+	}
+}
+
+func TestRunOutPut(t *testing.T) {
+	b := &fakeBrowser{name: "a", items: []string{"cookie"}}
+	report, err := Run(context.Background(), Plan{Browsers: []browser.Browser{b}, OutputDir: t.TempDir()})
+	if err != nil || report.Err() != nil {
+		t.Fatal(err, report.Err())
+	}
+	if len(b.got) != 1 || !b.got[0].output {
+		t.Error("without Collect the item must be written with OutPut")
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = fmt.Sprintf("item%03d", i)
+	}
+	var collected int32
+	plan := Plan{Browsers: []browser.Browser{&fakeBrowser{name: "a", items: items}}, Workers: 1}
+	plan.Collect = func(b browser.Browser, itemName string, item data.Item) error {
+		if atomic.AddInt32(&collected, 1) == 3 {
+			cancel()
+		}
+		return nil
+	}
+	report, err := Run(ctx, plan)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if report == nil || len(report.Items) >= len(items) || report.Finished.IsZero() {
+		t.Errorf("cancelled run extracted every item")
+	}
+}
+
+func TestRunCacheOptIn(t *testing.T) {
+	items := []string{data.ItemNameCookie, data.ItemNameCache}
+	for _, bodyDir := range []string{"", t.TempDir()} {
+		b := &fakeBrowser{name: "a", items: items}
+		plan := Plan{Browsers: []browser.Browser{b}, BodyDir: bodyDir}
+		plan.Collect = func(b browser.Browser, itemName string, item data.Item) error { return nil }
+		report, err := Run(context.Background(), plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 1
+		if bodyDir != "" {
+			want = 2
+		}
+		if len(report.Items) != want {
+			t.Errorf("body dir %q extracted %+v, want %d items", bodyDir, report.Items, want)
+		}
+	}
+}
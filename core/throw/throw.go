@@ -3,7 +3,10 @@
 // Author: teocci@yandex.com on 2021-Aug-15
 package throw
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 const (
 	errItemNotSupported    = `item not supported, default is "all", choose from history|downloads|password|bookmark|cookie`
@@ -15,6 +18,8 @@ const (
 	errPasswordIsEmpty    = "password is empty"
 	errDecryptFailed      = "decrypt failed, password is empty"
 	errDecodeASN1Failed   = "decode ASN1 data failed"
+
+	errPasswordProfileNotSupported = "password profile %s not supported"
 )
 
 func ErrorItemNotSupported() error {
@@ -47,4 +52,8 @@ func ErrorDecryptFailed() error {
 
 func ErrorDecodeASN1Failed() error {
 	return errors.New(errDecodeASN1Failed)
-}
\ No newline at end of file
+}
+
+func ErrorPasswordProfileNotSupported(name string) error {
+	return fmt.Errorf(errPasswordProfileNotSupported, name)
+}
@@ -0,0 +1,265 @@
+// Package timeline
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package timeline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/browser"
+	"github.com/teocci/go-chrome-cookies/core/data"
+	"github.com/teocci/go-chrome-cookies/core/extract"
+)
+
+// event types of the timeline
+const (
+	EventVisit            = "visit"
+	EventLastVisit        = "last_visit"
+	EventDownloadStart    = "download_start"
+	EventDownloadEnd      = "download_end"
+	EventCookieCreated    = "cookie_created"
+	EventLoginCreated     = "login_created"
+	EventLoginLastUsed    = "login_last_used"
+	EventLoginChanged     = "login_password_changed"
+	EventLoginNeverSaved  = "login_never_saved"
+	EventBookmarkAdded    = "bookmark_added"
+	EventBookmarkModified = "bookmark_modified"
+	EventBookmarkLastUsed = "bookmark_last_used"
+)
+
+// DefaultItems are the items Build reads when none are given, the aggregated history is
+// left out because every one of its last visits is already a visit event
+var DefaultItems = []string{
+	data.ItemNameVisit,
+	data.ItemNameDownload,
+	data.ItemNameCookie,
+	data.ItemNamePassword,
+	data.ItemNameBookmark,
+}
+
+// bodyfile time columns, see the sleuthkit body file format
+const (
+	bodyAccess = iota
+	bodyModified
+	bodyChanged
+	bodyCreated
+)
+
+var bodyColumns = map[string]int{
+	EventVisit:            bodyAccess,
+	EventLastVisit:        bodyAccess,
+	EventBookmarkLastUsed: bodyAccess,
+	EventLoginLastUsed:    bodyAccess,
+	EventDownloadEnd:      bodyModified,
+	EventBookmarkModified: bodyModified,
+	EventLoginChanged:     bodyModified,
+	EventLoginNeverSaved:  bodyModified,
+	EventDownloadStart:    bodyCreated,
+	EventCookieCreated:    bodyCreated,
+	EventLoginCreated:     bodyCreated,
+	EventBookmarkAdded:    bodyCreated,
+}
+
+// Event is a single dated record, Time is always UTC
+type Event struct {
+	Time        time.Time
+	Type        string
+	Browser     string
+	Profile     string
+	Item        string
+	Url         string
+	Description string
+}
+
+// Timeline collect the events of items of any browser, it is safe for concurrent use
+type Timeline struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func New() *Timeline {
+	return &Timeline{}
+}
+
+// Build extract the items of every browser and return their events in one timeline,
+// items default to DefaultItems and the items a browser doesn't have are left out. The
+// browsers with a glob profile path are split by profile, see browser.ExpandProfiles, so
+// every event gets the directory of the profile it comes from.
+func Build(ctx context.Context, browsers []browser.Browser, items []string) (*Timeline, *extract.Report, error) {
+	t := New()
+	browsers = browser.ExpandProfiles(browsers)
+	if len(items) == 0 {
+		items = DefaultItems
+	}
+	report := &extract.Report{Started: time.Now()}
+	for _, b := range browsers {
+		plan := extract.Plan{
+			Browsers: []browser.Browser{b},
+			Collect: func(b browser.Browser, itemName string, item data.Item) error {
+				t.Add(b.GetName(), filepath.Base(b.GetProfilePath()), itemName, item)
+				return nil
+			},
+		}
+		for _, name := range items {
+			if hasItem(b, name) {
+				plan.Items = append(plan.Items, name)
+			}
+		}
+		if len(plan.Items) == 0 {
+			continue
+		}
+		r, err := extract.Run(ctx, plan)
+		if r != nil {
+			report.Browsers = append(report.Browsers, r.Browsers...)
+			report.Items = append(report.Items, r.Items...)
+		}
+		if err != nil {
+			report.Finished = time.Now()
+			return t, report, err
+		}
+	}
+	report.Finished = time.Now()
+	return t, report, nil
+}
+
+func hasItem(b browser.Browser, name string) bool {
+	for _, v := range b.ListItems() {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Add turn the records of a parsed item into events and return how many were added,
+// items without dated records add nothing
+func (t *Timeline) Add(browserName, profile, itemName string, item data.Item) int {
+	var events []Event
+	add := func(when time.Time, eventType, url, description string) {
+		if when.IsZero() || when.Unix() <= 0 {
+			return
+		}
+		events = append(events, Event{
+			Time:        when.UTC(),
+			Type:        eventType,
+			Browser:     browserName,
+			Profile:     profile,
+			Item:        itemName,
+			Url:         url,
+			Description: description,
+		})
+	}
+	switch v := item.(type) {
+	case data.RecordItem[data.Visit]:
+		for _, r := range v.Records() {
+			add(r.VisitTime, EventVisit, r.Url, strings.TrimSpace(r.Title+" ("+r.Transition+")"))
+		}
+	case data.RecordItem[data.HistoryEntry]:
+		for _, r := range v.Records() {
+			add(r.LastVisitTime, EventLastVisit, r.Url, fmt.Sprintf("%s (%d visits)", r.Title, r.VisitCount))
+		}
+	case data.RecordItem[data.Download]:
+		for _, r := range v.Records() {
+			add(r.StartTime, EventDownloadStart, r.Url, r.TargetPath)
+			add(r.EndTime, EventDownloadEnd, r.Url, r.TargetPath)
+		}
+	case data.RecordItem[data.Cookie]:
+		for _, r := range v.Records() {
+			add(r.CreateDate, EventCookieCreated, r.Host+r.Path, r.KeyName)
+		}
+	case data.RecordItem[data.Login]:
+		for _, r := range v.Records() {
+			// a never saved site has no credentials, its date is when it was set, for
+			// firefox the modification time of the login-saving permission
+			if r.Blacklisted {
+				add(r.CreateDate, EventLoginNeverSaved, r.LoginUrl, "")
+				continue
+			}
+			add(r.CreateDate, EventLoginCreated, r.LoginUrl, r.UserName)
+			add(r.LastUsed, EventLoginLastUsed, r.LoginUrl, r.UserName)
+			// the password change date starts as the creation date
+			if r.PasswordChanged.After(r.CreateDate) {
+				add(r.PasswordChanged, EventLoginChanged, r.LoginUrl, r.UserName)
+			}
+		}
+	case data.RecordItem[data.Bookmark]:
+		for _, r := range v.Records() {
+			if r.Type == "separator" {
+				continue
+			}
+			name := strings.TrimPrefix(r.Folder+"/"+r.Name, "/")
+			add(r.DateAdded, EventBookmarkAdded, r.URL, name)
+			if r.Type == "folder" {
+				add(r.DateModified, EventBookmarkModified, r.URL, name)
+			}
+			add(r.LastUsed, EventBookmarkLastUsed, r.URL, name)
+		}
+	}
+	t.mu.Lock()
+	t.events = append(t.events, events...)
+	t.mu.Unlock()
+	return len(events)
+}
+
+// Events return a copy of the events ordered by time
+func (t *Timeline) Events() []Event {
+	t.mu.Lock()
+	events := make([]Event, len(t.events))
+	copy(events, t.events)
+	t.mu.Unlock()
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
+
+// WriteCsv write the ordered events as csv with a header row
+func (t *Timeline) WriteCsv(w io.Writer) error {
+	return t.write(data.NewCsvWriter(w))
+}
+
+// WriteJsonLines write the ordered events as one json object per line
+func (t *Timeline) WriteJsonLines(w io.Writer) error {
+	return t.write(data.NewJsonLinesWriter(w))
+}
+
+func (t *Timeline) write(rw data.RecordWriter) error {
+	for _, e := range t.Events() {
+		if err := rw.Write(e); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+// WriteBodyfile write the events in the sleuthkit body file format read by mactime and
+// other timeline tools. The event goes in the name column and its time in the column
+// matching the event: visits are accessed, downloads ending, edits and never saved
+// logins are modified and everything else is created.
+func (t *Timeline) WriteBodyfile(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range t.Events() {
+		var times [4]int64
+		times[bodyColumns[e.Type]] = e.Time.Unix()
+		name := fmt.Sprintf("[%s/%s] %s: %s", e.Browser, e.Profile, e.Type, e.Url)
+		if e.Description != "" {
+			name += " (" + e.Description + ")"
+		}
+		name = strings.NewReplacer("|", "%7C", "\n", " ", "\r", " ").Replace(name)
+		// MD5|name|inode|mode_as_string|UID|GID|size|atime|mtime|ctime|crtime
+		_, err := fmt.Fprintf(bw, "0|%s|0||0|0|0|%d|%d|%d|%d\n", name,
+			times[bodyAccess], times[bodyModified], times[bodyChanged], times[bodyCreated])
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
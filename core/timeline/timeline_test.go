@@ -0,0 +1,162 @@
+// Package timeline
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/teocci/go-chrome-cookies/core/data"
+)
+
+// fakeItem is a parsed item, it only has to expose its records
+type fakeItem struct{}
+
+func (fakeItem) ChromeParse(key []byte) error { return nil }
+
+func (fakeItem) ChromeParseContext(ctx context.Context, key []byte) error { return nil }
+
+func (fakeItem) FirefoxParse() error { return nil }
+
+func (fakeItem) FirefoxParseContext(ctx context.Context) error { return nil }
+
+func (fakeItem) OutPut(format data.OutputFormat, browser, dir string) error { return nil }
+
+func (fakeItem) CopyDB() error { return nil }
+
+func (fakeItem) Release() error { return nil }
+
+type fakeRecords[T any] struct {
+	fakeItem
+	records []T
+}
+
+func (f fakeRecords[T]) Records() []T { return f.records }
+
+var (
+	day1 = time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	day2 = day1.AddDate(0, 0, 1)
+	day3 = day1.AddDate(0, 0, 2)
+)
+
+func testTimeline() *Timeline {
+	t := New()
+	t.Add("Chrome", "Default", data.ItemNameVisit, fakeRecords[data.Visit]{records: []data.Visit{
+		{Url: "https://b.test/", Title: "B", Transition: "typed", VisitTime: day3},
+	}})
+	t.Add("Chrome", "Default", data.ItemNameDownload, fakeRecords[data.Download]{records: []data.Download{
+		{Url: "https://a.test/f.zip", TargetPath: "/tmp/f.zip", StartTime: day1, EndTime: day2},
+	}})
+	return t
+}
+
+func TestAdd(t *testing.T) {
+	tl := New()
+	n := tl.Add("Firefox", "x.default", data.ItemNameCookie, fakeRecords[data.Cookie]{records: []data.Cookie{
+		{Host: ".a.test", Path: "/", KeyName: "sid", CreateDate: day2},
+		// unset and pre 1970 times are not events
+		{Host: ".b.test", Path: "/", KeyName: "old", CreateDate: time.Unix(-1, 0)},
+		{Host: ".c.test", Path: "/", KeyName: "none"},
+	}})
+	if n != 1 {
+		t.Fatalf("added %d cookie events, want 1", n)
+	}
+	n = tl.Add("Firefox", "x.default", data.ItemNamePassword, fakeRecords[data.Login]{records: []data.Login{
+		{LoginUrl: "https://a.test/", UserName: "me", CreateDate: day1, LastUsed: day3, PasswordChanged: day1},
+		{LoginUrl: "https://never.test", Blacklisted: true, CreateDate: day3},
+	}})
+	if n != 3 {
+		t.Errorf("added %d login events, want created, last used and never saved", n)
+	}
+	if n = tl.Add("Firefox", "x.default", "unknown", fakeItem{}); n != 0 {
+		t.Errorf("added %d events for an item without records", n)
+	}
+	events := tl.Events()
+	if len(events) != 4 || events[0].Type != EventLoginCreated || events[1].Type != EventCookieCreated ||
+		events[2].Type != EventLoginLastUsed || events[3].Type != EventLoginNeverSaved {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if e := events[1]; e.Browser != "Firefox" || e.Profile != "x.default" || e.Url != ".a.test/" || e.Description != "sid" {
+		t.Errorf("unexpected cookie event %+v", e)
+	}
+}
+
+func TestWriteCsvAndJsonLines(t *testing.T) {
+	tl := testTimeline()
+	var buf bytes.Buffer
+	if err := tl.WriteCsv(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), "\ufeff")), "\n")
+	if len(lines) != 4 || lines[0] != "Time,Type,Browser,Profile,Item,Url,Description" {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], day1.Format(time.RFC3339)+","+EventDownloadStart+",Chrome,Default,") {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+
+	buf.Reset()
+	if err := tl.WriteJsonLines(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, " ") != "download_start download_end visit" {
+		t.Errorf("unexpected json lines order %v", types)
+	}
+}
+
+func TestWriteBodyfile(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testTimeline().WriteBodyfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	// atime|mtime|ctime|crtime are the last four columns
+	want := []struct {
+		column int
+		when   time.Time
+	}{{bodyCreated, day1}, {bodyModified, day2}, {bodyAccess, day3}}
+	for i, line := range lines {
+		fields := strings.Split(line, "|")
+		if len(fields) != 11 {
+			t.Fatalf("line %q has %d fields", line, len(fields))
+		}
+		for c, v := range fields[7:] {
+			expected := "0"
+			if c == want[i].column {
+				expected = strconv.FormatInt(want[i].when.Unix(), 10)
+			}
+			if v != expected {
+				t.Errorf("line %d column %d is %s, want %s", i, c, v, expected)
+			}
+		}
+	}
+	if !strings.Contains(lines[2], "[Chrome/Default] visit: https://b.test/ (B (typed))") {
+		t.Errorf("unexpected name %q", lines[2])
+	}
+}
+
+func TestBodyColumns(t *testing.T) {
+	for _, eventType := range []string{EventVisit, EventLastVisit, EventDownloadStart, EventDownloadEnd, EventCookieCreated,
+		EventLoginCreated, EventLoginLastUsed, EventLoginChanged, EventLoginNeverSaved, EventBookmarkAdded, EventBookmarkModified, EventBookmarkLastUsed} {
+		if _, ok := bodyColumns[eventType]; !ok {
+			t.Errorf("%s has no body file column", eventType)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package filemgmt
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package filemgmt
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// webkitEpochOffset is the seconds from 1601-01-01 to 1970-01-01, the webkit epoch
+	webkitEpochOffset = 11644473600
+	// macEpochOffset is the seconds from 1970-01-01 to 2001-01-01, the mac absolute time epoch
+	macEpochOffset = 978307200
+
+	microsPerSecond = 1000000
+)
+
+// minTime and maxTime bound the times the converters return, outside of them a time
+// can't be written as json so it is undefined
+var (
+	minTime = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxTime = time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)
+)
+
+// validTime return t in UTC, or the zero time when t is outside minTime and maxTime
+func validTime(t time.Time) time.Time {
+	if t.Before(minTime) || t.After(maxTime) {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// WebKitTime convert the microseconds since 1601-01-01 UTC used by chromium, 0 is unset
+// (session cookies, never visited) and gives the zero time
+func WebKitTime(micros int64) time.Time {
+	if micros == 0 {
+		return time.Time{}
+	}
+	sec := micros/microsPerSecond - webkitEpochOffset
+	nsec := micros % microsPerSecond * 1000
+	return validTime(time.Unix(sec, nsec))
+}
+
+// PRTime convert the microseconds since 1970-01-01 UTC used by firefox, 0 is unset
+func PRTime(micros int64) time.Time {
+	if micros == 0 {
+		return time.Time{}
+	}
+	return validTime(time.UnixMicro(micros))
+}
+
+// UnixMilliTime convert the milliseconds since 1970-01-01 UTC, 0 is unset
+func UnixMilliTime(millis int64) time.Time {
+	if millis == 0 {
+		return time.Time{}
+	}
+	return validTime(time.UnixMilli(millis))
+}
+
+// UnixTime convert the seconds since 1970-01-01 UTC, 0 is unset
+func UnixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return validTime(time.Unix(sec, 0))
+}
+
+// MacAbsoluteTime convert the seconds since 2001-01-01 UTC of core foundation, the
+// fraction is kept to the nanosecond. 0, NaN and infinities give the zero time.
+func MacAbsoluteTime(sec float64) time.Time {
+	if sec == 0 || math.IsNaN(sec) || math.IsInf(sec, 0) {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(sec)
+	if math.Abs(whole) > math.MaxInt64/2 {
+		return time.Time{}
+	}
+	return validTime(time.Unix(int64(whole)+macEpochOffset, int64(math.Round(frac*1e9))))
+}
+
+// ToWebKitTime is the inverse of WebKitTime, the zero time gives 0
+func ToWebKitTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return (t.Unix()+webkitEpochOffset)*microsPerSecond + int64(t.Nanosecond()/1000)
+}
+
+// ToPRTime is the inverse of PRTime, the zero time gives 0
+func ToPRTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro()
+}
+
+// ToUnixMilliTime is the inverse of UnixMilliTime, the zero time gives 0
+func ToUnixMilliTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// ToUnixTime is the inverse of UnixTime, the zero time gives 0
+func ToUnixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// ToMacAbsoluteTime is the inverse of MacAbsoluteTime, the zero time gives 0
+func ToMacAbsoluteTime(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.Unix()-macEpochOffset) + float64(t.Nanosecond())/1e9
+}
@@ -0,0 +1,123 @@
+// Package filemgmt
+// Created by Teocci.
+// Author: teocci@yandex.com on 2026-Oct-19
+package filemgmt
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// validMicros generate microseconds between 1601 and 9999 since the webkit epoch
+type validMicros int64
+
+func (validMicros) Generate(r *rand.Rand, _ int) reflect.Value {
+	lo, hi := ToWebKitTime(time.Date(1601, 1, 1, 0, 0, 0, 1000, time.UTC)), ToWebKitTime(maxTime)
+	return reflect.ValueOf(validMicros(lo + r.Int63n(hi-lo)))
+}
+
+func TestWebKitTimeRoundTrip(t *testing.T) {
+	f := func(v validMicros) bool {
+		return ToWebKitTime(WebKitTime(int64(v))) == int64(v)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebKitTimeMatchesPRTime(t *testing.T) {
+	f := func(v validMicros) bool {
+		micros := int64(v)
+		unixMicros := micros - webkitEpochOffset*microsPerSecond
+		if unixMicros == 0 {
+			return true
+		}
+		return WebKitTime(micros).Equal(PRTime(unixMicros))
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebKitTimeMonotonic(t *testing.T) {
+	f := func(a, b validMicros) bool {
+		if a > b {
+			a, b = b, a
+		}
+		return !WebKitTime(int64(a)).After(WebKitTime(int64(b)))
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnixRoundTrips(t *testing.T) {
+	f := func(sec int32, micros uint32) bool {
+		if sec == 0 {
+			return UnixTime(0).IsZero()
+		}
+		us := int64(sec)*microsPerSecond + int64(micros%microsPerSecond)
+		ms := us / 1000
+		return ToUnixTime(UnixTime(int64(sec))) == int64(sec) &&
+			ToPRTime(PRTime(us)) == us &&
+			ToUnixMilliTime(UnixMilliTime(ms)) == ms
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMacAbsoluteTimeRoundTrip(t *testing.T) {
+	f := func(sec int32, micros uint32) bool {
+		v := float64(sec) + float64(micros%microsPerSecond)/microsPerSecond
+		if v == 0 {
+			return MacAbsoluteTime(v).IsZero()
+		}
+		// a float64 keeps about a microsecond around the current date
+		return math.Abs(ToMacAbsoluteTime(MacAbsoluteTime(v))-v) < 1e-6
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTimeConvertersKnownValues(t *testing.T) {
+	want := time.Date(2021, 8, 14, 10, 30, 15, 123456000, time.UTC)
+	for name, got := range map[string]time.Time{
+		"webkit": WebKitTime(13273410615123456),
+		"prtime": PRTime(1628937015123456),
+		"millis": UnixMilliTime(1628937015123).Add(456 * time.Microsecond),
+		"unix":   UnixTime(1628937015).Add(123456 * time.Microsecond),
+		"mac":    MacAbsoluteTime(650629815.123456).Round(time.Microsecond),
+	} {
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestTimeConvertersUnset(t *testing.T) {
+	for name, got := range map[string]time.Time{
+		"webkit zero":     WebKitTime(0),
+		"webkit overflow": WebKitTime(math.MaxInt64),
+		"prtime zero":     PRTime(0),
+		"millis zero":     UnixMilliTime(0),
+		"unix zero":       UnixTime(0),
+		"unix overflow":   UnixTime(math.MaxInt64),
+		"mac zero":        MacAbsoluteTime(0),
+		"mac nan":         MacAbsoluteTime(math.NaN()),
+		"mac inf":         MacAbsoluteTime(math.Inf(1)),
+	} {
+		if !got.IsZero() {
+			t.Errorf("%s: got %s, want the zero time", name, got)
+		}
+	}
+	if ToWebKitTime(time.Time{}) != 0 || ToPRTime(time.Time{}) != 0 || ToUnixTime(time.Time{}) != 0 ||
+		ToUnixMilliTime(time.Time{}) != 0 || ToMacAbsoluteTime(time.Time{}) != 0 {
+		t.Error("the zero time must convert to 0")
+	}
+}
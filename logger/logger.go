@@ -34,11 +34,10 @@ func (l Level) String() string {
 	default:
 		panic("unhandled default case")
 	}
-	return ""
 }
 
 var (
-	formatLogger *Logger
+	formatLogger = newLog(os.Stdout).setLevel(LevelError).setFlags(log.Lshortfile)
 	levelMap     = map[string]Level{
 		levelDebugName: LevelDebug,
 		levelErrorName: LevelError,